@@ -0,0 +1,35 @@
+// Package webglwater holds the default assets, shaders and frontend files built
+// into the binary, so the server can run without any files next to it.
+package webglwater
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed assets/dudvmap.png assets/normalmap.png assets/stone-texture.png assets/meshes.json
+//go:embed web/shaders web/static
+var files embed.FS
+
+// Assets returns the default textures and meshes
+func Assets() fs.FS {
+	return sub("assets")
+}
+
+// Shaders returns the shader sources
+func Shaders() fs.FS {
+	return sub("web/shaders")
+}
+
+// Static returns the frontend files
+func Static() fs.FS {
+	return sub("web/static")
+}
+
+func sub(dir string) fs.FS {
+	files, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err) // Only fails for invalid paths, and these are constant
+	}
+	return files
+}
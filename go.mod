@@ -3,7 +3,12 @@ module github.com/ku3ppi/webgl-water
 go 1.22
 
 require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/gorilla/mux v1.8.0
 	github.com/gorilla/websocket v1.5.0
 	github.com/joho/godotenv v1.5.1
+	github.com/klauspost/compress v1.18.0
 )
+
+require golang.org/x/sys v0.13.0 // indirect
@@ -0,0 +1,264 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// Analytics only ever see the random session ID the frontend generates per
+// browser tab; no addresses or other identifying data are recorded.
+
+// sessionHeader carries the session ID on REST requests. WebSocket clients send it as ?session=.
+const sessionHeader = "X-Session-ID"
+
+// maxAnalyticsSessions bounds the memory used by analytics. Sessions beyond it are not tracked.
+const maxAnalyticsSessions = 10000
+
+// validSessionID matches the session IDs accepted from clients
+var validSessionID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// SessionSummary describes how one session explored the demo
+type SessionSummary struct {
+	ID         string         `json:"id"`
+	Started    time.Time      `json:"started"`
+	LastSeen   time.Time      `json:"lastSeen"`
+	TimeSpent  float64        `json:"timeSpent"`  // Seconds with the demo open
+	Updates    int            `json:"updates"`    // Accepted parameter changes
+	Parameters map[string]int `json:"parameters"` // Number of changes per parameter
+}
+
+// AnalyticsSummary aggregates all sessions
+type AnalyticsSummary struct {
+	Sessions         []SessionSummary `json:"sessions"`
+	AverageTimeSpent float64          `json:"averageTimeSpent"` // Seconds per session
+	Parameters       map[string]int   `json:"parameters"`       // Number of sessions that changed each parameter
+}
+
+// sessionStats is the running record of one session
+type sessionStats struct {
+	summary   SessionSummary
+	connected int       // Open WebSocket connections
+	since     time.Time // When the first of them opened
+	spent     time.Duration
+}
+
+// analytics collects per-session interaction data
+type analytics struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionStats
+}
+
+func newAnalytics() *analytics {
+	return &analytics{sessions: make(map[string]*sessionStats)}
+}
+
+// EnableAnalytics records which parameters each session changes and how long it
+// stays, and serves the summary at /analytics and /analytics.csv
+func (s *Server) EnableAnalytics() {
+	s.analytics = newAnalytics()
+}
+
+// clientSessionID returns the session ID a client sent, or "" if it sent none or an invalid one
+func clientSessionID(r *http.Request) string {
+	id := r.URL.Query().Get("session")
+	if id == "" {
+		id = r.Header.Get(sessionHeader)
+	}
+	if !validSessionID.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// session returns the stats of id, creating them if there is room. a.mu must be held.
+func (a *analytics) session(id string, now time.Time) *sessionStats {
+	stats, ok := a.sessions[id]
+	if !ok {
+		if len(a.sessions) >= maxAnalyticsSessions {
+			return nil
+		}
+		stats = &sessionStats{summary: SessionSummary{ID: id, Started: now, Parameters: make(map[string]int)}}
+		a.sessions[id] = stats
+	}
+	stats.summary.LastSeen = now
+	return stats
+}
+
+// connect starts counting the time a session spends with the demo open
+func (a *analytics) connect(id string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if stats := a.session(id, now); stats != nil {
+		if stats.connected == 0 {
+			stats.since = now
+		}
+		stats.connected++
+	}
+}
+
+// disconnect stops counting time once the session's last connection closes
+func (a *analytics) disconnect(id string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if stats := a.session(id, now); stats != nil && stats.connected > 0 {
+		stats.connected--
+		if stats.connected == 0 {
+			stats.spent += now.Sub(stats.since)
+		}
+	}
+}
+
+// touch counts changes to the given parameters
+func (a *analytics) touch(id string, now time.Time, parameters []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if stats := a.session(id, now); stats != nil {
+		for _, parameter := range parameters {
+			stats.summary.Parameters[parameter]++
+			stats.summary.Updates++
+		}
+	}
+}
+
+// summary returns the aggregate of all sessions, ordered by start time
+func (a *analytics) summary(now time.Time) AnalyticsSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := AnalyticsSummary{
+		Sessions:   make([]SessionSummary, 0, len(a.sessions)),
+		Parameters: make(map[string]int),
+	}
+	var total float64
+	for _, stats := range a.sessions {
+		session := stats.summary
+		spent := stats.spent
+		if stats.connected > 0 {
+			spent += now.Sub(stats.since)
+		}
+		session.TimeSpent = spent.Seconds()
+		session.Parameters = make(map[string]int, len(stats.summary.Parameters))
+		for parameter, count := range stats.summary.Parameters {
+			session.Parameters[parameter] = count
+			result.Parameters[parameter]++
+		}
+		result.Sessions = append(result.Sessions, session)
+		total += session.TimeSpent
+	}
+	if len(result.Sessions) > 0 {
+		result.AverageTimeSpent = total / float64(len(result.Sessions))
+	}
+
+	sort.Slice(result.Sessions, func(i, j int) bool {
+		if result.Sessions[i].Started.Equal(result.Sessions[j].Started) {
+			return result.Sessions[i].ID < result.Sessions[j].ID
+		}
+		return result.Sessions[i].Started.Before(result.Sessions[j].Started)
+	})
+	return result
+}
+
+// recordParameters counts the parameters changed by msgs for the request's session
+func (s *Server) recordParameters(r *http.Request, msgs ...state.Message) {
+	if s.analytics == nil {
+		return
+	}
+	id := clientSessionID(r)
+	if id == "" {
+		return
+	}
+
+	var parameters []string
+	for _, msg := range msgs {
+		if parameter := messageParameter(msg); parameter != "" {
+			parameters = append(parameters, parameter)
+		}
+	}
+	s.analytics.touch(id, s.clock.Now(), parameters)
+}
+
+// messageParameter returns the name of the parameter msg changes, as named in the API
+func messageParameter(msg state.Message) string {
+	switch msg.(type) {
+	case *state.SetReflectivityMessage:
+		return "reflectivity"
+	case *state.SetFresnelMessage:
+		return "fresnelStrength"
+	case *state.SetWaveSpeedMessage:
+		return "waveSpeed"
+	case *state.UseReflectionMessage:
+		return "useReflection"
+	case *state.UseRefractionMessage:
+		return "useRefraction"
+	case *state.SetExposureMessage:
+		return "exposure"
+	case *state.SetGammaMessage:
+		return "gamma"
+	case *state.SetToneMappingMessage:
+		return "toneMapping"
+	case *state.SetSurfaceLayerMessage, *state.RemoveSurfaceLayerMessage:
+		return "layers"
+	case *state.StartFlyThroughMessage, *state.StopFlyThroughMessage:
+		return "flyThrough"
+	case *state.MouseDownMessage, *state.ZoomMessage, *state.KeyUpMessage, *state.SetCameraModeMessage,
+		*state.RecallCameraPresetMessage:
+		// Counted per drag or key press rather than per mouse move or key repeat,
+		// which would drown out everything else
+		return "camera"
+	default:
+		return ""
+	}
+}
+
+// handleGetAnalytics returns the analytics summary
+func (s *Server) handleGetAnalytics(w http.ResponseWriter, r *http.Request) {
+	if s.analytics == nil {
+		http.Error(w, "Analytics are not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.analytics.summary(s.clock.Now()))
+}
+
+// handleExportAnalytics returns one CSV row per session, with a column per parameter
+func (s *Server) handleExportAnalytics(w http.ResponseWriter, r *http.Request) {
+	if s.analytics == nil {
+		http.Error(w, "Analytics are not enabled", http.StatusNotFound)
+		return
+	}
+	summary := s.analytics.summary(s.clock.Now())
+
+	parameters := make([]string, 0, len(summary.Parameters))
+	for parameter := range summary.Parameters {
+		parameters = append(parameters, parameter)
+	}
+	sort.Strings(parameters)
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="analytics.csv"`)
+	out := csv.NewWriter(w)
+	out.Write(append([]string{"session", "started", "last_seen", "time_spent_seconds", "updates"}, parameters...))
+	for _, session := range summary.Sessions {
+		row := []string{
+			session.ID,
+			session.Started.UTC().Format(time.RFC3339),
+			session.LastSeen.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(session.TimeSpent, 'f', 1, 64),
+			strconv.Itoa(session.Updates),
+		}
+		for _, parameter := range parameters {
+			row = append(row, strconv.Itoa(session.Parameters[parameter]))
+		}
+		out.Write(row)
+	}
+	out.Flush()
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SetAssetMemoryBudget limits the memory the asset manager spends on mesh
+// encodings, mipmaps and resized textures to budget bytes, 0 for no limit.
+// The least recently used data is dropped beyond it, except that of pinned
+// assets, which include the water, terrain and default textures.
+func (s *Server) SetAssetMemoryBudget(budget int64, pinned ...string) {
+	for _, name := range pinned {
+		s.assets.PinAsset(name)
+	}
+	s.assets.SetMemoryBudget(budget)
+}
+
+// handleGetAssetCache returns the memory used by cached asset data
+func (s *Server) handleGetAssetCache(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.assetsFor(r).CacheStats())
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// handleGetAudioList returns the names of all sounds
+func (s *Server) handleGetAudioList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"audio": s.assetsFor(r).ListAudio(),
+	})
+}
+
+// handleGetAudio returns the metadata of a sound
+func (s *Server) handleGetAudio(w http.ResponseWriter, r *http.Request) {
+	audio, err := s.assetsFor(r).GetAudio(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(audio)
+}
+
+// handlePutAudio registers a sound from a body such as
+// {"filePath": "rain.ogg", "loop": true, "volume": 0.5}, or changes the
+// playback settings of a registered sound when filePath is left out. Missing
+// playback fields keep their current values.
+func (s *Server) handlePutAudio(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	name := r.PathValue("name")
+	req := struct {
+		FilePath string `json:"filePath"`
+		assets.AudioPlayback
+	}{AudioPlayback: assets.AudioPlayback{Volume: 1}}
+	existing, err := collection.GetAudio(name)
+	if err == nil {
+		req.FilePath = existing.FilePath
+		req.Loop, req.Volume = existing.Loop, existing.Volume
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if existing != nil && req.FilePath == existing.FilePath {
+		_, err = collection.SetAudioPlayback(name, req.AudioPlayback)
+	} else if req.FilePath == "" {
+		http.Error(w, "filePath is required to register a sound", http.StatusBadRequest)
+		return
+	} else {
+		err = collection.RegisterAudioFile(name, req.FilePath, req.AudioPlayback)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindAudio, Name: name})
+
+	audio, _ := collection.GetAudio(name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(audio)
+}
+
+// handleDeleteAudio unregisters a sound; its file is kept
+func (s *Server) handleDeleteAudio(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !s.assetsFor(r).RemoveAudio(name) {
+		http.Error(w, fmt.Sprintf("audio '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindAudio, Name: name})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleStreamAudio serves the file of a sound. Range requests let players
+// seek and start playing before the whole file has downloaded.
+func (s *Server) handleStreamAudio(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	audio, err := collection.GetAudio(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", audio.MimeType)
+	s.serveCachedFile(w, r, collection.Files(), s.assetSource(r), audio.FilePath, s.cachePolicy.Assets)
+}
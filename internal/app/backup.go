@@ -0,0 +1,155 @@
+package app
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ku3ppi/webgl-water/internal/codec"
+)
+
+// backupCheckpointName is the name of the state checkpoint inside a backup archive
+const backupCheckpointName = "state.checkpoint"
+
+// backupContentTypes maps codec names to the content type of backup archives.
+// Other codecs are sent as application/octet-stream.
+var backupContentTypes = map[string]string{
+	codec.NameNone: "application/x-tar",
+	codec.NameGzip: "application/gzip",
+	codec.NameZstd: "application/zstd",
+}
+
+// EnableAdmin turns on the backup and restore endpoints, which answer only
+// requests carrying "Authorization: Bearer <token>". They answer 404 until
+// this is called, as a restore replaces the state of every client.
+func (s *Server) EnableAdmin(token string) error {
+	if token == "" {
+		return fmt.Errorf("admin token must not be empty")
+	}
+	s.adminToken = token
+	return nil
+}
+
+// withAdminToken rejects requests without the admin token, and all requests
+// if EnableAdmin was not called
+func (s *Server) withAdminToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			http.Error(w, "Admin endpoints are not enabled", http.StatusNotFound)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleBackup streams a tarball containing a consistent checkpoint of the live
+// state, compressed with the snapshot codec
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	// The checkpoint is taken under the state lock, so it is consistent on its own
+	var checkpoint bytes.Buffer
+	if err := s.appState.WriteCheckpoint(&checkpoint); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	c := s.compression.snapshots
+	encoder, err := c.NewWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType, ok := backupContentTypes[c.Name()]
+	if !ok {
+		contentType = "application/octet-stream"
+	}
+	now := s.clock.Now()
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"webgl-water-backup-%s.tar%s\"", now.Format("20060102-150405"), c.Extension()))
+
+	tw := tar.NewWriter(encoder)
+
+	header := &tar.Header{
+		Name:    backupCheckpointName,
+		Mode:    0644,
+		Size:    int64(checkpoint.Len()),
+		ModTime: now,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return
+	}
+	if _, err := tw.Write(checkpoint.Bytes()); err != nil {
+		return
+	}
+
+	tw.Close()
+	encoder.Close()
+}
+
+// handleRestore restores the live state from a backup tarball produced by handleBackup.
+// The codec is taken from the Content-Encoding header if given, and otherwise
+// recognized from the archive's first bytes.
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	body := bufio.NewReader(r.Body)
+	c, err := codec.Detect(body, s.compression.snapshots)
+	if encoding := r.Header.Get("Content-Encoding"); encoding != "" {
+		c, err = codec.Lookup(encoding)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder, err := c.NewReader(body)
+	if err != nil {
+		http.Error(w, "Invalid backup archive", http.StatusBadRequest)
+		return
+	}
+	defer decoder.Close()
+
+	tr := tar.NewReader(decoder)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			http.Error(w, "Backup archive has no state checkpoint", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Invalid backup archive", http.StatusBadRequest)
+			return
+		}
+		if header.Name != backupCheckpointName {
+			continue
+		}
+
+		if err := s.appState.ReadCheckpoint(tr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		break
+	}
+
+	// Persist the restored state right away so a restart does not undo the restore
+	if s.checkpointPath != "" {
+		if err := s.appState.SaveCheckpoint(s.checkpointPath, s.compression.checkpoints); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "restored"})
+}
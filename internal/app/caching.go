@@ -0,0 +1,140 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Files under /assets, /shaders and /static are served with an ETag derived
+// from their content and, where the file system knows it, a Last-Modified date.
+// net/http answers If-None-Match and If-Modified-Since with 304 Not Modified
+// once these headers are set, so browsers revalidate instead of re-downloading.
+
+// CachePolicy holds the Cache-Control header sent for each group of files.
+// An empty value sends no Cache-Control header.
+type CachePolicy struct {
+	Assets  string `json:"assets"`  // Textures, meshes and mip levels under /assets
+	Shaders string `json:"shaders"` // Shader sources under /shaders
+	Static  string `json:"static"`  // Frontend files under /static
+}
+
+// DefaultCachePolicy lets browsers keep every file but revalidate it on each
+// use, since file URLs do not change when the files do
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{
+		Assets:  "no-cache",
+		Shaders: "no-cache",
+		Static:  "no-cache",
+	}
+}
+
+// SetCachePolicy replaces the Cache-Control headers sent with files
+func (s *Server) SetCachePolicy(policy CachePolicy) {
+	s.cachePolicy = policy
+}
+
+// etagCache remembers the ETags of files, so each is hashed again only when its
+// size or modification time changes
+type etagCache struct {
+	mu      sync.Mutex
+	entries map[string]etagEntry // By source and file name
+}
+
+type etagEntry struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+func newETagCache() *etagCache {
+	return &etagCache{entries: make(map[string]etagEntry)}
+}
+
+// fileETag returns the ETag of a regular file, or "" if it cannot be read.
+// source names fsys, so files with the same name in different places are told apart.
+func (c *etagCache) fileETag(fsys fs.FS, source, name string) string {
+	info, err := fs.Stat(fsys, name)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	key := source + "/" + name
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.etag
+	}
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return ""
+	}
+	etag := formatETag(hash.Sum(nil))
+
+	c.mu.Lock()
+	c.entries[key] = etagEntry{size: info.Size(), modTime: info.ModTime(), etag: etag}
+	c.mu.Unlock()
+	return etag
+}
+
+// contentETag returns the ETag of generated content
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return formatETag(sum[:])
+}
+
+// formatETag quotes the first 128 bits of a hash as a strong ETag
+func formatETag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// serveCachedFile serves a file from fsys (named source) with caching headers
+func (s *Server) serveCachedFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, source, name, cacheControl string) {
+	if etag := s.etags.fileETag(fsys, source, name); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+	http.ServeFileFS(w, r, fsys, name)
+}
+
+// serveCachedContent serves generated content with caching headers
+func serveCachedContent(w http.ResponseWriter, r *http.Request, data []byte, cacheControl string) {
+	w.Header().Set("ETag", contentETag(data))
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// withStaticCaching adds caching headers to the static file server's responses
+func (s *Server) withStaticCaching(fsys fs.FS, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		if etag := s.etags.fileETag(fsys, "static", name); etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if s.cachePolicy.Static != "" {
+			w.Header().Set("Cache-Control", s.cachePolicy.Static)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
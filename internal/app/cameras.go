@@ -0,0 +1,94 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// handleGetCameras lists the pose of every camera and names the active one
+func (s *Server) handleGetCameras(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.appState.Snapshot()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"cameras": snapshot.Cameras,
+		"active":  snapshot.ActiveCamera,
+	})
+}
+
+// handlePutCamera places the named camera, adding it if needed
+func (s *Server) handlePutCamera(w http.ResponseWriter, r *http.Request) {
+	var pose state.CameraPreset
+	if err := json.NewDecoder(r.Body).Decode(&pose); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	msg := &state.SetCameraPoseMessage{Name: r.PathValue("name"), Pose: pose}
+	if err := state.ValidateMessage(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The name and pose are valid, so the only failure left is one camera too many
+	if err := s.appState.Update(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.appState.GetCameras()[msg.Name])
+}
+
+// handleUpdateNamedCamera applies a CameraUpdateRequest to the named camera
+// rather than the active one
+func (s *Server) handleUpdateNamedCamera(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, exists := s.appState.GetCameras()[name]; !exists {
+		http.Error(w, fmt.Sprintf("camera '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	msgs, ok := s.readCameraUpdate(w, r)
+	if !ok {
+		return
+	}
+	for i, msg := range msgs {
+		msgs[i] = &state.CameraMessage{Camera: name, Message: msg}
+	}
+	if !s.applyMessages(w, r, msgs) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
+
+// handleActivateCamera makes the named camera the one state updates show
+func (s *Server) handleActivateCamera(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.appState.Update(&state.SetActiveCameraMessage{Name: name}); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active": name,
+	})
+}
+
+// handleDeleteCamera removes a camera added through the API
+func (s *Server) handleDeleteCamera(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, exists := s.appState.GetCameras()[name]; !exists {
+		http.Error(w, fmt.Sprintf("camera '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	// The camera exists, so the only remaining failure is it being built in
+	if err := s.appState.Update(&state.RemoveCameraMessage{Name: name}); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
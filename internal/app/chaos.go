@@ -0,0 +1,264 @@
+package app
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// NetworkConditions describes the bad network a ChaosHub simulates for every client
+type NetworkConditions struct {
+	Latency     time.Duration `json:"latency"`     // Delay added to every message
+	Jitter      time.Duration `json:"jitter"`      // Random extra delay of up to this much per message
+	DropRate    float64       `json:"dropRate"`    // Probability (0-1) that a message is never delivered
+	ReorderRate float64       `json:"reorderRate"` // Probability (0-1) that a message is overtaken by later ones
+	Seed        int64         `json:"seed"`        // Seed for the random decisions, 0 for a random seed
+}
+
+// chaosReorderDelay is the least a reordered message is held back, so at least
+// one later update at the 60 Hz tick rate overtakes it
+const chaosReorderDelay = 50 * time.Millisecond
+
+// Validate checks that delays are not negative and rates are probabilities
+func (n NetworkConditions) Validate() error {
+	if n.Latency < 0 || n.Jitter < 0 {
+		return fmt.Errorf("latency and jitter must not be negative")
+	}
+	if n.DropRate < 0 || n.DropRate > 1 || n.ReorderRate < 0 || n.ReorderRate > 1 {
+		return fmt.Errorf("drop and reorder rates must be between 0 and 1")
+	}
+	return nil
+}
+
+// ChaosHub is a debug Hub that delivers messages through another Hub as if over
+// a bad network: late, unevenly spaced, lost or out of order. Each client gets
+// its own delivery queue, so one slow client does not hold up the others.
+// Messages that are not reordered keep their order, like on a real connection.
+//
+// Delivery happens after Send and Broadcast return, so write errors are
+// returned from the next Broadcast instead, and the failed connection is closed.
+type ChaosHub struct {
+	inner      Hub
+	conditions NetworkConditions
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	links  map[*websocket.Conn]*chaosLink
+	errors []error // Delivery errors not yet returned by Broadcast
+}
+
+// NewChaosHub wraps inner so messages to its clients suffer the given conditions
+func NewChaosHub(inner Hub, conditions NetworkConditions) *ChaosHub {
+	seed := conditions.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ChaosHub{
+		inner:      inner,
+		conditions: conditions,
+		rand:       rand.New(rand.NewSource(seed)),
+		links:      make(map[*websocket.Conn]*chaosLink),
+	}
+}
+
+// Register adds a connection and starts its delivery queue
+func (h *ChaosHub) Register(conn *websocket.Conn) {
+	h.inner.Register(conn)
+
+	link := newChaosLink()
+	h.mu.Lock()
+	h.links[conn] = link
+	h.mu.Unlock()
+	go h.deliver(conn, link)
+}
+
+// Unregister removes a connection, discarding messages still in flight to it
+func (h *ChaosHub) Unregister(conn *websocket.Conn) {
+	h.mu.Lock()
+	if link, ok := h.links[conn]; ok {
+		close(link.done)
+		delete(h.links, conn)
+	}
+	h.mu.Unlock()
+
+	h.inner.Unregister(conn)
+}
+
+// Send queues msg for a single connection. Connections that are not registered
+// are written to right away.
+func (h *ChaosHub) Send(conn *websocket.Conn, msg interface{}) error {
+	h.mu.Lock()
+	link, ok := h.links[conn]
+	if ok {
+		h.enqueue(link, msg)
+	}
+	h.mu.Unlock()
+
+	if !ok {
+		return h.inner.Send(conn, msg)
+	}
+	return nil
+}
+
+// Broadcast queues the message returned by msg for every connection, skipping
+// connections for which it returns nil. It returns the delivery errors since the last call.
+func (h *ChaosHub) Broadcast(msg func(conn *websocket.Conn) interface{}) []error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for conn, link := range h.links {
+		if m := msg(conn); m != nil {
+			h.enqueue(link, m)
+		}
+	}
+
+	errs := h.errors
+	h.errors = nil
+	return errs
+}
+
+// Count returns the number of connected clients
+func (h *ChaosHub) Count() int {
+	return h.inner.Count()
+}
+
+// enqueue schedules msg on link, or drops it. h.mu must be held.
+func (h *ChaosHub) enqueue(link *chaosLink, msg interface{}) {
+	if h.rand.Float64() < h.conditions.DropRate {
+		return
+	}
+
+	due := time.Now().Add(h.conditions.Latency)
+	if h.conditions.Jitter > 0 {
+		due = due.Add(time.Duration(h.rand.Int63n(int64(h.conditions.Jitter) + 1)))
+	}
+
+	link.mu.Lock()
+	if h.rand.Float64() < h.conditions.ReorderRate {
+		due = due.Add(max(h.conditions.Jitter, chaosReorderDelay))
+	} else {
+		// In-order messages never overtake each other, however the jitter falls
+		if due.Before(link.lastDue) {
+			due = link.lastDue
+		}
+		link.lastDue = due
+	}
+	link.seq++
+	heap.Push(&link.queue, chaosMessage{due: due, seq: link.seq, msg: msg})
+	link.mu.Unlock()
+
+	select {
+	case link.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver writes the messages queued on link to conn as they fall due, until
+// the connection is unregistered or a write fails
+func (h *ChaosHub) deliver(conn *websocket.Conn, link *chaosLink) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		link.mu.Lock()
+		now := time.Now()
+		var due []interface{}
+		for len(link.queue) > 0 && !link.queue[0].due.After(now) {
+			due = append(due, heap.Pop(&link.queue).(chaosMessage).msg)
+		}
+		wait := time.Hour
+		if len(link.queue) > 0 {
+			wait = link.queue[0].due.Sub(now)
+		}
+		link.mu.Unlock()
+
+		for _, msg := range due {
+			if err := h.inner.Send(conn, msg); err != nil {
+				h.mu.Lock()
+				h.errors = append(h.errors, err)
+				h.mu.Unlock()
+				conn.Close()
+				return
+			}
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-link.done:
+			return
+		case <-link.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// chaosLink is the delivery queue of one client
+type chaosLink struct {
+	mu      sync.Mutex
+	queue   chaosQueue
+	lastDue time.Time // Due time of the last in-order message
+	seq     int
+	wake    chan struct{} // Signals that a message was queued
+	done    chan struct{} // Closed when the client is unregistered
+}
+
+func newChaosLink() *chaosLink {
+	return &chaosLink{
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+}
+
+// chaosMessage is a message waiting to be delivered
+type chaosMessage struct {
+	due time.Time
+	seq int // Keeps messages due at the same time in the order they were sent
+	msg interface{}
+}
+
+// chaosQueue is a min-heap of messages ordered by due time
+type chaosQueue []chaosMessage
+
+func (q chaosQueue) Len() int { return len(q) }
+
+func (q chaosQueue) Less(i, j int) bool {
+	if q[i].due.Equal(q[j].due) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].due.Before(q[j].due)
+}
+
+func (q chaosQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *chaosQueue) Push(x interface{}) { *q = append(*q, x.(chaosMessage)) }
+
+func (q *chaosQueue) Pop() interface{} {
+	old := *q
+	msg := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return msg
+}
+
+// EnableNetworkChaos makes the server deliver WebSocket messages as if over a
+// bad network, to exercise client-side prediction and interpolation.
+// It must be called before clients connect.
+func (s *Server) EnableNetworkChaos(conditions NetworkConditions) error {
+	if err := conditions.Validate(); err != nil {
+		return err
+	}
+	s.hub = NewChaosHub(s.hub, conditions)
+	s.logger.Printf("Simulating network: %v latency, %v jitter, %.0f%% drops, %.0f%% reordered",
+		conditions.Latency, conditions.Jitter, conditions.DropRate*100, conditions.ReorderRate*100)
+	return nil
+}
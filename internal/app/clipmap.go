@@ -0,0 +1,16 @@
+package app
+
+import (
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// EnableClipmap makes clients render the water as geometry clipmaps around the
+// camera instead of the fixed water plane. The layout is sent with every state update.
+func (s *Server) EnableClipmap(config state.ClipmapConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	s.assets.CreateClipmapGrid(config.Cells)
+	s.clipmap = &config
+	return nil
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"time"
+)
+
+// Clock is the source of time for the server's loops and rate limits.
+// Tests can substitute a fake clock to step the simulation deterministically.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on a channel until stopped
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// systemClock is the Clock backed by the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+// systemTicker adapts time.Ticker to the Ticker interface
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t systemTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// Asset routes serve the active collection. Prefixing an API or asset path
+// with /collections/{name} serves it from that collection instead, e.g.
+// /api/collections/arctic/meshes or /assets/collections/arctic/stone-texture.png.
+
+// collectionKey is the context key of the collection a request is scoped to
+type collectionKey struct{}
+
+// scopedCollection is the collection named in a request's path
+type scopedCollection struct {
+	name   string
+	assets *assets.Assets
+}
+
+// RegisterCollection adds the assets in dir as the named collection
+func (s *Server) RegisterCollection(name, dir string) error {
+	return s.assets.RegisterCollection(name, dir)
+}
+
+// SetActiveCollection makes the asset routes serve the named collection,
+// loading it if needed, and tells WebSocket clients to reload their assets
+func (s *Server) SetActiveCollection(name string) error {
+	if name == "" {
+		name = assets.DefaultCollection
+	}
+	if !s.assets.LoadProgress().Ready {
+		return fmt.Errorf("collection '%s' cannot be activated before the assets are loaded", name)
+	}
+	collection, err := s.assets.Collection(name)
+	if err != nil {
+		return err
+	}
+
+	s.collectionMu.Lock()
+	changed := s.activeCollection != name
+	s.activeCollection, s.activeAssets = name, collection
+	s.collectionMu.Unlock()
+
+	if changed {
+		s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindCollection, Name: name})
+	}
+	return nil
+}
+
+// active returns the name and asset manager of the active collection
+func (s *Server) active() (string, *assets.Assets) {
+	s.collectionMu.RLock()
+	defer s.collectionMu.RUnlock()
+	if s.activeAssets == nil {
+		return assets.DefaultCollection, s.assets
+	}
+	return s.activeCollection, s.activeAssets
+}
+
+// terrainHeightAt returns the height of the active collection's terrain at
+// (x, z), for the walk camera
+func (s *Server) terrainHeightAt(x, z float32) (float32, bool) {
+	_, collection := s.active()
+	return collection.TerrainHeightAt(x, z)
+}
+
+// assetsFor returns the collection a request is served from: the one named in
+// its path, or the active collection
+func (s *Server) assetsFor(r *http.Request) *assets.Assets {
+	if scoped, ok := r.Context().Value(collectionKey{}).(scopedCollection); ok {
+		return scoped.assets
+	}
+	_, collection := s.active()
+	return collection
+}
+
+// assetSource names the files of the collection a request is served from, to
+// keep the ETags of equally named files in different collections apart
+func (s *Server) assetSource(r *http.Request) string {
+	name, _ := s.active()
+	if scoped, ok := r.Context().Value(collectionKey{}).(scopedCollection); ok {
+		name = scoped.name
+	}
+	if name == assets.DefaultCollection {
+		return "manager"
+	}
+	return "manager/" + name
+}
+
+// withCollection serves requests for /collections/{name}/... with next from
+// that collection, with the prefix stripped
+func (s *Server) withCollection(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/collections/"), "/")
+		collection, err := s.assets.Collection(name)
+		if err != nil {
+			http.Error(w, err.Error(), collectionStatus(err))
+			return
+		}
+
+		scoped := r.Clone(context.WithValue(r.Context(), collectionKey{}, scopedCollection{name: name, assets: collection}))
+		scoped.URL.Path = "/" + rest
+		scoped.URL.RawPath = ""
+		next.ServeHTTP(w, scoped)
+	})
+}
+
+// handleGetCollections lists the asset collections and names the active one
+func (s *Server) handleGetCollections(w http.ResponseWriter, r *http.Request) {
+	active, _ := s.active()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"collections": s.assets.ListCollections(),
+		"active":      active,
+	})
+}
+
+// handleActivateCollection makes the asset routes serve a collection
+func (s *Server) handleActivateCollection(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.SetActiveCollection(name); err != nil {
+		http.Error(w, err.Error(), collectionStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active": name,
+	})
+}
+
+// collectionStatus returns the HTTP status for an error getting a collection
+func collectionStatus(err error) int {
+	if errors.Is(err, assets.ErrCollectionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
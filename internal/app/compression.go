@@ -0,0 +1,244 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+	"github.com/ku3ppi/webgl-water/internal/codec"
+)
+
+// compressMinSize is the smallest response worth compressing. Below it the
+// encoding overhead outweighs the savings.
+const compressMinSize = 1024
+
+// compressibleTypes lists the content types compressed for HTTP clients.
+// Images and KTX2 textures are already compressed.
+var compressibleTypes = map[string]bool{
+	"application/json":         true,
+	"application/javascript":   true,
+	"application/octet-stream": true,
+	"image/svg+xml":            true,
+	"text/javascript":          true,
+}
+
+// CompressionConfig selects codecs by name (see codec.Names)
+type CompressionConfig struct {
+	HTTP        []string `json:"http"`        // Offered to clients through Accept-Encoding, most preferred first
+	Snapshots   string   `json:"snapshots"`   // Applied to backup archives
+	Checkpoints string   `json:"checkpoints"` // Applied to checkpoint files
+}
+
+// DefaultCompression sends HTTP responses uncompressed, gzips backups and
+// writes checkpoints uncompressed
+func DefaultCompression() CompressionConfig {
+	return CompressionConfig{
+		Snapshots:   codec.NameGzip,
+		Checkpoints: codec.NameNone,
+	}
+}
+
+// compression holds the codecs resolved from a CompressionConfig
+type compression struct {
+	http        []codec.Codec
+	snapshots   codec.Codec
+	checkpoints codec.Codec
+}
+
+// resolve looks up every codec named in config
+func (config CompressionConfig) resolve() (compression, error) {
+	var result compression
+	for _, name := range config.HTTP {
+		c, err := codec.Lookup(name)
+		if err != nil {
+			return compression{}, fmt.Errorf("invalid HTTP compression: %w", err)
+		}
+		result.http = append(result.http, c)
+	}
+
+	var err error
+	if result.snapshots, err = codec.Lookup(config.Snapshots); err != nil {
+		return compression{}, fmt.Errorf("invalid snapshot compression: %w", err)
+	}
+	if result.checkpoints, err = codec.Lookup(config.Checkpoints); err != nil {
+		return compression{}, fmt.Errorf("invalid checkpoint compression: %w", err)
+	}
+	return result, nil
+}
+
+// SetCompression selects the codecs for HTTP responses, backups and checkpoints
+func (s *Server) SetCompression(config CompressionConfig) error {
+	resolved, err := config.resolve()
+	if err != nil {
+		return err
+	}
+	s.compression = resolved
+	return nil
+}
+
+// EnablePrecompression makes the asset manager compress mesh JSON and shader
+// sources with the HTTP codecs when it loads them, instead of compressing them
+// on every request. It must be called before Initialize.
+func (s *Server) EnablePrecompression() {
+	s.precompress = true
+}
+
+// serveEncoded serves content with caching headers, choosing a precompressed
+// encoding if the client accepts one. withCompression appends the codec name
+// to the ETag as it does for responses it compresses itself.
+func (s *Server) serveEncoded(w http.ResponseWriter, r *http.Request, content *assets.EncodedContent, cacheControl string) {
+	w.Header().Set("ETag", formatETag(content.SHA256[:]))
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	body := content.Data
+	if r.Header.Get("Range") == "" {
+		c := codec.Negotiate(r.Header.Get("Accept-Encoding"), s.compression.http)
+		if encoded, ok := content.Encodings[c.Name()]; ok {
+			w.Header().Set("Content-Encoding", c.Name())
+			body = encoded
+		}
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// withCompression encodes responses with the codec negotiated from the
+// request's Accept-Encoding header. The codec name is appended to ETags, so a
+// compressed and an uncompressed response never share one.
+func (s *Server) withCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.compression.http) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// Compressing part of a file would break the byte offsets of range requests
+		c := codec.Negotiate(r.Header.Get("Accept-Encoding"), s.compression.http)
+		if c == codec.None || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, codec: c, suffix: "-" + c.Name() + `"`}
+		if match := r.Header.Get("If-None-Match"); strings.Contains(match, cw.suffix) {
+			r.Header.Set("If-None-Match", strings.ReplaceAll(match, cw.suffix, `"`))
+			cw.revalidating = true
+		}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter decides whether to compress a response once its headers and
+// the first chunk of its body are known
+type compressWriter struct {
+	http.ResponseWriter
+	codec        codec.Codec
+	suffix       string         // Appended to ETags of compressed responses
+	revalidating bool           // The client sent the ETag of a compressed response
+	status       int            // Set by WriteHeader, sent with the first write
+	started      bool           // The headers have been sent
+	encoder      io.WriteCloser // Nil while the response is sent uncompressed
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+// start sends the headers, compressing the body if it is worth it. first is
+// the first chunk of the body; its length stands in for a missing Content-Length.
+func (cw *compressWriter) start(first []byte) {
+	cw.started = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	header := cw.Header()
+	switch {
+	case cw.status == http.StatusNotModified && cw.revalidating:
+		cw.tagETag()
+	case cw.status == http.StatusOK && header.Get("Content-Encoding") == cw.codec.Name():
+		// Precompressed by the handler (see serveEncoded)
+		cw.tagETag()
+	case cw.status == http.StatusOK && cw.compressible(first):
+		encoder, err := cw.codec.NewWriter(cw.ResponseWriter)
+		if err != nil {
+			break
+		}
+		cw.encoder = encoder
+		header.Set("Content-Encoding", cw.codec.Name())
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		cw.tagETag()
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// compressible reports whether the response headers allow compressing the body
+func (cw *compressWriter) compressible(first []byte) bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		length = len(first)
+	}
+	if length < compressMinSize {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(first)
+		header.Set("Content-Type", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType]
+}
+
+// tagETag appends the codec name to the response's ETag
+func (cw *compressWriter) tagETag() {
+	if etag := cw.Header().Get("ETag"); strings.HasSuffix(etag, `"`) {
+		cw.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+cw.suffix)
+	}
+}
+
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if !cw.started {
+		cw.start(data)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(data)
+	}
+	return cw.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close sends the headers of an empty response or flushes the compressed stream
+func (cw *compressWriter) Close() error {
+	if !cw.started {
+		cw.start(nil)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// handleGenerateDudv serves a procedurally generated dudv map as PNG. Query
+// parameters named like the fields of assets.DudvParams override the defaults,
+// e.g. /api/dudv?scale=8&strength=0.6. The result can be loaded in place of
+// dudvmap.png to try other distortion characters.
+func (s *Server) handleGenerateDudv(w http.ResponseWriter, r *http.Request) {
+	params := assets.DefaultDudvParams()
+	query := r.URL.Query()
+	ints := map[string]*int{"size": &params.Size, "scale": &params.Scale, "octaves": &params.Octaves}
+	floats := map[string]*float32{"persistence": &params.Persistence, "strength": &params.Strength}
+	if value := query.Get("seed"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid seed", http.StatusBadRequest)
+			return
+		}
+		params.Seed = seed
+	}
+	for name, field := range ints {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid %s", name), http.StatusBadRequest)
+				return
+			}
+			*field = n
+		}
+	}
+	for name, field := range floats {
+		if value := query.Get(name); value != "" {
+			f, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid %s", name), http.StatusBadRequest)
+				return
+			}
+			*field = float32(f)
+		}
+	}
+
+	data, err := assets.GenerateDudvMap(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	serveCachedContent(w, r, data, s.cachePolicy.Assets)
+}
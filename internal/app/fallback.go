@@ -0,0 +1,27 @@
+package app
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// FallbackFiles are read when a file is missing from its directory on disk,
+// so a binary with the defaults embedded runs without any files next to it.
+// Any of them may be nil.
+type FallbackFiles struct {
+	Assets  fs.FS // Default textures and meshes
+	Shaders fs.FS // Shader sources
+	Static  fs.FS // Frontend files
+}
+
+// shaderFiles returns the shader directory over the fallback shaders
+func (s *Server) shaderFiles() fs.FS {
+	return assets.Overlay(os.DirFS(s.shaderDir()), s.fallback.Shaders)
+}
+
+// staticFiles returns the static directory over the fallback frontend files
+func (s *Server) staticFiles() fs.FS {
+	return assets.Overlay(os.DirFS(s.staticPath), s.fallback.Static)
+}
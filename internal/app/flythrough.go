@@ -0,0 +1,63 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// WebSocket messages controlling camera fly-throughs
+const (
+	clientMessageFlyThroughStart = "flythrough_start" // Carries a clientMessage.Path
+	clientMessageFlyThroughStop  = "flythrough_stop"
+)
+
+// handleGetFlyThrough returns the progress of the current fly-through
+func (s *Server) handleGetFlyThrough(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.appState.GetFlyThrough())
+}
+
+// handleStartFlyThrough starts flying the camera along the path in the body
+func (s *Server) handleStartFlyThrough(w http.ResponseWriter, r *http.Request) {
+	var path state.FlyThroughPath
+	if err := json.NewDecoder(r.Body).Decode(&path); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !s.applyMessages(w, r, []state.Message{&state.StartFlyThroughMessage{Path: path}}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.appState.GetFlyThrough())
+}
+
+// handleStopFlyThrough stops the current fly-through, if any
+func (s *Server) handleStopFlyThrough(w http.ResponseWriter, r *http.Request) {
+	s.appState.Update(&state.StopFlyThroughMessage{})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleFlyThroughMessage applies a flythrough_start or flythrough_stop
+// message from a WebSocket client
+func (s *Server) handleFlyThroughMessage(r *http.Request, msg clientMessage) error {
+	if err := s.inputs.allow(clientID(r), 0, s.clock.Now()); err != nil {
+		return err
+	}
+
+	var update state.Message = &state.StopFlyThroughMessage{}
+	if msg.Type == clientMessageFlyThroughStart {
+		var path state.FlyThroughPath
+		if msg.Path != nil {
+			path = *msg.Path
+		}
+		update = &state.StartFlyThroughMessage{Path: path}
+	}
+	if err := s.appState.Update(update); err != nil {
+		return err
+	}
+	s.recordParameters(r, update)
+	return nil
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// clientMessageGamepad reports gamepad stick axes, carrying a clientMessage.Gamepad
+const clientMessageGamepad = "gamepad"
+
+// handleGamepadMessage applies a gamepad message from a WebSocket client. It
+// reports whether the client's sticks are now deflected.
+func (s *Server) handleGamepadMessage(r *http.Request, msg clientMessage) (bool, error) {
+	if err := s.inputs.allow(clientID(r), 0, s.clock.Now()); err != nil {
+		return false, err
+	}
+
+	var axes state.GamepadAxes
+	if msg.Gamepad != nil {
+		axes = *msg.Gamepad
+	}
+	if err := s.appState.Update(&state.GamepadMessage{Axes: axes}); err != nil {
+		return false, err
+	}
+	return axes != state.GamepadAxes{}, nil
+}
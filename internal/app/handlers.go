@@ -0,0 +1,135 @@
+package app
+
+import (
+	"net/http"
+)
+
+// The handlers below only depend on net/http, so they can be mounted under any
+// router (chi, echo, http.ServeMux, ...). Each expects paths relative to its
+// mount point, so strip the prefix when mounting:
+//
+//	mux.Handle("/water/api/", http.StripPrefix("/water/api", server.APIHandler()))
+
+// APIHandler returns the REST API routes (/meshes, /state, /admin/backup, ...).
+// Asset routes serve the active collection, or the collection named by a
+// /collections/{name} prefix.
+// While assets load, only /assets/status answers; the handlers below other than
+// StaticHandler and IndexHandler respond with 503 Service Unavailable.
+func (s *Server) APIHandler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /manifest", s.handleGetManifest)
+	api.HandleFunc("GET /meshes", s.handleGetMeshes)
+	api.HandleFunc("GET /meshes/{name}", s.handleGetMesh)
+	api.HandleFunc("PUT /meshes/{name}/material", s.handleAssignMaterial)
+	api.HandleFunc("GET /materials", s.handleGetMaterials)
+	api.HandleFunc("GET /materials/{name}", s.handleGetMaterial)
+	api.HandleFunc("PUT /materials/{name}", s.handlePutMaterial)
+	api.HandleFunc("DELETE /materials/{name}", s.handleDeleteMaterial)
+	api.HandleFunc("GET /textures", s.handleGetTextures)
+	api.HandleFunc("GET /textures/{name}", s.handleGetTexture)
+	api.HandleFunc("GET /scenes", s.handleGetScenes)
+	api.HandleFunc("GET /scenes/{name}", s.handleGetScene)
+	api.HandleFunc("PATCH /scenes/{name}", s.handlePatchScene)
+	api.HandleFunc("GET /audio", s.handleGetAudioList)
+	api.HandleFunc("GET /audio/{name}", s.handleGetAudio)
+	api.HandleFunc("PUT /audio/{name}", s.handlePutAudio)
+	api.HandleFunc("DELETE /audio/{name}", s.handleDeleteAudio)
+	api.HandleFunc("GET /audio/{name}/stream", s.handleStreamAudio)
+	api.HandleFunc("GET /sprites", s.handleGetSpriteSheets)
+	api.HandleFunc("GET /sprites/{name}", s.handleGetSpriteSheet)
+	api.HandleFunc("GET /sprites/{name}/image", s.handleSpriteSheetImage)
+	api.HandleFunc("GET /fonts", s.handleGetBitmapFonts)
+	api.HandleFunc("GET /fonts/{name}", s.handleGetBitmapFont)
+	api.HandleFunc("GET /fonts/{name}/image", s.handleBitmapFontImage)
+	api.HandleFunc("GET /skyboxes", s.handleGetSkyboxes)
+	api.HandleFunc("GET /skyboxes/{name}", s.handleGetSkybox)
+	api.HandleFunc("GET /skyboxes/{name}/{face}", s.handleSkyboxFace)
+	api.HandleFunc("GET /terrain", s.handleGetTerrain)
+	api.HandleFunc("POST /terrain", s.handleGenerateTerrain)
+	api.HandleFunc("GET /dudv", s.handleGenerateDudv)
+	api.HandleFunc("GET /water-mesh", s.handleGetWaterMesh)
+	api.HandleFunc("POST /water-mesh", s.handleGenerateWaterMesh)
+	api.HandleFunc("GET /instances", s.handleGetScatters)
+	api.HandleFunc("GET /instances/{mesh}", s.handleGetInstances)
+	api.HandleFunc("PUT /instances/{mesh}", s.handlePutInstances)
+	api.HandleFunc("DELETE /instances/{mesh}", s.handleDeleteInstances)
+	api.HandleFunc("GET /state", s.handleGetState)
+	api.HandleFunc("GET /state/poll", s.handlePollState)
+	api.HandleFunc("POST /state/water", s.handleUpdateWater)
+	api.HandleFunc("POST /state/camera", s.handleUpdateCamera)
+	api.HandleFunc("GET /state/camera/presets", s.handleGetCameraPresets)
+	api.HandleFunc("PUT /state/camera/presets/{name}", s.handlePutCameraPreset)
+	api.HandleFunc("POST /state/camera/presets/{name}/recall", s.handleRecallCameraPreset)
+	api.HandleFunc("DELETE /state/camera/presets/{name}", s.handleDeleteCameraPreset)
+	api.HandleFunc("GET /state/cameras", s.handleGetCameras)
+	api.HandleFunc("PUT /state/cameras/{name}", s.handlePutCamera)
+	api.HandleFunc("POST /state/cameras/{name}", s.handleUpdateNamedCamera)
+	api.HandleFunc("DELETE /state/cameras/{name}", s.handleDeleteCamera)
+	api.HandleFunc("POST /state/cameras/{name}/activate", s.handleActivateCamera)
+	api.HandleFunc("GET /state/camera/flythrough", s.handleGetFlyThrough)
+	api.HandleFunc("POST /state/camera/flythrough", s.handleStartFlyThrough)
+	api.HandleFunc("DELETE /state/camera/flythrough", s.handleStopFlyThrough)
+	api.HandleFunc("POST /state/render", s.handleUpdateRender)
+	api.HandleFunc("POST /state/undo", s.handleUndo)
+	api.HandleFunc("POST /state/redo", s.handleRedo)
+	api.HandleFunc("GET /state/water/layers", s.handleGetLayers)
+	api.HandleFunc("PUT /state/water/layers/{name}", s.handlePutLayer)
+	api.HandleFunc("DELETE /state/water/layers/{name}", s.handleDeleteLayer)
+	api.HandleFunc("GET /recordings", s.handleGetRecordings)
+	api.HandleFunc("POST /recordings/{name}/record", s.handleStartRecording)
+	api.HandleFunc("POST /recordings/{name}/replay", s.handleStartReplay)
+	api.HandleFunc("DELETE /recordings/record", s.handleStopRecording)
+	api.HandleFunc("DELETE /recordings/replay", s.handleStopReplay)
+	api.HandleFunc("GET /analytics", s.handleGetAnalytics)
+	api.HandleFunc("GET /analytics.csv", s.handleExportAnalytics)
+	api.HandleFunc("GET /collections", s.handleGetCollections)
+	api.HandleFunc("POST /collections/{name}/activate", s.handleActivateCollection)
+	api.Handle("/collections/", s.withCollection(api))
+	api.HandleFunc("GET /admin/cache", s.handleGetAssetCache)
+	api.HandleFunc("GET /admin/backup", s.withAdminToken(s.handleBackup))
+	api.HandleFunc("POST /admin/restore", s.withAdminToken(s.handleRestore))
+
+	// The load status is the one route answering while assets load
+	routes := http.NewServeMux()
+	routes.HandleFunc("GET /assets/status", s.handleGetAssetStatus)
+	routes.Handle("/", s.withAssetsLoaded(api))
+	return withProtocolVersion(s.withCompression(routes))
+}
+
+// AssetHandler returns the handler serving asset files as /{filename}, textures
+// scaled down as /{name}?w=…&h=… and texture mip levels as /{name}/mip/{level}.
+// Each is served from another collection than the active one under /collections/{name}.
+func (s *Server) AssetHandler() http.Handler {
+	assets := http.NewServeMux()
+	assets.HandleFunc("GET /{filename}", s.handleAssetFile)
+	assets.HandleFunc("GET /{name}/mip/{level}", s.handleMipLevel)
+
+	// Kept apart, as /{name}/mip/{level} would match collection paths as well
+	routes := http.NewServeMux()
+	routes.Handle("/collections/", s.withCollection(assets))
+	routes.Handle("/", assets)
+	return s.withCompression(s.withAssetsLoaded(routes))
+}
+
+// ShaderHandler returns the handler serving shader sources as /{name}
+func (s *Server) ShaderHandler() http.Handler {
+	shaders := http.NewServeMux()
+	shaders.HandleFunc("GET /{name}", s.handleShader)
+	return s.withCompression(s.withAssetsLoaded(shaders))
+}
+
+// StaticHandler returns the file server for the frontend's static files
+func (s *Server) StaticHandler() http.Handler {
+	files := s.staticFiles()
+	return s.withCompression(s.withStaticCaching(files, http.FileServerFS(files)))
+}
+
+// WebSocketHandler returns the handler upgrading requests to the real-time update stream
+func (s *Server) WebSocketHandler() http.Handler {
+	return s.withAssetsLoaded(http.HandlerFunc(s.handleWebSocket))
+}
+
+// IndexHandler returns the handler serving the main application page
+func (s *Server) IndexHandler() http.Handler {
+	return http.HandlerFunc(s.handleIndex)
+}
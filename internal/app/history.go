@@ -0,0 +1,30 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// handleUndo reverts the last water, render, scenery or surface layer change
+func (s *Server) handleUndo(w http.ResponseWriter, r *http.Request) {
+	s.applyHistoryMessage(w, &state.UndoMessage{})
+}
+
+// handleRedo reapplies the last undone change
+func (s *Server) handleRedo(w http.ResponseWriter, r *http.Request) {
+	s.applyHistoryMessage(w, &state.RedoMessage{})
+}
+
+// applyHistoryMessage applies an undo or redo and responds with what can be
+// undone and redone next, or 409 if there was nothing to undo or redo
+func (s *Server) applyHistoryMessage(w http.ResponseWriter, msg state.Message) {
+	if err := s.appState.Update(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.appState.GetHistory())
+}
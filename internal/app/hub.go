@@ -0,0 +1,80 @@
+package app
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Hub tracks connected WebSocket clients and delivers messages to them
+type Hub interface {
+	Register(conn *websocket.Conn)
+	Unregister(conn *websocket.Conn)
+	Send(conn *websocket.Conn, msg interface{}) error
+	Broadcast(msg func(conn *websocket.Conn) interface{}) []error
+	Count() int
+}
+
+// ClientHub is the default Hub. Writes are serialized because a WebSocket
+// connection supports only one concurrent writer.
+type ClientHub struct {
+	mu      sync.Mutex
+	clients map[*websocket.Conn]bool
+}
+
+// NewClientHub creates an empty client hub
+func NewClientHub() *ClientHub {
+	return &ClientHub{
+		clients: make(map[*websocket.Conn]bool),
+	}
+}
+
+// Register adds a connection to the hub
+func (h *ClientHub) Register(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[conn] = true
+}
+
+// Unregister removes a connection from the hub
+func (h *ClientHub) Unregister(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, conn)
+}
+
+// Send writes msg as JSON to a single connection
+func (h *ClientHub) Send(conn *websocket.Conn, msg interface{}) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return conn.WriteJSON(msg)
+}
+
+// Broadcast writes the message returned by msg as JSON to every connection,
+// skipping connections for which it returns nil.
+// Connections that fail are closed and dropped, and their errors returned.
+func (h *ClientHub) Broadcast(msg func(conn *websocket.Conn) interface{}) []error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var errs []error
+	for conn := range h.clients {
+		m := msg(conn)
+		if m == nil {
+			continue
+		}
+		if err := conn.WriteJSON(m); err != nil {
+			errs = append(errs, err)
+			delete(h.clients, conn)
+			conn.Close()
+		}
+	}
+	return errs
+}
+
+// Count returns the number of connected clients
+func (h *ClientHub) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
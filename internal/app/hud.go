@@ -0,0 +1,73 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleGetSpriteSheets returns a list of all sprite sheets
+func (s *Server) handleGetSpriteSheets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sprites": s.assetsFor(r).ListSpriteSheets(),
+	})
+}
+
+// handleGetSpriteSheet returns the image size and frame rects of a sprite sheet
+func (s *Server) handleGetSpriteSheet(w http.ResponseWriter, r *http.Request) {
+	sheet, err := s.assetsFor(r).GetSpriteSheet(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sheet)
+}
+
+// handleSpriteSheetImage serves the image of a sprite sheet
+func (s *Server) handleSpriteSheetImage(w http.ResponseWriter, r *http.Request) {
+	sheet, err := s.assetsFor(r).GetSpriteSheet(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.serveHUDImage(w, r, sheet.Image)
+}
+
+// handleGetBitmapFonts returns a list of all bitmap fonts
+func (s *Server) handleGetBitmapFonts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"fonts": s.assetsFor(r).ListBitmapFonts(),
+	})
+}
+
+// handleGetBitmapFont returns the metrics, glyph rects and kerning of a bitmap font
+func (s *Server) handleGetBitmapFont(w http.ResponseWriter, r *http.Request) {
+	font, err := s.assetsFor(r).GetBitmapFont(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(font)
+}
+
+// handleBitmapFontImage serves the glyph image of a bitmap font
+func (s *Server) handleBitmapFontImage(w http.ResponseWriter, r *http.Request) {
+	font, err := s.assetsFor(r).GetBitmapFont(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.serveHUDImage(w, r, font.Image)
+}
+
+// serveHUDImage serves an image registered with a sprite sheet or font. Only
+// registered paths are served, never a path taken from the request.
+func (s *Server) serveHUDImage(w http.ResponseWriter, r *http.Request, image string) {
+	w.Header().Set("Content-Type", getContentType(image))
+	s.serveCachedFile(w, r, s.assetsFor(r).Files(), s.assetSource(r), image, s.cachePolicy.Assets)
+}
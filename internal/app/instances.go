@@ -0,0 +1,91 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// SetScatter scatters instances of mesh over a terrain mesh. The instances are
+// placed once the terrain exists and again whenever it changes.
+func (s *Server) SetScatter(mesh string, params assets.ScatterParams) error {
+	_, err := s.assets.SetScatter(mesh, params)
+	return err
+}
+
+// handleGetScatters lists the meshes scattered over terrain
+func (s *Server) handleGetScatters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"meshes": s.assetsFor(r).ListScatters(),
+	})
+}
+
+// handleGetInstances returns the scatter settings and instance transforms of a mesh
+func (s *Server) handleGetInstances(w http.ResponseWriter, r *http.Request) {
+	mesh := r.PathValue("mesh")
+	params, instances, err := s.assetsFor(r).Instances(mesh)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"mesh":      mesh,
+		"params":    params,
+		"instances": instances,
+	})
+}
+
+// handlePutInstances scatters a mesh over terrain, replacing its instances.
+// Fields missing from the body keep their current values, or the defaults of
+// assets.DefaultScatterParams for a mesh that is not scattered yet.
+func (s *Server) handlePutInstances(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	mesh := r.PathValue("mesh")
+	if _, err := collection.GetMesh(mesh); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	params, _, err := collection.Instances(mesh)
+	if err != nil {
+		params = assets.DefaultScatterParams()
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if _, err := collection.GetMesh(params.Terrain); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	instances, err := collection.SetScatter(mesh, params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindInstances, Name: mesh})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"mesh":      mesh,
+		"params":    params,
+		"instances": instances,
+	})
+}
+
+// handleDeleteInstances removes the scatter of a mesh
+func (s *Server) handleDeleteInstances(w http.ResponseWriter, r *http.Request) {
+	mesh := r.PathValue("mesh")
+	if !s.assetsFor(r).RemoveScatter(mesh) {
+		http.Error(w, fmt.Sprintf("mesh '%s' is not scattered", mesh), http.StatusNotFound)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindInstances, Name: mesh})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// WebSocket messages for keyboard camera control, carrying a clientMessage.Key
+const (
+	clientMessageKeyDown = "key_down" // Key pressed or repeated while held
+	clientMessageKeyUp   = "key_up"   // Key released
+)
+
+// handleKeyMessage applies a key_down or key_up message from a WebSocket
+// client and records the keys it holds in held
+func (s *Server) handleKeyMessage(r *http.Request, msg clientMessage, held map[state.Key]bool) error {
+	if err := s.inputs.allow(clientID(r), 0, s.clock.Now()); err != nil {
+		return err
+	}
+
+	var update state.Message = &state.KeyUpMessage{Key: msg.Key}
+	if msg.Type == clientMessageKeyDown {
+		update = &state.KeyDownMessage{Key: msg.Key}
+	}
+	if err := s.appState.Update(update); err != nil {
+		return err
+	}
+
+	if msg.Type == clientMessageKeyDown {
+		held[msg.Key] = true
+	} else {
+		delete(held, msg.Key)
+		s.recordParameters(r, update)
+	}
+	return nil
+}
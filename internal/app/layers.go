@@ -0,0 +1,58 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// handleGetLayers lists the surface layers composited over the water
+func (s *Server) handleGetLayers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"layers": s.appState.GetSurfaceLayers(),
+	})
+}
+
+// handlePutLayer adds the named surface layer or replaces it. Fields missing
+// from the body take the defaults of state.NewSurfaceLayer.
+func (s *Server) handlePutLayer(w http.ResponseWriter, r *http.Request) {
+	layer := state.NewSurfaceLayer(r.PathValue("name"), "")
+	if err := json.NewDecoder(r.Body).Decode(&layer); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	layer.Name = r.PathValue("name")
+
+	if _, err := s.assetsFor(r).GetTexture(layer.Texture); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msg := &state.SetSurfaceLayerMessage{Layer: layer}
+	if err := state.ValidateMessage(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The layer is valid, so the only remaining failure is running out of layers
+	if err := s.appState.Update(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	s.recordParameters(r, msg)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layer)
+}
+
+// handleDeleteLayer removes a surface layer
+func (s *Server) handleDeleteLayer(w http.ResponseWriter, r *http.Request) {
+	msg := &state.RemoveSurfaceLayerMessage{Name: r.PathValue("name")}
+	if err := s.appState.Update(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.recordParameters(r, msg)
+	w.WriteHeader(http.StatusNoContent)
+}
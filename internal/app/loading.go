@@ -0,0 +1,59 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// EnableBackgroundLoading makes Initialize return before the assets are loaded,
+// so the server can serve a loading screen right away. Until loading finishes,
+// requests for assets, shaders and the API other than /api/assets/status are
+// answered with 503 Service Unavailable.
+func (s *Server) EnableBackgroundLoading() {
+	s.backgroundLoad = true
+}
+
+// SetLoadWorkers sets how many goroutines load assets concurrently, GOMAXPROCS by default
+func (s *Server) SetLoadWorkers(workers int) {
+	s.assets.SetLoadWorkers(workers)
+}
+
+// loadAssets initializes the asset manager and marks the assets as loaded
+func (s *Server) loadAssets() error {
+	defer close(s.loaded)
+	if err := s.assets.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize assets: %w", err)
+	}
+	return nil
+}
+
+// withAssetsLoaded answers requests with the load progress until the assets
+// are loaded: 503 with Retry-After while loading, 500 if loading failed
+func (s *Server) withAssetsLoaded(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		progress := s.assets.LoadProgress()
+		if progress.Ready {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if progress.Phase == assets.LoadPhaseFailed {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(progress)
+	})
+}
+
+// handleGetAssetStatus returns how far asset loading has come
+func (s *Server) handleGetAssetStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(s.assets.LoadProgress())
+}
@@ -0,0 +1,12 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleGetManifest returns the content hash and size of every mesh, texture and shader
+func (s *Server) handleGetManifest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.assetsFor(r).Manifest())
+}
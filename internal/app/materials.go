@@ -0,0 +1,94 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// handleGetMaterials returns the names of all materials
+func (s *Server) handleGetMaterials(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"materials": s.assetsFor(r).ListMaterials(),
+	})
+}
+
+// handleGetMaterial returns a material by name
+func (s *Server) handleGetMaterial(w http.ResponseWriter, r *http.Request) {
+	material, err := s.assetsFor(r).GetMaterial(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(material)
+}
+
+// handlePutMaterial adds the named material or replaces it. Fields missing
+// from the body take the defaults of assets.NewMaterial.
+func (s *Server) handlePutMaterial(w http.ResponseWriter, r *http.Request) {
+	material := assets.NewMaterial(r.PathValue("name"))
+	if err := json.NewDecoder(r.Body).Decode(&material); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	material.Name = r.PathValue("name")
+
+	if err := s.assetsFor(r).SetMaterial(material); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindMaterial, Name: material.Name})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(material)
+}
+
+// handleDeleteMaterial removes a material that no mesh uses
+func (s *Server) handleDeleteMaterial(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	name := r.PathValue("name")
+	if _, err := collection.GetMaterial(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := collection.RemoveMaterial(name); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindMaterial, Name: name})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAssignMaterial sets the material of a mesh from a body such as
+// {"material": "stone"}. An empty name removes the assignment.
+func (s *Server) handleAssignMaterial(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	var req struct {
+		Material string `json:"material"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	mesh := r.PathValue("name")
+	if _, err := collection.GetMesh(mesh); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := collection.AssignMaterial(mesh, req.Material); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindMaterial, Name: req.Material})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"mesh":     mesh,
+		"material": req.Material,
+	})
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"log"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// Option customizes a Server created by NewServer
+type Option func(*Server)
+
+// WithAssets makes the server use an existing asset manager instead of creating one for assetsPath
+func WithAssets(a *assets.Assets) Option {
+	return func(s *Server) { s.assets = a }
+}
+
+// WithState makes the server drive an existing application state
+func WithState(st *state.State) Option {
+	return func(s *Server) { s.appState = st }
+}
+
+// WithLogger sets the logger for server messages (log.Default() if not given)
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Server) { s.logger = logger }
+}
+
+// WithHub sets the hub WebSocket clients are registered with
+func WithHub(hub Hub) Option {
+	return func(s *Server) { s.hub = hub }
+}
+
+// WithFallbackFiles sets the files served when the asset, shader or static
+// directories lack them. Files on disk always take precedence.
+func WithFallbackFiles(files FallbackFiles) Option {
+	return func(s *Server) { s.fallback = files }
+}
+
+// WithClock sets the time source for the simulation loop, checkpoints and rate limits
+func WithClock(clock Clock) Option {
+	return func(s *Server) { s.clock = clock }
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// pollTimeout is how long a long-poll request waits for a change before
+// returning the unchanged state, kept below common proxy idle timeouts
+const pollTimeout = 25 * time.Second
+
+// handlePollState is a long-polling fallback for clients that can use neither
+// WebSockets nor SSE. It responds with the same state_update payload as the
+// WebSocket once the state version differs from ?since, or after pollTimeout.
+// Requests without ?since respond immediately.
+func (s *Server) handlePollState(w http.ResponseWriter, r *http.Request) {
+	if since := r.URL.Query().Get("since"); since != "" {
+		version, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid since version", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), pollTimeout)
+		defer cancel()
+		s.appState.WaitForChange(ctx, version)
+
+		// The client went away while waiting
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(s.stateUpdate())
+}
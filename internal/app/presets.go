@@ -0,0 +1,96 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// EnableCameraPresets makes the server load camera presets from path on start
+// and write them back to it whenever one is saved or deleted. Without it,
+// presets last until the server stops.
+func (s *Server) EnableCameraPresets(path string) {
+	s.presetsPath = path
+}
+
+// loadCameraPresets restores the camera presets saved by an earlier run, if any
+func (s *Server) loadCameraPresets() {
+	if s.presetsPath == "" {
+		return
+	}
+	if err := s.appState.LoadCameraPresets(s.presetsPath); err == nil {
+		s.logger.Printf("Restored camera presets from %s", s.presetsPath)
+	} else if !os.IsNotExist(err) {
+		s.logger.Printf("Failed to restore camera presets: %v", err)
+	}
+}
+
+// persistCameraPresets writes the camera presets to the presets file, if enabled.
+// Writes are serialized so the file always ends up with the latest presets.
+func (s *Server) persistCameraPresets() error {
+	if s.presetsPath == "" {
+		return nil
+	}
+	s.presetsMu.Lock()
+	defer s.presetsMu.Unlock()
+	return s.appState.SaveCameraPresets(s.presetsPath)
+}
+
+// handleGetCameraPresets lists the saved camera presets by name
+func (s *Server) handleGetCameraPresets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"presets": s.appState.GetCameraPresets(),
+	})
+}
+
+// handlePutCameraPreset saves the current camera under the name in the path
+func (s *Server) handlePutCameraPreset(w http.ResponseWriter, r *http.Request) {
+	msg := &state.SaveCameraPresetMessage{Name: r.PathValue("name")}
+	if err := state.ValidateMessage(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The name is valid, so the only remaining failure is running out of presets
+	if err := s.appState.Update(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err := s.persistCameraPresets(); err != nil {
+		s.logger.Printf("Error writing camera presets: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.appState.GetCameraPresets()[msg.Name])
+}
+
+// handleRecallCameraPreset moves the camera to a saved preset
+func (s *Server) handleRecallCameraPreset(w http.ResponseWriter, r *http.Request) {
+	msg := &state.RecallCameraPresetMessage{Name: r.PathValue("name")}
+	if err := s.appState.Update(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.recordParameters(r, msg)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
+
+// handleDeleteCameraPreset removes a saved preset
+func (s *Server) handleDeleteCameraPreset(w http.ResponseWriter, r *http.Request) {
+	if err := s.appState.Update(&state.DeleteCameraPresetMessage{Name: r.PathValue("name")}); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := s.persistCameraPresets(); err != nil {
+		s.logger.Printf("Error writing camera presets: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ProtocolVersion is the version of the WebSocket and REST payloads this server speaks.
+// Bump it whenever a payload changes in a way older frontends cannot parse.
+const ProtocolVersion = 2
+
+// MinProtocolVersion is the oldest frontend protocol still served.
+// Older clients are told to reload instead of receiving payloads they cannot parse.
+const MinProtocolVersion = 2
+
+// protocolHeader carries the protocol version on REST requests and responses
+const protocolHeader = "X-Protocol-Version"
+
+// reloadRequiredCloseCode is the WebSocket close code sent after a reload_required message
+const reloadRequiredCloseCode = 4000
+
+// clientProtocolVersion returns the protocol version a client announced through
+// ?protocol= or the X-Protocol-Version header. Clients that announce nothing
+// predate versioning and are treated as version 1.
+func clientProtocolVersion(r *http.Request) int {
+	value := r.URL.Query().Get("protocol")
+	if value == "" {
+		value = r.Header.Get(protocolHeader)
+	}
+	if value == "" {
+		return 1
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// reloadRequired is the message telling a stale frontend to reload itself
+func reloadRequired(clientVersion int) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               "reload_required",
+		"protocolVersion":    ProtocolVersion,
+		"minProtocolVersion": MinProtocolVersion,
+		"clientVersion":      clientVersion,
+	}
+}
+
+// withProtocolVersion tags every response with the server's protocol version and
+// rejects requests that explicitly announce an unsupported one. Requests without
+// a version (curl, scripts) are served as before.
+func withProtocolVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(protocolHeader, strconv.Itoa(ProtocolVersion))
+
+		if r.Header.Get(protocolHeader) != "" {
+			if version := clientProtocolVersion(r); version < MinProtocolVersion || version > ProtocolVersion {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUpgradeRequired)
+				json.NewEncoder(w).Encode(reloadRequired(version))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,491 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// A session recording is a JSON lines file with one entry per message the
+// state applied while recording, timestamped from the start of the recording.
+// Replaying one feeds the messages back into the current state at their
+// original pace or a multiple of it, from the simulation loop. Replays do not
+// reset the state first, so restore the state the recording started from,
+// such as a backup or checkpoint, to reproduce it exactly. Entries are numbered
+// in order, and a recording missing any of them is refused.
+
+// recordingExt is the file extension of session recordings
+const recordingExt = ".jsonl"
+
+// MaxReplaySpeed is the fastest a recording can be replayed, as a multiple of its original pace
+const MaxReplaySpeed = 100
+
+// recordingBuffer is how many entries can wait for the recording writer. The
+// state hands entries over under its lock, so rather than hold up the
+// simulation, a recording whose buffer fills up ends with an error.
+const recordingBuffer = 4096
+
+// maxRecordingLine is the longest recording entry that can be replayed, in bytes
+const maxRecordingLine = 1 << 20
+
+// recordingEntry is one line of a session recording
+type recordingEntry struct {
+	Seq  int     `json:"seq"`  // Position in the recording, counting from 0
+	Time float64 `json:"time"` // Milliseconds since the recording started
+	state.EncodedMessage
+}
+
+// recorder hands applied messages to a goroutine appending them to a recording file
+type recorder struct {
+	name     string
+	entries  chan recordingEntry // Closed to stop the writer
+	done     chan error          // Receives the writer's result once it has closed the file
+	started  time.Time
+	messages int
+	err      error // Why the recording ended early; entries is closed once set
+}
+
+// newRecorder starts a recorder writing to file
+func newRecorder(name string, file *os.File, started time.Time) *recorder {
+	r := &recorder{
+		name:    name,
+		entries: make(chan recordingEntry, recordingBuffer),
+		done:    make(chan error, 1),
+		started: started,
+	}
+	go r.write(file)
+	return r
+}
+
+// write appends entries to file until the entries channel is closed. Output
+// is buffered and flushed whenever the writer catches up.
+func (r *recorder) write(file *os.File) {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	var err error
+	for entry := range r.entries {
+		if err == nil {
+			err = encoder.Encode(entry)
+		}
+		if err == nil && len(r.entries) == 0 {
+			err = writer.Flush()
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	r.done <- err
+}
+
+// RecordingStatus is the progress of the current recording
+type RecordingStatus struct {
+	Active   bool    `json:"active"`
+	Name     string  `json:"name,omitempty"`
+	Elapsed  float64 `json:"elapsed"` // Milliseconds since the recording started
+	Messages int     `json:"messages"`
+	Error    string  `json:"error,omitempty"` // Why the recording ended before it was stopped
+}
+
+// replay is a recording being fed back into the state
+type replay struct {
+	name     string
+	entries  []recordingEntry
+	messages []state.Message // Decoded entries
+	speed    float64
+	started  time.Time
+	next     int // Index of the next entry to apply
+}
+
+// ReplayStatus is the progress of the current replay
+type ReplayStatus struct {
+	Active   bool    `json:"active"`
+	Name     string  `json:"name,omitempty"`
+	Speed    float64 `json:"speed"`
+	Elapsed  float64 `json:"elapsed"`  // Milliseconds into the recording
+	Duration float64 `json:"duration"` // Milliseconds
+	Messages int     `json:"messages"` // Applied so far
+	Total    int     `json:"total"`
+}
+
+// ReplayRequest is the optional body of a replay request
+type ReplayRequest struct {
+	Speed *float64 `json:"speed,omitempty"` // Defaults to 1, the original pace
+}
+
+// EnableRecordings lets API clients record sessions to and replay them from
+// files in dir, which is created when the first recording starts
+func (s *Server) EnableRecordings(dir string) {
+	s.recordingsDir = dir
+	s.appState.SetUpdateHook(s.recordMessage)
+}
+
+// recordingPath returns the file of the named recording
+func (s *Server) recordingPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid recording name '%s'", name)
+	}
+	return filepath.Join(s.recordingsDir, name+recordingExt), nil
+}
+
+// startRecording starts appending applied messages to the named recording
+func (s *Server) startRecording(name string) error {
+	path, err := s.recordingPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.recordingsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+
+	s.recordingMu.Lock()
+	defer s.recordingMu.Unlock()
+	if s.recorder != nil {
+		return fmt.Errorf("already recording '%s'", s.recorder.name)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create recording: %w", err)
+	}
+	s.recorder = newRecorder(name, file, s.clock.Now())
+	s.logger.Printf("Recording session to %s", path)
+	return nil
+}
+
+// stopRecording closes the current recording, if any, and returns its final
+// status once every entry is written
+func (s *Server) stopRecording() (RecordingStatus, error) {
+	s.recordingMu.Lock()
+	r := s.recorder
+	if r == nil {
+		s.recordingMu.Unlock()
+		return RecordingStatus{}, nil
+	}
+	status := s.recordingStatus()
+	if r.err == nil {
+		close(r.entries)
+	}
+	s.recorder = nil
+	// Messages applied while the file is flushed must not wait for it
+	s.recordingMu.Unlock()
+
+	if err := <-r.done; err != nil {
+		return status, fmt.Errorf("failed to write recording: %w", err)
+	}
+	return status, r.err
+}
+
+// recordMessage hands msg to the current recording, if any. The state calls it
+// under its write lock, so entries are in the order they were applied, and it
+// never waits for the file: if the writer has fallen a full buffer behind, the
+// recording ends there rather than leaving out messages.
+func (s *Server) recordMessage(msg state.Message) {
+	s.recordingMu.Lock()
+	defer s.recordingMu.Unlock()
+	r := s.recorder
+	if r == nil || r.err != nil {
+		return
+	}
+
+	encoded, err := state.EncodeMessage(msg)
+	if err != nil {
+		s.logger.Printf("Error recording message: %v", err)
+		return
+	}
+	entry := recordingEntry{
+		Seq:            r.messages,
+		Time:           float64(s.clock.Now().Sub(r.started)) / float64(time.Millisecond),
+		EncodedMessage: encoded,
+	}
+	select {
+	case r.entries <- entry:
+		r.messages++
+	default:
+		r.err = fmt.Errorf("recording '%s' ended after %d messages because the writer fell behind", r.name, r.messages)
+		close(r.entries)
+		s.logger.Printf("%v", r.err)
+	}
+}
+
+// recordingStatus returns the progress of the current recording. The recording lock must be held.
+func (s *Server) recordingStatus() RecordingStatus {
+	r := s.recorder
+	if r == nil {
+		return RecordingStatus{}
+	}
+	status := RecordingStatus{
+		Active:   true,
+		Name:     r.name,
+		Elapsed:  float64(s.clock.Now().Sub(r.started)) / float64(time.Millisecond),
+		Messages: r.messages,
+	}
+	if r.err != nil {
+		status.Error = r.err.Error()
+	}
+	return status
+}
+
+// loadRecording reads and decodes the named recording
+func (s *Server) loadRecording(name string) ([]recordingEntry, []state.Message, error) {
+	path, err := s.recordingPath(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var entries []recordingEntry
+	var messages []state.Message
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxRecordingLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry recordingEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		if entry.Seq != len(entries) {
+			return nil, nil, fmt.Errorf("recording line %d: expected entry %d, got %d; messages are missing", line, len(entries), entry.Seq)
+		}
+		msg, err := entry.Decode()
+		if err != nil {
+			return nil, nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		entry.Data = nil
+		entries = append(entries, entry)
+		messages = append(messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return entries, messages, nil
+}
+
+// startReplay starts feeding the named recording into the state at speed
+// times its original pace, replacing any replay in progress
+func (s *Server) startReplay(name string, speed float64) error {
+	if math.IsNaN(speed) || speed <= 0 || speed > MaxReplaySpeed {
+		return fmt.Errorf("replay speed must be above 0 and at most %d, got %v", MaxReplaySpeed, speed)
+	}
+	entries, messages, err := s.loadRecording(name)
+	if err != nil {
+		return err
+	}
+
+	s.recordingMu.Lock()
+	defer s.recordingMu.Unlock()
+	s.replay = &replay{
+		name:     name,
+		entries:  entries,
+		messages: messages,
+		speed:    speed,
+		started:  s.clock.Now(),
+	}
+	s.logger.Printf("Replaying recording '%s' at %gx", name, speed)
+	return nil
+}
+
+// stopReplay stops the current replay, if any, and returns its final status
+func (s *Server) stopReplay() ReplayStatus {
+	s.recordingMu.Lock()
+	defer s.recordingMu.Unlock()
+	status := s.replayStatus(s.clock.Now())
+	s.replay = nil
+	return status
+}
+
+// advanceReplay applies the messages of the current replay that are due at
+// now, and ends the replay after its last message. Messages the state rejects
+// are logged and skipped.
+func (s *Server) advanceReplay(now time.Time) {
+	s.recordingMu.Lock()
+	p := s.replay
+	if p == nil {
+		s.recordingMu.Unlock()
+		return
+	}
+	elapsed := p.elapsed(now)
+	var due []state.Message
+	for p.next < len(p.entries) && p.entries[p.next].Time <= elapsed {
+		due = append(due, p.messages[p.next])
+		p.next++
+	}
+	if p.next == len(p.entries) {
+		s.replay = nil
+		s.logger.Printf("Finished replaying recording '%s'", p.name)
+	}
+	// Applying calls back into recordMessage, which takes the lock
+	s.recordingMu.Unlock()
+
+	for _, msg := range due {
+		if err := s.appState.Update(msg); err != nil {
+			s.logger.Printf("Skipped replayed message: %v", err)
+		}
+	}
+}
+
+// elapsed returns how far into the recording the replay is at now, in milliseconds
+func (p *replay) elapsed(now time.Time) float64 {
+	return float64(now.Sub(p.started)) / float64(time.Millisecond) * p.speed
+}
+
+// duration returns the time of the last entry in milliseconds
+func (p *replay) duration() float64 {
+	if len(p.entries) == 0 {
+		return 0
+	}
+	return p.entries[len(p.entries)-1].Time
+}
+
+// replayStatus returns the progress of the current replay. The recording lock must be held.
+func (s *Server) replayStatus(now time.Time) ReplayStatus {
+	p := s.replay
+	if p == nil {
+		return ReplayStatus{}
+	}
+	return ReplayStatus{
+		Active:   true,
+		Name:     p.name,
+		Speed:    p.speed,
+		Elapsed:  min(p.elapsed(now), p.duration()),
+		Duration: p.duration(),
+		Messages: p.next,
+		Total:    len(p.entries),
+	}
+}
+
+// closeRecording finishes the current recording when the server shuts down
+func (s *Server) closeRecording() {
+	if _, err := s.stopRecording(); err != nil {
+		s.logger.Printf("Error closing recording: %v", err)
+	}
+}
+
+// handleGetRecordings lists the saved recordings with the recording and replay in progress
+func (s *Server) handleGetRecordings(w http.ResponseWriter, r *http.Request) {
+	if s.recordingsDir == "" {
+		http.Error(w, "Recordings are not enabled", http.StatusNotFound)
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(s.recordingsDir, "*"+recordingExt))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), recordingExt))
+	}
+	sort.Strings(names)
+
+	s.recordingMu.Lock()
+	recording, replaying := s.recordingStatus(), s.replayStatus(s.clock.Now())
+	s.recordingMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recordings": names,
+		"recording":  recording,
+		"replay":     replaying,
+	})
+}
+
+// handleStartRecording starts recording the session under the name in the path,
+// replacing any recording by that name
+func (s *Server) handleStartRecording(w http.ResponseWriter, r *http.Request) {
+	if s.recordingsDir == "" {
+		http.Error(w, "Recordings are not enabled", http.StatusNotFound)
+		return
+	}
+	name := r.PathValue("name")
+	if _, err := s.recordingPath(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.startRecording(name); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	s.recordingMu.Lock()
+	status := s.recordingStatus()
+	s.recordingMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleStopRecording stops the current recording, if any, and returns its final status
+func (s *Server) handleStopRecording(w http.ResponseWriter, r *http.Request) {
+	status, err := s.stopRecording()
+	if err != nil {
+		s.logger.Printf("Error closing recording: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleStartReplay starts replaying the recording named in the path
+func (s *Server) handleStartReplay(w http.ResponseWriter, r *http.Request) {
+	if s.recordingsDir == "" {
+		http.Error(w, "Recordings are not enabled", http.StatusNotFound)
+		return
+	}
+	var req ReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	speed := 1.0
+	if req.Speed != nil {
+		speed = *req.Speed
+	}
+
+	name := r.PathValue("name")
+	if _, err := s.recordingPath(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.startReplay(name, speed); err != nil {
+		status := http.StatusBadRequest
+		if os.IsNotExist(err) {
+			err, status = fmt.Errorf("recording '%s' not found", name), http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	s.recordingMu.Lock()
+	status := s.replayStatus(s.clock.Now())
+	s.recordingMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleStopReplay stops the current replay, if any, and returns its final status
+func (s *Server) handleStopReplay(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.stopReplay())
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/gorilla/websocket"
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// EnableHotReload makes the server reload assets when their files change and
+// notify WebSocket clients with an asset_changed message so they can re-fetch them
+func (s *Server) EnableHotReload() {
+	s.hotReload = true
+}
+
+// shaderDir returns the directory shaders are served from
+func (s *Server) shaderDir() string {
+	return filepath.Join(s.staticPath, "..", "shaders")
+}
+
+// watchAssets reloads changed assets until the server shuts down
+func (s *Server) watchAssets() {
+	// Reloads must not race the initial load
+	select {
+	case <-s.loaded:
+	case <-s.done:
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	err := s.assets.Watch(ctx, s.shaderDir(), func(change assets.AssetChange, err error) {
+		if err != nil {
+			s.logger.Printf("Error reloading %s '%s': %v", change.Kind, change.Name, err)
+			return
+		}
+		s.logger.Printf("Reloaded %s '%s'", change.Kind, change.Name)
+		s.broadcastAssetChanged(change)
+	})
+	if err != nil {
+		s.logger.Printf("Asset hot-reload disabled: %v", err)
+	}
+}
+
+// broadcastAssetChanged tells every WebSocket client that an asset was reloaded
+func (s *Server) broadcastAssetChanged(change assets.AssetChange) {
+	message := map[string]interface{}{
+		"type": "asset_changed",
+		"kind": change.Kind,
+		"name": change.Name,
+	}
+	errs := s.hub.Broadcast(func(conn *websocket.Conn) interface{} { return message })
+	for _, err := range errs {
+		s.logger.Printf("Error sending asset change: %v", err)
+	}
+}
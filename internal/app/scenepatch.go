@@ -0,0 +1,53 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// handlePatchScene applies a scene patch and forwards it to every WebSocket
+// client, so other editors apply the same edits without re-fetching the scene
+func (s *Server) handlePatchScene(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	name := r.PathValue("name")
+	if _, err := collection.GetScene(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var patch assets.ScenePatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	scene, err := collection.PatchScene(name, patch)
+	switch {
+	case errors.Is(err, assets.ErrScenePatchConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	message := map[string]interface{}{
+		"type":     "scene_patch",
+		"scene":    name,
+		"revision": scene.Revision,
+		"ops":      patch.Ops,
+	}
+	errs := s.hub.Broadcast(func(conn *websocket.Conn) interface{} { return message })
+	for _, err := range errs {
+		s.logger.Printf("Error sending scene patch: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"revision": scene.Revision,
+	})
+}
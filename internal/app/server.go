@@ -1,12 +1,19 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -21,20 +28,74 @@ type Server struct {
 	assets     *assets.Assets
 	appState   *state.State
 	upgrader   websocket.Upgrader
-	clients    map[*websocket.Conn]bool
+	hub        Hub
+	streamsMu  sync.Mutex
+	streams    map[*websocket.Conn]*clientStream
+	xrOwner    *websocket.Conn // Client driving the XR session, guarded by streamsMu
+	logger     *log.Logger
+	clock      Clock
 	staticPath string
 	port       int
+	inputs     *inputValidator
+
+	checkpointPath     string
+	checkpointInterval time.Duration
+	adminToken         string        // Bearer token for backup and restore; empty disables them
+	tickRate           int           // Simulation steps per second
+	alpha              atomic.Uint32 // Float32 bits of the interpolation alpha; see tick.go
+	presetsPath        string        // Camera presets file; empty keeps presets in memory
+	presetsMu          sync.Mutex    // Serializes writes to the presets file
+
+	recordingsDir string     // Session recordings directory; empty disables recording
+	recordingMu   sync.Mutex // Guards recorder and replay
+	recorder      *recorder  // Recording in progress, if any
+	replay        *replay    // Replay in progress, if any
+
+	hotReload bool
+	clipmap   *state.ClipmapConfig // Nil renders the fixed water plane
+	analytics *analytics           // Nil unless EnableAnalytics was called
+	fallback  FallbackFiles
+
+	cachePolicy CachePolicy
+	etags       *etagCache
+	compression compression
+	precompress bool
+
+	backgroundLoad   bool
+	collectionMu     sync.RWMutex
+	activeCollection string         // Name of the collection asset routes serve
+	activeAssets     *assets.Assets // Nil for the default collection, s.assets
+	loaded           chan struct{}  // Closed once the assets are loaded, successfully or not
+
+	hooks      Hooks
+	httpServer *http.Server
+	background sync.Once
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+// Hooks are optional callbacks invoked on server events. Any of them may be nil.
+type Hooks struct {
+	OnServe            func(addr net.Addr)   // Called once the server starts accepting connections
+	OnClientConnect    func(r *http.Request) // Called when a WebSocket client connects
+	OnClientDisconnect func(r *http.Request) // Called when a WebSocket client disconnects
+	OnTick             func(clock float32)   // Called after every simulation step, before broadcasting
 }
 
-// NewServer creates a new server instance
-func NewServer(assetsPath, staticPath string, port int) *Server {
+// NewServer creates a new server instance.
+// Dependencies not supplied through options are created with default settings.
+func NewServer(assetsPath, staticPath string, port int, opts ...Option) *Server {
 	server := &Server{
-		router:     mux.NewRouter(),
-		assets:     assets.NewAssets(assetsPath),
-		appState:   state.NewState(),
-		staticPath: staticPath,
-		port:       port,
-		clients:    make(map[*websocket.Conn]bool),
+		router:      mux.NewRouter(),
+		staticPath:  staticPath,
+		port:        port,
+		inputs:      newInputValidator(DefaultInputLimits()),
+		etags:       newETagCache(),
+		cachePolicy: DefaultCachePolicy(),
+		streams:     make(map[*websocket.Conn]*clientStream),
+		done:        make(chan struct{}),
+		loaded:      make(chan struct{}),
+		tickRate:    DefaultTickRate,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for development
@@ -42,70 +103,233 @@ func NewServer(assetsPath, staticPath string, port int) *Server {
 		},
 	}
 
+	// The default codecs are built in, so resolving them cannot fail
+	server.compression, _ = DefaultCompression().resolve()
+
+	for _, opt := range opts {
+		opt(server)
+	}
+	if server.assets == nil {
+		server.assets = assets.NewAssets(assetsPath)
+	}
+	if server.appState == nil {
+		server.appState = state.NewState()
+	}
+	server.appState.SetGround(server.terrainHeightAt)
+	if server.logger == nil {
+		server.logger = log.Default()
+	}
+	if server.hub == nil {
+		server.hub = NewClientHub()
+	}
+	if server.clock == nil {
+		server.clock = systemClock{}
+	}
+
 	server.setupRoutes()
 	return server
 }
 
-// setupRoutes configures all HTTP routes
+// setupRoutes configures all HTTP routes.
+// Each group is a self-contained handler (see handlers.go) mounted under its prefix.
 func (s *Server) setupRoutes() {
 	// Static file serving
-	s.router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir(s.staticPath))))
+	s.router.PathPrefix("/static/").Handler(http.StripPrefix("/static", s.StaticHandler()))
 
 	// Asset serving
-	s.router.HandleFunc("/assets/{filename}", s.handleAssetFile).Methods("GET")
+	s.router.PathPrefix("/assets/").Handler(http.StripPrefix("/assets", s.AssetHandler()))
 
 	// API endpoints
-	api := s.router.PathPrefix("/api").Subrouter()
-	api.HandleFunc("/meshes", s.handleGetMeshes).Methods("GET")
-	api.HandleFunc("/meshes/{name}", s.handleGetMesh).Methods("GET")
-	api.HandleFunc("/textures", s.handleGetTextures).Methods("GET")
-	api.HandleFunc("/state", s.handleGetState).Methods("GET")
-	api.HandleFunc("/state/water", s.handleUpdateWater).Methods("POST")
-	api.HandleFunc("/state/camera", s.handleUpdateCamera).Methods("POST")
+	s.router.PathPrefix("/api/").Handler(http.StripPrefix("/api", s.APIHandler()))
 
 	// WebSocket endpoint for real-time updates
-	s.router.HandleFunc("/ws", s.handleWebSocket)
+	s.router.Handle("/ws", s.WebSocketHandler())
 
 	// Shader serving
-	s.router.HandleFunc("/shaders/{name}", s.handleShader).Methods("GET")
+	s.router.PathPrefix("/shaders/").Handler(http.StripPrefix("/shaders", s.ShaderHandler()))
 
 	// Main application route
-	s.router.HandleFunc("/", s.handleIndex).Methods("GET")
+	s.router.Handle("/", s.IndexHandler()).Methods("GET")
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
+	if err := s.Initialize(); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+
+	s.logger.Printf("Starting server on port %d", s.port)
+	s.logger.Printf("Static path: %s", s.staticPath)
+
+	return s.Serve(listener)
+}
+
+// Initialize loads assets and restores the last checkpoint, if any. With
+// EnableBackgroundLoading, the assets are loaded after it returns.
+func (s *Server) Initialize() error {
 	// Initialize assets
-	if err := s.assets.Initialize(); err != nil {
-		return fmt.Errorf("failed to initialize assets: %w", err)
+	s.assets.SetShaderDir(s.shaderDir())
+	s.assets.SetFallbackFS(s.fallback.Assets, s.fallback.Shaders)
+	if s.precompress {
+		s.assets.EnablePrecompression(s.compression.http...)
+	}
+	if s.backgroundLoad {
+		go func() {
+			if err := s.loadAssets(); err != nil {
+				s.logger.Printf("Error loading assets: %v", err)
+			}
+		}()
+	} else if err := s.loadAssets(); err != nil {
+		return err
 	}
 
-	log.Printf("Starting server on port %d", s.port)
-	log.Printf("Static path: %s", s.staticPath)
+	// Resume from the last checkpoint, if any
+	if s.checkpointPath != "" {
+		if err := s.appState.LoadCheckpoint(s.checkpointPath, s.compression.checkpoints); err == nil {
+			s.logger.Printf("Restored state from checkpoint %s", s.checkpointPath)
+		} else if !os.IsNotExist(err) {
+			s.logger.Printf("Failed to restore checkpoint: %v", err)
+		}
+	}
+	s.loadCameraPresets()
 
-	// Start state update ticker
-	go s.startStateUpdates()
+	return nil
+}
 
-	return http.ListenAndServe(fmt.Sprintf(":%d", s.port), s.router)
+// StartBackground starts the simulation, checkpoint and asset reload loops.
+// It is safe to call more than once; only the first call has an effect.
+func (s *Server) StartBackground() {
+	s.background.Do(func() {
+		// Start state update ticker
+		go s.startStateUpdates()
+
+		if s.checkpointPath != "" {
+			go s.startCheckpoints()
+		}
+
+		if s.hotReload {
+			go s.watchAssets()
+		}
+	})
+}
+
+// Serve starts the background loops and serves HTTP on listener until Shutdown is called.
+// Initialize must be called first.
+func (s *Server) Serve(listener net.Listener) error {
+	s.StartBackground()
+
+	s.httpServer = &http.Server{Handler: s.router}
+	if s.hooks.OnServe != nil {
+		s.hooks.OnServe(listener.Addr())
+	}
+
+	err := s.httpServer.Serve(listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops the background loops and gracefully stops serving HTTP
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.done) })
+	s.closeRecording()
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
 }
 
-// startStateUpdates starts a ticker to update application state
+// Handler returns the HTTP handler serving every route, for mounting in another router.
+// The simulation only advances after StartBackground or Serve has been called.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// SetHooks installs callbacks for server events
+func (s *Server) SetHooks(hooks Hooks) {
+	s.hooks = hooks
+}
+
+// startStateUpdates runs the fixed-timestep simulation loop (see tick.go)
 func (s *Server) startStateUpdates() {
-	ticker := time.NewTicker(16 * time.Millisecond) // ~60 FPS
+	ticker := s.clock.NewTicker(frameInterval)
 	defer ticker.Stop()
 
-	lastTime := time.Now()
+	step := s.tickStep()
+	stepMilliseconds := float32(step.Seconds() * 1000)
+	lastTime := s.clock.Now()
+	var accumulator time.Duration
 
-	for range ticker.C {
-		now := time.Now()
-		deltaTime := float32(now.Sub(lastTime).Milliseconds())
-		lastTime = now
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C():
+			accumulator += now.Sub(lastTime)
+			lastTime = now
+
+			// Feed in replayed messages that are due before stepping
+			s.advanceReplay(now)
 
-		// Update application state
-		s.appState.Update(&state.AdvanceClockMessage{DeltaTime: deltaTime})
+			// Update application state in as many whole steps as have elapsed
+			for steps := 0; accumulator >= step; steps++ {
+				if steps == maxStepsPerFrame {
+					accumulator %= step
+					break
+				}
+				s.appState.Update(&state.AdvanceClockMessage{DeltaTime: stepMilliseconds})
+				if s.hooks.OnTick != nil {
+					s.hooks.OnTick(s.appState.GetClock())
+				}
+				accumulator -= step
+			}
+			s.setTickAlpha(float32(accumulator) / float32(step))
 
-		// Broadcast state updates to connected WebSocket clients
-		s.broadcastStateUpdate()
+			// Broadcast state updates to connected WebSocket clients
+			s.broadcastStateUpdate()
+		}
+	}
+}
+
+// DefaultCheckpointInterval is how often checkpoints are written when
+// EnableCheckpoints is given an interval of zero or less
+const DefaultCheckpointInterval = time.Minute
+
+// EnableCheckpoints makes the server restore state from path on start and
+// write a new checkpoint to it every interval
+func (s *Server) EnableCheckpoints(path string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	s.checkpointPath = path
+	s.checkpointInterval = interval
+}
+
+// SetInputLimits replaces the limits applied to camera updates from clients
+func (s *Server) SetInputLimits(limits InputLimits) {
+	s.inputs = newInputValidator(limits)
+}
+
+// startCheckpoints periodically writes the application state to the checkpoint file
+func (s *Server) startCheckpoints() {
+	ticker := s.clock.NewTicker(s.checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C():
+			if err := s.appState.SaveCheckpoint(s.checkpointPath, s.compression.checkpoints); err != nil {
+				s.logger.Printf("Error writing checkpoint: %v", err)
+			}
+		}
 	}
 }
 
@@ -137,11 +361,30 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
         input[type="range"] { width: 150px; }
         input[type="checkbox"] { margin-left: 8px; }
         h3 { margin-top: 0; margin-bottom: 15px; font-size: 14px; }
+        #loading {
+            position: absolute;
+            top: 0; left: 0; right: 0; bottom: 0;
+            display: flex;
+            flex-direction: column;
+            align-items: center;
+            justify-content: center;
+            background: #000;
+            color: white;
+            font-family: Arial, sans-serif;
+            font-size: 14px;
+        }
+        #loading-bar { width: 300px; height: 6px; margin-top: 10px; background: #333; border-radius: 3px; }
+        #loading-fill { width: 0; height: 100%; background: #4af; border-radius: 3px; }
     </style>
 </head>
 <body>
     <canvas id="canvas" width="1200" height="800"></canvas>
 
+    <div id="loading">
+        <div id="loading-text">Loading assets...</div>
+        <div id="loading-bar"><div id="loading-fill"></div></div>
+    </div>
+
     <div id="controls">
         <h3>Water Controls</h3>
         <div class="control-group">
@@ -184,30 +427,101 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 // handleAssetFile serves asset files (textures, etc.)
 func (s *Server) handleAssetFile(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	filename := vars["filename"]
-
-	// Try serving from current directory (where the original PNG files are)
-	// Working directory is now webgl-water root
-	rootPath := filepath.Join(".", filename)
-	if _, err := os.Stat(rootPath); err == nil {
-		w.Header().Set("Content-Type", getContentType(filename))
-		http.ServeFile(w, r, rootPath)
+	filename := r.PathValue("filename")
+	if query := r.URL.Query(); query.Has("w") || query.Has("h") {
+		s.handleResizedTexture(w, r, filename)
 		return
 	}
+	collection := s.assetsFor(r)
 
-	// Fall back to assets directory
-	assetsPath := filepath.Join(".", "assets", filename)
-	if _, err := os.Stat(assetsPath); err == nil {
-		w.Header().Set("Content-Type", getContentType(filename))
-		http.ServeFile(w, r, assetsPath)
+	// Serve a compressed variant (e.g. KTX2) to clients that ask for it
+	w.Header().Set("Vary", "Accept")
+	if variantPath, mimeType := collection.NegotiateTextureFile(filename, r.Header.Get("Accept")); variantPath != "" {
+		w.Header().Set("Content-Type", mimeType)
+		s.serveCachedFile(w, r, os.DirFS(filepath.Dir(variantPath)), filepath.Dir(variantPath), filepath.Base(variantPath), s.cachePolicy.Assets)
 		return
 	}
 
+	// Try serving from current directory (where the original PNG files are),
+	// then the assets directory below it, then the asset manager's files: the
+	// cache of a remote store, or embedded defaults. Other collections than the
+	// default are only served from their own files, which must not be shadowed.
+	type source struct {
+		name  string
+		files fs.FS
+	}
+	sources := []source{{s.assetSource(r), collection.Files()}}
+	if collection == s.assets {
+		sources = append([]source{{".", os.DirFS(".")}, {"assets", os.DirFS("assets")}}, sources...)
+	}
+	for _, source := range sources {
+		if _, err := fs.Stat(source.files, filename); err == nil {
+			w.Header().Set("Content-Type", getContentType(filename))
+			s.serveCachedFile(w, r, source.files, source.name, filename, s.cachePolicy.Assets)
+			return
+		}
+	}
+
 	// File not found
 	http.NotFound(w, r)
 }
 
+// handleResizedTexture serves a texture scaled down to fit the w and h query
+// parameters, for clients that do not need its full resolution. The texture is
+// looked up by name, then by file name.
+func (s *Server) handleResizedTexture(w http.ResponseWriter, r *http.Request, name string) {
+	collection := s.assetsFor(r)
+	var size [2]int
+	for i, param := range []string{"w", "h"} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s", param), http.StatusBadRequest)
+			return
+		}
+		size[i] = n
+	}
+
+	if _, err := collection.GetTexture(name); err != nil {
+		if byFile, ok := collection.TextureForFile(name); ok {
+			name = byFile
+		}
+	}
+	if _, err := collection.GetTexture(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	resized, err := collection.ResizeTexture(name, size[0], size[1])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", resized.MimeType)
+	serveCachedContent(w, r, resized.Data, s.cachePolicy.Assets)
+}
+
+// handleMipLevel serves one generated mipmap level of a texture as PNG
+func (s *Server) handleMipLevel(w http.ResponseWriter, r *http.Request) {
+	level, err := strconv.Atoi(r.PathValue("level"))
+	if err != nil {
+		http.Error(w, "Invalid mip level", http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.assetsFor(r).GetMipLevel(r.PathValue("name"), level)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	serveCachedContent(w, r, data, s.cachePolicy.Assets)
+}
+
 func getContentType(filename string) string {
 	ext := filepath.Ext(filename)
 	switch ext {
@@ -217,6 +531,14 @@ func getContentType(filename string) string {
 		return "image/jpeg"
 	case ".json":
 		return "application/json"
+	case ".ktx2":
+		return assets.KTX2MimeType
+	case ".dds":
+		return assets.DDSMimeType
+	case ".ogg", ".oga":
+		return assets.OggMimeType
+	case ".mp3":
+		return assets.MP3MimeType
 	default:
 		return "application/octet-stream"
 	}
@@ -224,32 +546,50 @@ func getContentType(filename string) string {
 
 // handleGetMeshes returns a list of all available meshes
 func (s *Server) handleGetMeshes(w http.ResponseWriter, r *http.Request) {
-	meshNames := s.assets.ListMeshes()
+	collection := s.assetsFor(r)
+	meshNames := collection.ListMeshes()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"meshes": meshNames,
+		"meshes":    meshNames,
+		"materials": collection.MeshMaterials(),
 	})
 }
 
-// handleGetMesh returns a specific mesh by name
+// handleGetMesh returns a specific mesh by name, as JSON or, for {name}.bin,
+// in the binary mesh format
 func (s *Server) handleGetMesh(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	meshName := vars["name"]
+	meshName := r.PathValue("name")
+	if name, ok := strings.CutSuffix(meshName, ".bin"); ok {
+		s.handleGetMeshBinary(w, r, name)
+		return
+	}
 
-	mesh, err := s.assets.GetMesh(meshName)
+	mesh, err := s.assetsFor(r).MeshJSON(meshName)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(mesh)
+	s.serveEncoded(w, r, mesh, "")
+}
+
+// handleGetMeshBinary returns a mesh in the binary mesh format
+func (s *Server) handleGetMeshBinary(w http.ResponseWriter, r *http.Request, meshName string) {
+	mesh, err := s.assetsFor(r).MeshBinary(meshName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	s.serveEncoded(w, r, mesh, "")
 }
 
 // handleGetTextures returns a list of all available textures
 func (s *Server) handleGetTextures(w http.ResponseWriter, r *http.Request) {
-	textureNames := s.assets.ListTextures()
+	textureNames := s.assetsFor(r).ListTextures()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -257,23 +597,46 @@ func (s *Server) handleGetTextures(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleGetState returns the current application state
-func (s *Server) handleGetState(w http.ResponseWriter, r *http.Request) {
-	camera := s.appState.GetCamera()
-	water := s.appState.GetWater()
-
-	response := map[string]interface{}{
-		"clock":   s.appState.GetClock(),
-		"scenery": s.appState.GetScenery(),
-		"camera": map[string]interface{}{
-			"position":   [3]float32{camera.GetPosition().X, camera.GetPosition().Y, camera.GetPosition().Z},
-			"viewMatrix": camera.GetViewMatrix().ToSlice(),
-		},
-		"water": water,
+// handleGetTexture returns the metadata of a specific texture
+func (s *Server) handleGetTexture(w http.ResponseWriter, r *http.Request) {
+	texture, err := s.assetsFor(r).GetTexture(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(texture)
+}
+
+// handleGetScenes returns a list of all imported scenes
+func (s *Server) handleGetScenes(w http.ResponseWriter, r *http.Request) {
+	sceneNames := s.assetsFor(r).ListScenes()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"scenes": sceneNames,
+	})
+}
+
+// handleGetScene returns a specific scene with its hierarchy and materials
+func (s *Server) handleGetScene(w http.ResponseWriter, r *http.Request) {
+	sceneName := r.PathValue("name")
+
+	scene, err := s.assetsFor(r).GetScene(sceneName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(scene)
+}
+
+// handleGetState returns the current application state
+func (s *Server) handleGetState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.statePayload(s.appState.Snapshot(), s.assetsFor(r)))
 }
 
 // WaterUpdateRequest represents a water property update request
@@ -293,21 +656,83 @@ func (s *Server) handleUpdateWater(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Apply updates
+	// Collect updates
+	var msgs []state.Message
 	if req.Reflectivity != nil {
-		s.appState.Update(&state.SetReflectivityMessage{Value: *req.Reflectivity})
+		msgs = append(msgs, &state.SetReflectivityMessage{Value: *req.Reflectivity})
 	}
 	if req.FresnelStrength != nil {
-		s.appState.Update(&state.SetFresnelMessage{Value: *req.FresnelStrength})
+		msgs = append(msgs, &state.SetFresnelMessage{Value: *req.FresnelStrength})
 	}
 	if req.WaveSpeed != nil {
-		s.appState.Update(&state.SetWaveSpeedMessage{Value: *req.WaveSpeed})
+		msgs = append(msgs, &state.SetWaveSpeedMessage{Value: *req.WaveSpeed})
 	}
 	if req.UseReflection != nil {
-		s.appState.Update(&state.UseReflectionMessage{Value: *req.UseReflection})
+		msgs = append(msgs, &state.UseReflectionMessage{Value: *req.UseReflection})
 	}
 	if req.UseRefraction != nil {
-		s.appState.Update(&state.UseRefractionMessage{Value: *req.UseRefraction})
+		msgs = append(msgs, &state.UseRefractionMessage{Value: *req.UseRefraction})
+	}
+	if !s.applyMessages(w, r, msgs) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
+
+// applyMessages validates every message before applying any of them, so a request
+// carrying one corrupted value leaves the state untouched. It writes a 400 response
+// and returns false if validation fails or the state rejects a message, such as
+// one naming a camera that does not exist; messages before that one stay applied.
+func (s *Server) applyMessages(w http.ResponseWriter, r *http.Request, msgs []state.Message) bool {
+	for _, msg := range msgs {
+		if err := state.ValidateMessage(msg); err != nil {
+			s.logger.Printf("Rejected state update: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+	for i, msg := range msgs {
+		if err := s.appState.Update(msg); err != nil {
+			s.recordParameters(r, msgs[:i]...)
+			s.logger.Printf("Rejected state update: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+	s.recordParameters(r, msgs...)
+	return true
+}
+
+// RenderUpdateRequest represents a render parameter update request
+type RenderUpdateRequest struct {
+	Exposure    *float32 `json:"exposure,omitempty"`
+	Gamma       *float32 `json:"gamma,omitempty"`
+	ToneMapping *string  `json:"toneMapping,omitempty"`
+}
+
+// handleUpdateRender updates exposure, gamma and tone mapping
+func (s *Server) handleUpdateRender(w http.ResponseWriter, r *http.Request) {
+	var req RenderUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Collect updates
+	var msgs []state.Message
+	if req.Exposure != nil {
+		msgs = append(msgs, &state.SetExposureMessage{Value: *req.Exposure})
+	}
+	if req.Gamma != nil {
+		msgs = append(msgs, &state.SetGammaMessage{Value: *req.Gamma})
+	}
+	if req.ToneMapping != nil {
+		msgs = append(msgs, &state.SetToneMappingMessage{Value: state.ToneMapping(*req.ToneMapping)})
+	}
+	if !s.applyMessages(w, r, msgs) {
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -325,108 +750,300 @@ type CameraUpdateRequest struct {
 		X int32 `json:"x"`
 		Y int32 `json:"y"`
 	} `json:"mouseMove,omitempty"`
-	Zoom *float32 `json:"zoom,omitempty"`
+	Zoom       *float32 `json:"zoom,omitempty"`
+	TouchOrbit *struct {
+		DX int32 `json:"dx"`
+		DY int32 `json:"dy"`
+	} `json:"touchOrbit,omitempty"` // Single finger drag in pixels since the previous touch event
+	Pinch     *float32           `json:"pinch,omitempty"`     // Finger distance divided by the distance at the previous touch event
+	Rotate    *float32           `json:"rotate,omitempty"`    // Radians two fingers turned counterclockwise since the previous touch event
+	KeyDown   state.Key          `json:"keyDown,omitempty"`   // KeyboardEvent.code of a pressed key, repeated while held
+	KeyUp     state.Key          `json:"keyUp,omitempty"`     // KeyboardEvent.code of a released key
+	Mode      state.CameraMode   `json:"mode,omitempty"`      // Switches between "orbit" and "walk"
+	Gamepad   *state.GamepadAxes `json:"gamepad,omitempty"`   // Stick deflections, resent while deflected
+	Timestamp float64            `json:"timestamp,omitempty"` // Client clock in milliseconds, must not go backwards
 }
 
 // handleUpdateCamera updates camera state
 func (s *Server) handleUpdateCamera(w http.ResponseWriter, r *http.Request) {
+	msgs, ok := s.readCameraUpdate(w, r)
+	if !ok || !s.applyMessages(w, r, msgs) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
+
+// readCameraUpdate decodes and checks a CameraUpdateRequest and returns its
+// state messages. On failure it writes the error response and returns false.
+func (s *Server) readCameraUpdate(w http.ResponseWriter, r *http.Request) ([]state.Message, bool) {
 	var req CameraUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
+		return nil, false
 	}
 
-	// Apply camera updates
+	// Reject implausible input before any of it reaches the shared camera
+	if err := s.inputs.allow(clientID(r), req.Timestamp, s.clock.Now()); err != nil {
+		status := http.StatusBadRequest
+		if err == errRateLimited {
+			status = http.StatusTooManyRequests
+		}
+		http.Error(w, err.Error(), status)
+		return nil, false
+	}
 	if req.MouseDown != nil {
-		s.appState.Update(&state.MouseDownMessage{X: req.MouseDown.X, Y: req.MouseDown.Y})
+		if err := s.inputs.checkPointer(req.MouseDown.X, req.MouseDown.Y); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if req.MouseMove != nil {
+		if err := s.inputs.checkPointer(req.MouseMove.X, req.MouseMove.Y); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if req.TouchOrbit != nil {
+		if err := s.inputs.checkPointer(req.TouchOrbit.DX, req.TouchOrbit.DY); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if req.Zoom != nil {
+		zoom, err := s.inputs.clampZoom(*req.Zoom)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		req.Zoom = &zoom
+	}
+
+	// Collect camera updates
+	var msgs []state.Message
+	if req.MouseDown != nil {
+		msgs = append(msgs, &state.MouseDownMessage{X: req.MouseDown.X, Y: req.MouseDown.Y})
 	}
 	if req.MouseUp != nil && *req.MouseUp {
-		s.appState.Update(&state.MouseUpMessage{})
+		msgs = append(msgs, &state.MouseUpMessage{})
 	}
 	if req.MouseMove != nil {
-		s.appState.Update(&state.MouseMoveMessage{X: req.MouseMove.X, Y: req.MouseMove.Y})
+		msgs = append(msgs, &state.MouseMoveMessage{X: req.MouseMove.X, Y: req.MouseMove.Y})
 	}
 	if req.Zoom != nil {
-		s.appState.Update(&state.ZoomMessage{Delta: *req.Zoom})
+		msgs = append(msgs, &state.ZoomMessage{Delta: *req.Zoom})
 	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+	if req.TouchOrbit != nil {
+		msgs = append(msgs, &state.TouchOrbitMessage{DX: req.TouchOrbit.DX, DY: req.TouchOrbit.DY})
+	}
+	if req.Pinch != nil {
+		msgs = append(msgs, &state.PinchZoomMessage{Scale: *req.Pinch})
+	}
+	if req.Rotate != nil {
+		msgs = append(msgs, &state.TwoFingerRotateMessage{Angle: *req.Rotate})
+	}
+	if req.Gamepad != nil {
+		msgs = append(msgs, &state.GamepadMessage{Axes: *req.Gamepad})
+	}
+	if req.KeyDown != "" {
+		msgs = append(msgs, &state.KeyDownMessage{Key: req.KeyDown})
+	}
+	if req.KeyUp != "" {
+		msgs = append(msgs, &state.KeyUpMessage{Key: req.KeyUp})
+	}
+	if req.Mode != "" {
+		msgs = append(msgs, &state.SetCameraModeMessage{Mode: req.Mode})
+	}
+	return msgs, true
 }
 
 // handleShader serves shader files
 func (s *Server) handleShader(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	shaderName := vars["name"]
-
-	shaderPath := filepath.Join(s.staticPath, "..", "shaders", shaderName)
+	shader, err := s.assetsFor(r).ShaderSource(r.PathValue("name"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
 
 	w.Header().Set("Content-Type", "text/plain")
-	http.ServeFile(w, r, shaderPath)
+	s.serveEncoded(w, r, shader, s.cachePolicy.Shaders)
 }
 
 // handleWebSocket handles WebSocket connections for real-time updates
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		s.logger.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 	defer conn.Close()
 
-	// Register client
-	s.clients[conn] = true
-	defer delete(s.clients, conn)
+	// Tell stale frontends to reload instead of streaming payloads they cannot parse
+	if version := clientProtocolVersion(r); version < MinProtocolVersion || version > ProtocolVersion {
+		s.logger.Printf("WebSocket client speaks protocol %d, requesting reload", version)
+		conn.WriteJSON(reloadRequired(version))
+		conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(reloadRequiredCloseCode, "reload required"))
+		return
+	}
+
+	// Register client with the streaming profile requested in ?profile=
+	stream := newClientStream(Profile(r.URL.Query().Get("profile")))
+	s.streamsMu.Lock()
+	s.streams[conn] = stream
+	s.streamsMu.Unlock()
+	defer func() {
+		s.streamsMu.Lock()
+		delete(s.streams, conn)
+		s.streamsMu.Unlock()
+	}()
 
-	log.Printf("WebSocket client connected")
+	s.hub.Register(conn)
+	defer s.hub.Unregister(conn)
+	defer s.exitXR(conn)
+
+	s.logger.Printf("WebSocket client connected")
+	if s.hooks.OnClientConnect != nil {
+		s.hooks.OnClientConnect(r)
+	}
+	if s.hooks.OnClientDisconnect != nil {
+		defer s.hooks.OnClientDisconnect(r)
+	}
+	if id := clientSessionID(r); s.analytics != nil && id != "" {
+		s.analytics.connect(id, s.clock.Now())
+		defer func() { s.analytics.disconnect(id, s.clock.Now()) }()
+	}
 
 	// Send initial state
-	s.sendStateUpdate(conn)
+	s.streamsMu.Lock()
+	initial := stream.keyframe(s.stateUpdate())
+	s.streamsMu.Unlock()
+	s.hub.Send(conn, initial)
+
+	// Keys this client holds are released, and its gamepad sticks returned to
+	// rest, when it disconnects
+	held := make(map[state.Key]bool)
+	deflected := false
+	defer func() {
+		for key := range held {
+			s.appState.Update(&state.KeyUpMessage{Key: key})
+		}
+		if deflected {
+			s.appState.Update(&state.GamepadMessage{})
+		}
+	}()
 
 	// Listen for client messages
 	for {
-		_, _, err := conn.ReadMessage()
+		_, data, err := conn.ReadMessage()
 		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
+			s.logger.Printf("WebSocket read error: %v", err)
 			break
 		}
-		// For now, we just ignore client messages
-		// In a more complete implementation, we could handle client-side state updates here
+
+		var msg clientMessage
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		switch msg.Type {
+		case clientMessageSetProfile:
+			s.streamsMu.Lock()
+			stream.setProfile(msg.Profile)
+			s.streamsMu.Unlock()
+		case clientMessageSleep:
+			s.streamsMu.Lock()
+			stream.sleep()
+			s.streamsMu.Unlock()
+		case clientMessageWake:
+			s.streamsMu.Lock()
+			keyframe := stream.wake(s.stateUpdate())
+			s.streamsMu.Unlock()
+			s.hub.Send(conn, keyframe)
+		case clientMessageKeyDown, clientMessageKeyUp:
+			if err := s.handleKeyMessage(r, msg, held); err != nil && err != errRateLimited {
+				s.logger.Printf("Rejected key message: %v", err)
+			}
+		case clientMessageGamepad:
+			if moved, err := s.handleGamepadMessage(r, msg); err == nil {
+				deflected = moved
+			} else if err != errRateLimited {
+				s.logger.Printf("Rejected gamepad message: %v", err)
+			}
+		case clientMessageFlyThroughStart, clientMessageFlyThroughStop:
+			if err := s.handleFlyThroughMessage(r, msg); err != nil && err != errRateLimited {
+				s.logger.Printf("Rejected fly-through message: %v", err)
+			}
+		case clientMessageXREnter, clientMessageXRExit, clientMessageXRPose:
+			if err := s.handleXRMessage(conn, r, msg.Type, data); err != nil && err != errRateLimited {
+				s.logger.Printf("Rejected XR message: %v", err)
+			}
+		}
 	}
 }
 
 // broadcastStateUpdate sends state updates to all connected WebSocket clients
 func (s *Server) broadcastStateUpdate() {
-	if len(s.clients) == 0 {
+	if s.hub.Count() == 0 {
 		return
 	}
 
-	for conn := range s.clients {
-		if err := s.sendStateUpdate(conn); err != nil {
-			log.Printf("Error sending state update: %v", err)
-			delete(s.clients, conn)
-			conn.Close()
+	update := s.stateUpdate()
+	errs := s.hub.Broadcast(func(conn *websocket.Conn) interface{} {
+		s.streamsMu.Lock()
+		defer s.streamsMu.Unlock()
+
+		stream, ok := s.streams[conn]
+		if !ok {
+			return update
 		}
+		return stream.next(update)
+	})
+	for _, err := range errs {
+		s.logger.Printf("Error sending state update: %v", err)
 	}
 }
 
-// sendStateUpdate sends the current state to a specific WebSocket connection
-func (s *Server) sendStateUpdate(conn *websocket.Conn) error {
-	camera := s.appState.GetCamera()
-	water := s.appState.GetWater()
+// stateUpdate builds the state_update message sent to WebSocket clients
+func (s *Server) stateUpdate() map[string]interface{} {
+	_, activeAssets := s.active()
+	update := s.statePayload(s.appState.Snapshot(), activeAssets)
+	update["type"] = "state_update"
+	return update
+}
 
-	stateUpdate := map[string]interface{}{
-		"type":    "state_update",
-		"clock":   s.appState.GetClock(),
-		"scenery": s.appState.GetScenery(),
-		"camera": map[string]interface{}{
-			"position":   [3]float32{camera.GetPosition().X, camera.GetPosition().Y, camera.GetPosition().Z},
-			"viewMatrix": camera.GetViewMatrix().ToSlice(),
-		},
-		"water": water,
-	}
+// statePayload builds the state served by GET /state and sent in state
+// updates, with the water mesh parameters of collection
+func (s *Server) statePayload(snapshot *state.Snapshot, collection *assets.Assets) map[string]interface{} {
+	camera, position := cameraView(snapshot)
 
-	return conn.WriteJSON(stateUpdate)
+	payload := map[string]interface{}{
+		"clock":   snapshot.Clock,
+		"scenery": snapshot.Scenery,
+		"camera":  camera,
+		"water":   snapshot.Water,
+		"render":  snapshot.Render,
+		"layers":  snapshot.Layers,
+		"version": snapshot.Version,
+		// Fraction of a simulation step between the clock and the present
+		"alpha":    s.tickAlpha(),
+		"tickRate": s.tickRate,
+		// Name of the camera the camera payload shows
+		"activeCamera": snapshot.ActiveCamera,
+		// Fly-through progress, for demo reel tooling
+		"flyThrough": snapshot.FlyThrough,
+		// How many settings changes can be undone and redone
+		"history": snapshot.History,
+		// Clients place the radial and projected water meshes themselves
+		"waterMesh": collection.WaterMeshParams(),
+	}
+	if s.clipmap != nil {
+		payload["clipmap"] = s.clipmap.Layout(position)
+	}
+	if snapshot.XR.Active {
+		payload["xr"] = xrPayload(snapshot.XR)
+	}
+	return payload
 }
 
 // GetPort returns the server port
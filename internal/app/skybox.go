@@ -0,0 +1,39 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleGetSkyboxes returns a list of all available skyboxes
+func (s *Server) handleGetSkyboxes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"skyboxes": s.assetsFor(r).ListSkyboxes(),
+	})
+}
+
+// handleGetSkybox returns the layout, size and face images of a specific skybox
+func (s *Server) handleGetSkybox(w http.ResponseWriter, r *http.Request) {
+	skybox, err := s.assetsFor(r).GetSkybox(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(skybox)
+}
+
+// handleSkyboxFace serves the image of one skybox face. Only paths registered
+// with the skybox are served, never a path taken from the request.
+func (s *Server) handleSkyboxFace(w http.ResponseWriter, r *http.Request) {
+	path, err := s.assetsFor(r).GetSkyboxFacePath(r.PathValue("name"), r.PathValue("face"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", getContentType(path))
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// Profile selects how state updates are streamed to a WebSocket client
+type Profile string
+
+// Supported streaming profiles
+const (
+	ProfileFull   Profile = "full"   // Every update, full precision
+	ProfileMobile Profile = "mobile" // Reduced rate, rounded floats, delta-only updates
+)
+
+// Mobile profile tuning
+const (
+	mobileUpdateDivider = 4    // Send every 4th tick (~15 Hz)
+	mobileFloatScale    = 1000 // Round floats to 3 decimals
+)
+
+// Control messages sent by WebSocket clients
+const (
+	clientMessageSetProfile = "set_profile" // Switch streaming profile
+	clientMessageSleep      = "sleep"       // Tab hidden: stop streaming
+	clientMessageWake       = "wake"        // Tab visible again: resume with a full keyframe
+)
+
+// clientMessage is a control message sent by a WebSocket client
+type clientMessage struct {
+	Type    string                `json:"type"`
+	Profile Profile               `json:"profile,omitempty"`
+	Key     state.Key             `json:"key,omitempty"`     // KeyboardEvent.code of key_down and key_up messages
+	Path    *state.FlyThroughPath `json:"path,omitempty"`    // Path of flythrough_start messages
+	Gamepad *state.GamepadAxes    `json:"gamepad,omitempty"` // Axes of gamepad messages
+}
+
+// clientStream tracks what has been sent to one WebSocket client
+type clientStream struct {
+	profile Profile
+	asleep  bool // The client's tab is hidden, so nothing is sent
+	tick    int
+	last    map[string]interface{} // Last payload sent in the mobile profile
+}
+
+// newClientStream creates a stream for the given profile, falling back to the full profile
+func newClientStream(profile Profile) *clientStream {
+	if profile != ProfileMobile {
+		profile = ProfileFull
+	}
+	return &clientStream{profile: profile}
+}
+
+// setProfile switches profiles. The next update is sent in full.
+func (c *clientStream) setProfile(profile Profile) {
+	*c = *newClientStream(profile)
+}
+
+// next returns the message to send for update, or nil to skip this tick
+func (c *clientStream) next(update map[string]interface{}) interface{} {
+	if c.asleep {
+		return nil
+	}
+	if c.profile != ProfileMobile {
+		return update
+	}
+
+	c.tick++
+	if c.last != nil && c.tick%mobileUpdateDivider != 0 {
+		return nil
+	}
+	return c.keyframeOrDelta(update)
+}
+
+// sleep stops streaming until wake is called
+func (c *clientStream) sleep() {
+	c.asleep = true
+}
+
+// wake resumes streaming and returns a full keyframe of update to send right away
+func (c *clientStream) wake(update map[string]interface{}) interface{} {
+	c.asleep = false
+	return c.keyframe(update)
+}
+
+// keyframe returns update as a complete message, resetting the delta baseline
+func (c *clientStream) keyframe(update map[string]interface{}) interface{} {
+	if c.profile != ProfileMobile {
+		return update
+	}
+
+	c.last = nil
+	return c.keyframeOrDelta(update)
+}
+
+// keyframeOrDelta rounds update and returns it in full the first time, or only
+// the fields that changed since the last message as a state_delta. Fields the
+// update no longer has, such as xr after leaving an XR session, are sent as
+// null so clients merging deltas drop them.
+func (c *clientStream) keyframeOrDelta(update map[string]interface{}) interface{} {
+	rounded, err := roundedPayload(update)
+	if err != nil {
+		return update
+	}
+
+	if c.last == nil {
+		c.last = rounded
+		return rounded
+	}
+
+	delta := map[string]interface{}{"type": "state_delta"}
+	for key, value := range rounded {
+		if key != "type" && !reflect.DeepEqual(c.last[key], value) {
+			delta[key] = value
+		}
+	}
+	for key := range c.last {
+		if _, ok := rounded[key]; !ok {
+			delta[key] = nil
+		}
+	}
+	c.last = rounded
+
+	if len(delta) == 1 {
+		return nil
+	}
+	return delta
+}
+
+// roundedPayload converts update to plain JSON values with every number rounded for the mobile profile
+func roundedPayload(update map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	return roundValue(payload).(map[string]interface{}), nil
+}
+
+// roundValue rounds every number inside a decoded JSON value
+func roundValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return math.Round(v*mobileFloatScale) / mobileFloatScale
+	case []interface{}:
+		for i := range v {
+			v[i] = roundValue(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = roundValue(v[key])
+		}
+	}
+	return value
+}
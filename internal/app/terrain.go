@@ -0,0 +1,39 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// handleGetTerrain returns the settings of the generated terrain
+func (s *Server) handleGetTerrain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.assetsFor(r).TerrainParams())
+}
+
+// handleGenerateTerrain regenerates the terrain and tells clients to re-fetch it.
+// Fields missing from the body keep their current values.
+func (s *Server) handleGenerateTerrain(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	params := collection.TerrainParams()
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	mesh, err := collection.GenerateTerrain(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindMesh, Name: mesh.Name})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"params":        params,
+		"vertexCount":   mesh.VertexCount,
+		"triangleCount": mesh.TriangleCount,
+	})
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// The simulation advances in fixed steps of 1/tickRate seconds, however late
+// the loop's ticker fires, so the same input always yields the same state.
+// Broadcasts go out at frame rate in between; their alpha says how far the
+// wall clock has moved from the last step towards the next, for clients to
+// interpolate.
+
+// DefaultTickRate is the number of simulation steps per second
+const DefaultTickRate = 60
+
+// MaxTickRate is the highest supported number of simulation steps per second
+const MaxTickRate = 1000
+
+// maxStepsPerFrame bounds how many steps one frame runs to catch up after a
+// stall, so a slow server drops time instead of falling further behind
+const maxStepsPerFrame = 5
+
+// frameInterval is how often the loop steps the simulation and broadcasts
+const frameInterval = 16 * time.Millisecond // ~60 FPS
+
+// SetTickRate sets how many fixed simulation steps run per second. Call it
+// before StartBackground.
+func (s *Server) SetTickRate(hz int) error {
+	if hz <= 0 || hz > MaxTickRate {
+		return fmt.Errorf("tick rate must be between 1 and %d Hz, got %d", MaxTickRate, hz)
+	}
+	s.tickRate = hz
+	return nil
+}
+
+// tickStep returns the simulated time one step covers
+func (s *Server) tickStep() time.Duration {
+	return time.Second / time.Duration(s.tickRate)
+}
+
+// tickAlpha returns how far between the last simulation step and the next
+// the wall clock was at the last frame, from 0 up to 1
+func (s *Server) tickAlpha() float32 {
+	return math.Float32frombits(s.alpha.Load())
+}
+
+// setTickAlpha records the interpolation alpha of the current frame
+func (s *Server) setTickAlpha(alpha float32) {
+	s.alpha.Store(math.Float32bits(alpha))
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// InputLimits bounds what a single client may send to the shared state
+type InputLimits struct {
+	MaxMessagesPerSecond float64 // Sustained update rate per client
+	Burst                float64 // Updates allowed in a burst above the sustained rate
+	MaxZoomDelta         float32 // Largest zoom step accepted in one message (larger steps are clamped)
+	MaxPointerCoord      int32   // Largest absolute pointer coordinate in pixels
+}
+
+// DefaultInputLimits returns limits suitable for mouse and touch clients at 60 Hz
+func DefaultInputLimits() InputLimits {
+	return InputLimits{
+		MaxMessagesPerSecond: 120,
+		Burst:                60,
+		MaxZoomDelta:         20,
+		MaxPointerCoord:      16384,
+	}
+}
+
+// clientInput tracks rate limiting and timestamp state for one client
+type clientInput struct {
+	tokens        float64
+	lastSeen      time.Time
+	lastTimestamp float64
+}
+
+// inputValidator checks inbound interaction messages for plausibility
+type inputValidator struct {
+	mu      sync.Mutex
+	limits  InputLimits
+	clients map[string]*clientInput
+}
+
+// errRateLimited is returned when a client sends updates faster than its limit allows
+var errRateLimited = errors.New("rate limit exceeded")
+
+// clientIdleTimeout is how long a client's validation state is kept after its last message
+const clientIdleTimeout = time.Minute
+
+func newInputValidator(limits InputLimits) *inputValidator {
+	return &inputValidator{
+		limits:  limits,
+		clients: make(map[string]*clientInput),
+	}
+}
+
+// client returns the tracking state for id, refilling its rate limit tokens
+func (v *inputValidator) client(id string, now time.Time) *clientInput {
+	c, exists := v.clients[id]
+	if !exists {
+		// Forget clients that went quiet so the map does not grow without bound
+		for otherID, other := range v.clients {
+			if now.Sub(other.lastSeen) > clientIdleTimeout {
+				delete(v.clients, otherID)
+			}
+		}
+		c = &clientInput{tokens: v.limits.Burst, lastSeen: now}
+		v.clients[id] = c
+	}
+
+	elapsed := now.Sub(c.lastSeen).Seconds()
+	c.tokens = math.Min(v.limits.Burst, c.tokens+elapsed*v.limits.MaxMessagesPerSecond)
+	c.lastSeen = now
+	return c
+}
+
+// allow consumes one message from the client's rate limit.
+// A timestamp of zero means the client did not send one; otherwise it must not go backwards.
+func (v *inputValidator) allow(id string, timestamp float64, now time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	c := v.client(id, now)
+	if c.tokens < 1 {
+		return errRateLimited
+	}
+	if timestamp != 0 {
+		if math.IsNaN(timestamp) || math.IsInf(timestamp, 0) || timestamp < c.lastTimestamp {
+			return fmt.Errorf("non-monotonic timestamp")
+		}
+		c.lastTimestamp = timestamp
+	}
+
+	c.tokens--
+	return nil
+}
+
+// checkPointer validates a pointer position in pixels
+func (v *inputValidator) checkPointer(x, y int32) error {
+	limit := v.limits.MaxPointerCoord
+	if x < -limit || x > limit || y < -limit || y > limit {
+		return fmt.Errorf("pointer position (%d, %d) out of range", x, y)
+	}
+	return nil
+}
+
+// clampZoom validates a zoom delta and clamps it to the configured magnitude
+func (v *inputValidator) clampZoom(delta float32) (float32, error) {
+	if math.IsNaN(float64(delta)) || math.IsInf(float64(delta), 0) {
+		return 0, fmt.Errorf("invalid zoom delta")
+	}
+	if delta > v.limits.MaxZoomDelta {
+		return v.limits.MaxZoomDelta, nil
+	}
+	if delta < -v.limits.MaxZoomDelta {
+		return -v.limits.MaxZoomDelta, nil
+	}
+	return delta, nil
+}
+
+// clientID identifies the client behind a request for rate limiting
+func clientID(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
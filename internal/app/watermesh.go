@@ -0,0 +1,46 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+)
+
+// SetWaterMesh generates the water mesh with params instead of the default
+// uniform grid. Clipmaps take precedence when enabled.
+func (s *Server) SetWaterMesh(params assets.WaterMeshParams) error {
+	_, err := s.assets.GenerateWaterMesh(params)
+	return err
+}
+
+// handleGetWaterMesh returns the settings of the water mesh
+func (s *Server) handleGetWaterMesh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.assetsFor(r).WaterMeshParams())
+}
+
+// handleGenerateWaterMesh regenerates the water mesh and tells clients to re-fetch it.
+// Fields missing from the body keep their current values.
+func (s *Server) handleGenerateWaterMesh(w http.ResponseWriter, r *http.Request) {
+	collection := s.assetsFor(r)
+	params := collection.WaterMeshParams()
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	mesh, err := collection.GenerateWaterMesh(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.broadcastAssetChanged(assets.AssetChange{Kind: assets.AssetKindMesh, Name: mesh.Name})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"params":        params,
+		"vertexCount":   mesh.VertexCount,
+		"triangleCount": mesh.TriangleCount,
+	})
+}
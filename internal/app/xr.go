@@ -0,0 +1,140 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// WebXR messages sent by WebSocket clients. One client at a time drives the
+// XR session; its head pose replaces the shared camera until it sends xr_exit
+// or disconnects.
+const (
+	clientMessageXREnter = "xr_enter" // Start a session at the current camera position
+	clientMessageXRExit  = "xr_exit"  // End the session
+	clientMessageXRPose  = "xr_pose"  // Poses of one XR frame, see xrPoseRequest
+)
+
+// xrPoseRequest is an xr_pose message. Clients send one per XR frame.
+type xrPoseRequest struct {
+	Head        state.XRPose         `json:"head"`
+	Views       []state.XRView       `json:"views"`
+	Controllers []state.XRController `json:"controllers"`
+	Timestamp   float64              `json:"timestamp,omitempty"` // XRFrame time in milliseconds, must not go backwards
+}
+
+// xrViewPayload is one per-eye view in state updates
+type xrViewPayload struct {
+	Eye              state.XREye `json:"eye"`
+	Position         math3d.Vec3 `json:"position"`
+	ViewMatrix       math3d.Mat4 `json:"viewMatrix"`
+	ProjectionMatrix math3d.Mat4 `json:"projectionMatrix"`
+}
+
+// xrControllerPayload is one controller in state updates, positioned in the scene
+type xrControllerPayload struct {
+	Hand        state.XRHand `json:"hand"`
+	Position    math3d.Vec3  `json:"position"`
+	Orientation math3d.Quat  `json:"orientation"`
+	Select      bool         `json:"select"`
+	Squeeze     bool         `json:"squeeze"`
+}
+
+// handleXRMessage applies an XR message from the client on conn
+func (s *Server) handleXRMessage(conn *websocket.Conn, r *http.Request, msgType string, data []byte) error {
+	s.streamsMu.Lock()
+	owner := s.xrOwner
+	if msgType == clientMessageXREnter && owner == nil {
+		s.xrOwner, owner = conn, conn
+	}
+	s.streamsMu.Unlock()
+	if owner == nil {
+		return fmt.Errorf("no XR session is active")
+	}
+	if owner != conn {
+		return fmt.Errorf("another client is driving the XR session")
+	}
+
+	switch msgType {
+	case clientMessageXREnter:
+		return s.appState.Update(&state.EnterXRMessage{})
+	case clientMessageXRExit:
+		s.exitXR(conn)
+		return nil
+	}
+
+	var req xrPoseRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("invalid XR pose: %w", err)
+	}
+	if err := s.inputs.allow(clientID(r), req.Timestamp, s.clock.Now()); err != nil {
+		return err
+	}
+	return s.appState.Update(&state.XRPoseMessage{Head: req.Head, Views: req.Views, Controllers: req.Controllers})
+}
+
+// exitXR ends the XR session if the client on conn drives it
+func (s *Server) exitXR(conn *websocket.Conn) {
+	s.streamsMu.Lock()
+	owner := s.xrOwner == conn
+	if owner {
+		s.xrOwner = nil
+	}
+	s.streamsMu.Unlock()
+
+	if owner {
+		s.appState.Update(&state.ExitXRMessage{})
+	}
+}
+
+// cameraView returns the camera payload of state updates and the camera
+// position. During an XR session both follow the headset.
+func cameraView(snapshot *state.Snapshot) (map[string]interface{}, math3d.Vec3) {
+	if xr := snapshot.XR; xr.Active {
+		position := xr.HeadPosition()
+		return map[string]interface{}{
+			"mode":       snapshot.Camera.Mode,
+			"position":   position,
+			"viewMatrix": xr.ViewMatrix(xr.Head),
+		}, position
+	}
+
+	return map[string]interface{}{
+		"mode":       snapshot.Camera.Mode,
+		"position":   snapshot.Camera.Position,
+		"viewMatrix": snapshot.Camera.ViewMatrix,
+	}, snapshot.Camera.Position
+}
+
+// xrPayload returns the per-eye views and controllers of an active XR session
+// for state updates. Projection matrices are passed through from the device.
+func xrPayload(xr state.XR) map[string]interface{} {
+	views := make([]xrViewPayload, len(xr.Views))
+	for i, view := range xr.Views {
+		views[i] = xrViewPayload{
+			Eye:              view.Eye,
+			Position:         xr.Origin.Add(view.Transform.Position),
+			ViewMatrix:       xr.ViewMatrix(view.Transform),
+			ProjectionMatrix: view.Projection,
+		}
+	}
+	controllers := make([]xrControllerPayload, len(xr.Controllers))
+	for i, controller := range xr.Controllers {
+		controllers[i] = xrControllerPayload{
+			Hand:        controller.Hand,
+			Position:    xr.Origin.Add(controller.Pose.Position),
+			Orientation: controller.Pose.Orientation,
+			Select:      controller.Select,
+			Squeeze:     controller.Squeeze,
+		}
+	}
+	return map[string]interface{}{
+		"origin":      xr.Origin,
+		"views":       views,
+		"controllers": controllers,
+	}
+}
@@ -2,48 +2,109 @@ package assets
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/ku3ppi/webgl-water/internal/codec"
 	"github.com/ku3ppi/webgl-water/internal/math3d"
 )
 
 // Assets manages all game assets (meshes, textures, etc.)
 type Assets struct {
-	meshes   map[string]*Mesh
-	textures map[string]*Texture
-	basePath string
+	mu            sync.RWMutex // Guards the maps once Watch reloads assets concurrently
+	meshes        map[string]*Mesh
+	textures      map[string]*Texture
+	scenes        map[string]*Scene
+	patches       map[string][]appliedScenePatch // Recent patches per scene, for conflict detection
+	mipmaps       map[string][][]byte            // PNG-encoded mip levels per texture name
+	resized       map[cacheKey]*ResizedTexture   // Downscaled textures served so far
+	skyboxes      map[string]*Skybox             // Sky environments by name
+	terrain       TerrainParams                  // Settings of the generated terrain
+	water         WaterMeshParams                // Settings of the water mesh
+	scatters      map[string]*scatterSet         // Instances scattered over terrain, by instanced mesh
+	ground        *Ground                        // Height queries against the terrain, built on first use
+	materials     map[string]*Material
+	audio         map[string]*Audio
+	spriteSheets  map[string]*SpriteSheet
+	fonts         map[string]*BitmapFont
+	collections   map[string]*collection // Other collections by name, guarded by collectionsMu
+	collectionsMu sync.Mutex
+	manifest      manifest       // Content hashes, built by Initialize
+	precompressed *precompressed // Compressed mesh JSON and shaders, built by Initialize
+	cache         *memoryCache   // Size and use of mesh encodings, mipmaps and resized textures
+	progress      loadProgress   // How far Initialize has come
+	loadWorkers   int            // Goroutines Initialize loads with, GOMAXPROCS if 0
+	basePath      string
+
+	shaderDir      string // Listed in the manifest if set
+	fallback       fs.FS  // Read when a file is missing from basePath
+	shaderFallback fs.FS  // Read when a shader is missing from shaderDir
+
+	precompressCodecs []codec.Codec // Codecs mesh JSON and shaders are precompressed with
 }
 
 // NewAssets creates a new asset manager
 func NewAssets(basePath string) *Assets {
 	return &Assets{
-		meshes:   make(map[string]*Mesh),
-		textures: make(map[string]*Texture),
-		basePath: basePath,
+		meshes:       make(map[string]*Mesh),
+		textures:     make(map[string]*Texture),
+		scenes:       make(map[string]*Scene),
+		patches:      make(map[string][]appliedScenePatch),
+		mipmaps:      make(map[string][][]byte),
+		resized:      make(map[cacheKey]*ResizedTexture),
+		skyboxes:     make(map[string]*Skybox),
+		scatters:     make(map[string]*scatterSet),
+		materials:    make(map[string]*Material),
+		audio:        make(map[string]*Audio),
+		spriteSheets: make(map[string]*SpriteSheet),
+		fonts:        make(map[string]*BitmapFont),
+		collections:  make(map[string]*collection),
+		water:        DefaultWaterMeshParams(),
+		cache:        newMemoryCache(),
+		basePath:     basePath,
 	}
 }
 
 // Mesh represents a 3D mesh with vertices, normals, and indices
 type Mesh struct {
-	Name          string    `json:"name"`
-	Vertices      []float32 `json:"vertices"`      // Position data (x, y, z, x, y, z, ...)
-	Normals       []float32 `json:"normals"`       // Normal data (nx, ny, nz, nx, ny, nz, ...)
-	TexCoords     []float32 `json:"texCoords"`     // Texture coordinates (u, v, u, v, ...)
-	Indices       []uint16  `json:"indices"`       // Triangle indices
-	VertexCount   int       `json:"vertexCount"`   // Number of vertices
-	TriangleCount int       `json:"triangleCount"` // Number of triangles
+	Name          string     `json:"name"`
+	Vertices      []float32  `json:"vertices"`           // Position data (x, y, z, x, y, z, ...)
+	Normals       []float32  `json:"normals"`            // Normal data (nx, ny, nz, nx, ny, nz, ...)
+	TexCoords     []float32  `json:"texCoords"`          // Texture coordinates (u, v, u, v, ...)
+	Tangents      []float32  `json:"tangents"`           // Tangent data (tx, ty, tz, w, ...), w is the bitangent sign
+	Indices       []uint32   `json:"indices"`            // Triangle indices
+	IndexWidth    int        `json:"indexWidth"`         // Bits per index for uploading Indices: 16, or 32 above 65536 vertices
+	VertexCount   int        `json:"vertexCount"`        // Number of vertices
+	TriangleCount int        `json:"triangleCount"`      // Number of triangles
+	Bounds        MeshBounds `json:"bounds"`             // Bounding box and sphere of Vertices
+	Material      string     `json:"material,omitempty"` // Name of the material the mesh is shaded with
 }
 
 // Texture represents texture metadata
 type Texture struct {
-	Name     string `json:"name"`
-	Width    int    `json:"width"`
-	Height   int    `json:"height"`
-	Format   string `json:"format"`
-	FilePath string `json:"filePath"`
+	Name       string           `json:"name"`
+	Width      int              `json:"width"`
+	Height     int              `json:"height"`
+	Format     string           `json:"format"`
+	FilePath   string           `json:"filePath"`
+	Mipmapped  bool             `json:"mipmapped"`          // Whether clients may generate mipmaps (power-of-two size)
+	ColorSpace ColorSpace       `json:"colorSpace"`         // How texel values must be interpreted when sampling
+	Levels     int              `json:"levels,omitempty"`   // Mip levels stored in the file itself (DDS)
+	Variants   []TextureVariant `json:"variants,omitempty"` // Compressed encodings served to clients that accept them
+}
+
+// IndexWidthFor returns the narrowest index width in bits that can address vertexCount vertices
+func IndexWidthFor(vertexCount int) int {
+	if vertexCount > math.MaxUint16+1 {
+		return 32
+	}
+	return 16
 }
 
 // MeshData represents the combined mesh data structure
@@ -53,16 +114,26 @@ type MeshData struct {
 
 // LoadMeshes loads all meshes from the meshes data file
 func (a *Assets) LoadMeshes() error {
+	meshes, err := a.readMeshes()
+	if err != nil {
+		return err
+	}
+	a.registerMeshes(meshes)
+	return nil
+}
+
+// readMeshes parses the meshes data file without registering its meshes
+func (a *Assets) readMeshes() ([]*Mesh, error) {
 	meshPath := filepath.Join(a.basePath, "../meshes.bytes")
 
 	// Check if the binary file exists, if not try JSON
-	jsonPath := filepath.Join(a.basePath, "meshes.json")
+	jsonPath := "meshes.json"
 
 	var meshData MeshData
 	var err error
 
 	if _, statErr := os.Stat(meshPath); statErr == nil {
-		// Load from binary file (if we implement binary format)
+		// Load from binary file
 		meshData, err = a.loadMeshesFromBinary(meshPath)
 	} else {
 		// Load from JSON file
@@ -70,21 +141,27 @@ func (a *Assets) LoadMeshes() error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to load meshes: %w", err)
+		return nil, fmt.Errorf("failed to load meshes: %w", err)
 	}
 
-	// Store meshes in the asset manager
-	for _, mesh := range meshData.Meshes {
-		meshCopy := mesh // Create a copy to avoid pointer issues
-		a.meshes[mesh.Name] = &meshCopy
+	meshes := make([]*Mesh, len(meshData.Meshes))
+	for i := range meshData.Meshes {
+		meshes[i] = &meshData.Meshes[i]
+		meshes[i].IndexWidth = IndexWidthFor(len(meshes[i].Vertices) / 3)
 	}
+	return meshes, nil
+}
 
-	return nil
+// registerMeshes stores meshes read from the meshes data file in the asset manager
+func (a *Assets) registerMeshes(meshes []*Mesh) {
+	for _, mesh := range meshes {
+		a.storeMesh(mesh.Name, mesh)
+	}
 }
 
-// loadMeshesFromJSON loads meshes from a JSON file
+// loadMeshesFromJSON loads meshes from a JSON file relative to the assets directory
 func (a *Assets) loadMeshesFromJSON(path string) (MeshData, error) {
-	file, err := os.Open(path)
+	file, err := a.openFile(path)
 	if err != nil {
 		return MeshData{}, err
 	}
@@ -103,15 +180,15 @@ func (a *Assets) loadMeshesFromJSON(path string) (MeshData, error) {
 	return meshData, nil
 }
 
-// loadMeshesFromBinary loads meshes from binary format (placeholder)
+// loadMeshesFromBinary loads meshes from the binary meshes.bytes produced by the original Rust tutorial build
 func (a *Assets) loadMeshesFromBinary(path string) (MeshData, error) {
-	// TODO: Implement binary mesh loading
-	// For now, return empty data and let it fall back to JSON
-	return MeshData{}, fmt.Errorf("binary mesh loading not implemented")
+	return loadTutorialMeshes(path)
 }
 
 // GetMesh returns a mesh by name
 func (a *Assets) GetMesh(name string) (*Mesh, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	mesh, exists := a.meshes[name]
 	if !exists {
 		return nil, fmt.Errorf("mesh '%s' not found", name)
@@ -121,6 +198,8 @@ func (a *Assets) GetMesh(name string) (*Mesh, error) {
 
 // ListMeshes returns a list of all loaded mesh names
 func (a *Assets) ListMeshes() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	names := make([]string, 0, len(a.meshes))
 	for name := range a.meshes {
 		names = append(names, name)
@@ -137,7 +216,7 @@ func (a *Assets) CreateWaterMesh(size float32, segments int) *Mesh {
 	vertices := make([]float32, vertexCount*3)
 	normals := make([]float32, vertexCount*3)
 	texCoords := make([]float32, vertexCount*2)
-	indices := make([]uint16, triangleCount*3)
+	indices := make([]uint32, triangleCount*3)
 
 	// Generate vertices, normals, and texture coordinates
 	step := size / float32(segments)
@@ -171,9 +250,9 @@ func (a *Assets) CreateWaterMesh(size float32, segments int) *Mesh {
 	indexCount := 0
 	for i := 0; i < segments; i++ {
 		for j := 0; j < segments; j++ {
-			topLeft := uint16(i*(segments+1) + j)
+			topLeft := uint32(i*(segments+1) + j)
 			topRight := topLeft + 1
-			bottomLeft := uint16((i+1)*(segments+1) + j)
+			bottomLeft := uint32((i+1)*(segments+1) + j)
 			bottomRight := bottomLeft + 1
 
 			// First triangle (top-left, bottom-left, top-right)
@@ -196,97 +275,39 @@ func (a *Assets) CreateWaterMesh(size float32, segments int) *Mesh {
 		Normals:       normals,
 		TexCoords:     texCoords,
 		Indices:       indices,
+		IndexWidth:    IndexWidthFor(vertexCount),
 		VertexCount:   vertexCount,
 		TriangleCount: triangleCount,
 	}
 
 	// Store the generated mesh
-	a.meshes["water_plane"] = mesh
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.storeMesh("water_plane", mesh)
 
 	return mesh
 }
 
-// CreateTerrainMesh generates a simple terrain mesh (for testing)
+// CreateTerrainMesh generates procedural terrain with the default noise settings,
+// lying between -heightScale and the water level
 func (a *Assets) CreateTerrainMesh(size float32, segments int, heightScale float32) *Mesh {
-	// Calculate vertex count
-	vertexCount := (segments + 1) * (segments + 1)
-	triangleCount := segments * segments * 2
-
-	vertices := make([]float32, vertexCount*3)
-	normals := make([]float32, vertexCount*3)
-	texCoords := make([]float32, vertexCount*2)
-	indices := make([]uint16, triangleCount*3)
-
-	// Generate vertices and texture coordinates
-	step := size / float32(segments)
-	halfSize := size * 0.5
-
-	for i := 0; i <= segments; i++ {
-		for j := 0; j <= segments; j++ {
-			index := i*(segments+1) + j
-			vertIndex := index * 3
-			texIndex := index * 2
-
-			// Position with some simple height variation
-			x := float32(j)*step - halfSize
-			z := float32(i)*step - halfSize
-			// Simple height function (could be replaced with noise)
-			height := heightScale * (float32(i+j) / float32(segments*2))
-
-			vertices[vertIndex] = x
-			vertices[vertIndex+1] = -height // Negative so it's below water
-			vertices[vertIndex+2] = z
-
-			// Texture coordinates
-			texCoords[texIndex] = float32(j) / float32(segments)
-			texCoords[texIndex+1] = float32(i) / float32(segments)
-		}
-	}
-
-	// Calculate normals (after vertices are set)
-	a.calculateNormals(vertices, indices, normals, segments)
-
-	// Generate indices for triangles
-	indexCount := 0
-	for i := 0; i < segments; i++ {
-		for j := 0; j < segments; j++ {
-			topLeft := uint16(i*(segments+1) + j)
-			topRight := topLeft + 1
-			bottomLeft := uint16((i+1)*(segments+1) + j)
-			bottomRight := bottomLeft + 1
-
-			// First triangle (top-left, bottom-left, top-right)
-			indices[indexCount] = topLeft
-			indices[indexCount+1] = bottomLeft
-			indices[indexCount+2] = topRight
-			indexCount += 3
-
-			// Second triangle (top-right, bottom-left, bottom-right)
-			indices[indexCount] = topRight
-			indices[indexCount+1] = bottomLeft
-			indices[indexCount+2] = bottomRight
-			indexCount += 3
-		}
-	}
-
-	mesh := &Mesh{
-		Name:          "terrain",
-		Vertices:      vertices,
-		Normals:       normals,
-		TexCoords:     texCoords,
-		Indices:       indices,
-		VertexCount:   vertexCount,
-		TriangleCount: triangleCount,
-	}
-
-	// Store the generated mesh
-	a.meshes["terrain"] = mesh
-
+	params := DefaultTerrainParams()
+	params.Size = size
+	params.Segments = segments
+	params.Amplitude = heightScale
+	params.BaseHeight = -heightScale
+
+	mesh := params.Mesh()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.storeMesh("terrain", mesh)
+	a.terrain = params
 	return mesh
 }
 
 // calculateNormals calculates vertex normals for a mesh
-func (a *Assets) calculateNormals(vertices []float32, indices []uint16, normals []float32, segments int) {
+func calculateNormals(vertices []float32, indices []uint32, normals []float32) {
 	// Initialize normals to zero
 	for i := range normals {
 		normals[i] = 0.0
@@ -331,6 +352,13 @@ func (a *Assets) calculateNormals(vertices []float32, indices []uint16, normals
 
 // RegisterTexture registers a texture with the asset manager
 func (a *Assets) RegisterTexture(name, filePath string, width, height int, format string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.registerTexture(name, filePath, width, height, format)
+}
+
+// registerTexture is RegisterTexture with the write lock already held
+func (a *Assets) registerTexture(name, filePath string, width, height int, format string) {
 	texture := &Texture{
 		Name:     name,
 		Width:    width,
@@ -338,11 +366,15 @@ func (a *Assets) RegisterTexture(name, filePath string, width, height int, forma
 		Format:   format,
 		FilePath: filePath,
 	}
+	texture.Mipmapped = texture.Mipmappable()
+	texture.ColorSpace = defaultColorSpace(name)
 	a.textures[name] = texture
 }
 
 // GetTexture returns a texture by name
 func (a *Assets) GetTexture(name string) (*Texture, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	texture, exists := a.textures[name]
 	if !exists {
 		return nil, fmt.Errorf("texture '%s' not found", name)
@@ -352,15 +384,19 @@ func (a *Assets) GetTexture(name string) (*Texture, error) {
 
 // GetTextureFilePath returns the full file path for a texture
 func (a *Assets) GetTextureFilePath(name string) (string, error) {
-	texture, err := a.GetTexture(name)
-	if err != nil {
-		return "", err
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	texture, exists := a.textures[name]
+	if !exists {
+		return "", fmt.Errorf("texture '%s' not found", name)
 	}
 	return filepath.Join(a.basePath, texture.FilePath), nil
 }
 
 // ListTextures returns a list of all registered texture names
 func (a *Assets) ListTextures() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	names := make([]string, 0, len(a.textures))
 	for name := range a.textures {
 		names = append(names, name)
@@ -368,16 +404,95 @@ func (a *Assets) ListTextures() []string {
 	return names
 }
 
-// Initialize sets up default assets
+// Initialize sets up default assets. Its progress is reported to LoadProgress
+// and the OnLoadProgress listener.
 func (a *Assets) Initialize() error {
+	err := a.initialize()
+	a.progress.finish(err)
+	return err
+}
+
+func (a *Assets) initialize() error {
+	a.progress.begin(LoadPhaseMeshes, 0)
+
 	// Create basic water and terrain meshes
-	a.CreateWaterMesh(20.0, 64)        // 20x20 unit water plane with 64x64 segments
+	if _, err := a.GenerateWaterMesh(a.WaterMeshParams()); err != nil {
+		return err
+	}
 	a.CreateTerrainMesh(50.0, 32, 5.0) // 50x50 unit terrain with height variation
 
-	// Register default textures (these should exist in the assets directory)
-	a.RegisterTexture("dudvmap", "dudvmap.png", 512, 512, "rgba")
-	a.RegisterTexture("normalmap", "normalmap.png", 512, 512, "rgba")
-	a.RegisterTexture("stone", "stone-texture.png", 512, 512, "rgba")
+	// A heightmap in the assets directory replaces the generated terrain
+	if _, err := a.CreateTerrainFromHeightmap(filepath.Join(a.basePath, heightmapFile), 50.0, 5.0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// Load exported meshes (such as the original tutorial's meshes.bytes) and
+	// import scenes from Blender, glTF 2.0 scenes and STL meshes from CAD tools
+	if err := a.importMeshes(); err != nil {
+		return err
+	}
+
+	a.progress.begin(LoadPhaseTextures, 0)
+	// Register default textures from their image headers. The handlers also serve
+	// them from the working directory, so keep the old metadata if they are not in
+	// the assets directory.
+	defaults := []struct{ name, file string }{
+		{"dudvmap", "dudvmap.png"},
+		{"normalmap", "normalmap.png"},
+		{"stone", "stone-texture.png"},
+	}
+	for _, texture := range defaults {
+		err := a.RegisterTextureFile(texture.name, texture.file)
+		if errors.Is(err, os.ErrNotExist) {
+			a.RegisterTexture(texture.name, texture.file, 512, 512, "rgba")
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Pick up any other images dropped into the assets directory
+	if err := a.ScanTextures(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := a.ScanSkyboxes(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := a.ScanAudio(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := a.ScanHUD(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := a.ScanCollections(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// The terrain is shaded with the stone texture unless materials.json says otherwise
+	stone := NewMaterial("stone")
+	stone.DiffuseTexture = "stone"
+	if err := a.SetMaterial(stone); err != nil {
+		return err
+	}
+	if terrain, err := a.GetMesh("terrain"); err == nil && terrain.Material == "" {
+		if err := a.AssignMaterial("terrain", stone.Name); err != nil {
+			return err
+		}
+	}
+	if err := a.LoadMaterials(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := a.GenerateMipmaps(); err != nil {
+		return err
+	}
 
+	// Hash and compress everything once loaded; reloads and regeneration update single entries
+	a.progress.begin(LoadPhaseCompression, 0)
+	if err := a.buildManifest(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := a.buildPrecompressed(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
 	return nil
 }
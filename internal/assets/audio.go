@@ -0,0 +1,197 @@
+package assets
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Audio formats and the MIME types they are served with
+const (
+	AudioFormatOgg = "ogg"
+	AudioFormatMP3 = "mp3"
+
+	OggMimeType = "audio/ogg"
+	MP3MimeType = "audio/mpeg"
+)
+
+// audioExtensions maps the sound files ScanAudio registers to their format
+var audioExtensions = map[string]string{".ogg": AudioFormatOgg, ".oga": AudioFormatOgg, ".mp3": AudioFormatMP3}
+
+// Audio is a sound file clients can stream, such as an ambient water or rain loop
+type Audio struct {
+	Name     string  `json:"name"`
+	FilePath string  `json:"filePath"` // Relative to the assets directory
+	Format   string  `json:"format"`   // One of the AudioFormat constants
+	MimeType string  `json:"mimeType"`
+	Size     int64   `json:"size"`   // File size in bytes
+	Loop     bool    `json:"loop"`   // Whether clients play the sound on repeat
+	Volume   float32 `json:"volume"` // Playback gain from 0 to 1
+}
+
+// AudioPlayback holds the playback settings of a sound that clients may change
+type AudioPlayback struct {
+	Loop   bool    `json:"loop"`
+	Volume float32 `json:"volume"`
+}
+
+// Validate reports an error if the volume is out of range
+func (p AudioPlayback) Validate() error {
+	if math.IsNaN(float64(p.Volume)) || p.Volume < 0 || p.Volume > 1 {
+		return fmt.Errorf("audio volume must be between 0 and 1, got %v", p.Volume)
+	}
+	return nil
+}
+
+// RegisterAudioFile registers the Ogg or MP3 file at filePath (relative to the
+// assets directory) as a sound, replacing any sound with the same name. The
+// format is taken from the file contents rather than its extension.
+func (a *Assets) RegisterAudioFile(name, filePath string, playback AudioPlayback) error {
+	if name == "" {
+		return fmt.Errorf("audio name must not be empty")
+	}
+	if err := playback.Validate(); err != nil {
+		return err
+	}
+	if !fs.ValidPath(filepath.ToSlash(filePath)) {
+		return fmt.Errorf("audio file '%s' must be inside the assets directory", filePath)
+	}
+
+	file, err := a.openFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 16)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("failed to read audio '%s': %w", name, err)
+	}
+	format, mimeType := sniffAudio(header[:n])
+	if format == "" {
+		return fmt.Errorf("audio '%s' is not an Ogg or MP3 file", name)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.audio[name] = &Audio{
+		Name:     name,
+		FilePath: filePath,
+		Format:   format,
+		MimeType: mimeType,
+		Size:     info.Size(),
+		Loop:     playback.Loop,
+		Volume:   playback.Volume,
+	}
+	return nil
+}
+
+// sniffAudio returns the format and MIME type of a sound from its first bytes
+func sniffAudio(header []byte) (string, string) {
+	switch {
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return AudioFormatOgg, OggMimeType
+	case bytes.HasPrefix(header, []byte("ID3")),
+		len(header) >= 2 && header[0] == 0xff && header[1]&0xe0 == 0xe0: // MPEG frame sync
+		return AudioFormatMP3, MP3MimeType
+	}
+	return "", ""
+}
+
+// ScanAudio registers every Ogg and MP3 file in the assets directory that is
+// not registered yet, named after the file without its extension. Sounds whose
+// name contains "loop", "ambient" or "rain" are looped.
+func (a *Assets) ScanAudio() error {
+	entries, err := fs.ReadDir(a.Files(), ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || audioExtensions[ext] == "" {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, err := a.GetAudio(name); err == nil {
+			continue
+		}
+		if err := a.RegisterAudioFile(name, entry.Name(), defaultAudioPlayback(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultAudioPlayback guesses from its name whether a sound is a loop
+func defaultAudioPlayback(name string) AudioPlayback {
+	lower := strings.ToLower(name)
+	loop := false
+	for _, hint := range []string{"loop", "ambient", "rain"} {
+		if strings.Contains(lower, hint) {
+			loop = true
+		}
+	}
+	return AudioPlayback{Loop: loop, Volume: 1}
+}
+
+// GetAudio returns a sound by name
+func (a *Assets) GetAudio(name string) (*Audio, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	audio, ok := a.audio[name]
+	if !ok {
+		return nil, fmt.Errorf("audio '%s' not found", name)
+	}
+	return audio, nil
+}
+
+// ListAudio returns the names of all sounds in sorted order
+func (a *Assets) ListAudio() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.audio))
+	for name := range a.audio {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetAudioPlayback changes whether a sound loops and how loud it plays
+func (a *Assets) SetAudioPlayback(name string, playback AudioPlayback) (*Audio, error) {
+	if err := playback.Validate(); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	audio, ok := a.audio[name]
+	if !ok {
+		return nil, fmt.Errorf("audio '%s' not found", name)
+	}
+	// Sounds are shared with readers, so replace rather than modify it
+	updated := *audio
+	updated.Loop, updated.Volume = playback.Loop, playback.Volume
+	a.audio[name] = &updated
+	return &updated, nil
+}
+
+// RemoveAudio unregisters a sound and reports whether it was registered.
+// Its file is left in place.
+func (a *Assets) RemoveAudio(name string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.audio[name]
+	delete(a.audio, name)
+	return ok
+}
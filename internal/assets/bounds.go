@@ -0,0 +1,70 @@
+package assets
+
+import (
+	"math"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// AABB is an axis-aligned bounding box in model space
+type AABB struct {
+	Min math3d.Vec3 `json:"min"`
+	Max math3d.Vec3 `json:"max"`
+}
+
+// Center returns the point halfway between Min and Max
+func (b AABB) Center() math3d.Vec3 {
+	return b.Min.Add(b.Max).Scale(0.5)
+}
+
+// Size returns the extent of the box along each axis
+func (b AABB) Size() math3d.Vec3 {
+	return b.Max.Sub(b.Min)
+}
+
+// BoundingSphere is a sphere containing every vertex of a mesh, in model space
+type BoundingSphere struct {
+	Center math3d.Vec3 `json:"center"`
+	Radius float32     `json:"radius"`
+}
+
+// MeshBounds are the bounding volumes of a mesh, computed when it is stored
+type MeshBounds struct {
+	Box    AABB           `json:"box"`
+	Sphere BoundingSphere `json:"sphere"`
+}
+
+// ComputeBounds returns the bounding box of the positions in vertices (x, y, z, ...)
+// and a sphere around the center of that box. A mesh without vertices has
+// empty bounds at the origin.
+func ComputeBounds(vertices []float32) MeshBounds {
+	if len(vertices) < 3 {
+		return MeshBounds{}
+	}
+
+	box := AABB{
+		Min: math3d.NewVec3(vertices[0], vertices[1], vertices[2]),
+		Max: math3d.NewVec3(vertices[0], vertices[1], vertices[2]),
+	}
+	for i := 3; i+2 < len(vertices); i += 3 {
+		v := math3d.NewVec3(vertices[i], vertices[i+1], vertices[i+2])
+		box.Min = box.Min.Min(v)
+		box.Max = box.Max.Max(v)
+	}
+
+	// Centering the sphere on the box is not minimal, but is tight for the
+	// grids and symmetric models this server serves and costs a single pass
+	center := box.Center()
+	var radiusSquared float32
+	for i := 0; i+2 < len(vertices); i += 3 {
+		d := math3d.NewVec3(vertices[i], vertices[i+1], vertices[i+2]).Sub(center).LengthSquared()
+		if d > radiusSquared {
+			radiusSquared = d
+		}
+	}
+
+	return MeshBounds{
+		Box:    box,
+		Sphere: BoundingSphere{Center: center, Radius: float32(math.Sqrt(float64(radiusSquared)))},
+	}
+}
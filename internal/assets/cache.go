@@ -0,0 +1,242 @@
+package assets
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// Besides the assets themselves, Assets keeps data derived from them: mesh
+// encodings, mipmap chains and resized textures. All of it can be rebuilt from
+// the assets, so with a memory budget the least recently used entries are
+// dropped and rebuilt when next requested. Entries of pinned assets are kept.
+
+// Kinds of cached data
+const (
+	CacheKindMeshJSON   = "meshJSON"   // Precompressed mesh JSON
+	CacheKindMeshBinary = "meshBinary" // Precompressed binary meshes
+	CacheKindMipmaps    = "mipmaps"    // Mipmap chains of textures
+	CacheKindResized    = "resized"    // Textures scaled down for clients
+)
+
+// coreAssets are the meshes and textures every client loads, pinned by NewAssets
+var coreAssets = []string{"water_plane", "terrain", "dudvmap", "normalmap", "stone"}
+
+// cacheKey identifies one cached entry
+type cacheKey struct {
+	kind    string
+	asset   string // Name of the mesh or texture the entry was derived from
+	variant string // Distinguishes entries of the same kind and asset, such as resized sizes
+}
+
+type cacheEntry struct {
+	key  cacheKey
+	size int64
+}
+
+// CacheStats describes the memory used by cached data
+type CacheStats struct {
+	Budget    int64            `json:"budget"`    // Bytes cached data may use, 0 for no limit
+	Used      int64            `json:"used"`      // Bytes of cached data held
+	Pinned    int64            `json:"pinned"`    // Bytes of Used held for pinned assets, never evicted
+	Entries   int              `json:"entries"`   // Number of cached entries
+	ByKind    map[string]int64 `json:"byKind"`    // Bytes of Used by the CacheKind constants
+	Hits      uint64           `json:"hits"`      // Requests served from the cache
+	Misses    uint64           `json:"misses"`    // Requests for data that had to be built, such as evicted entries
+	Evictions uint64           `json:"evictions"` // Entries dropped to stay within the budget
+	MeshBytes int64            `json:"meshBytes"` // Bytes of vertex and index data of all meshes, which is not evicted
+	Pins      []string         `json:"pins"`      // Names of pinned assets
+}
+
+// memoryCache tracks the size and use of cached entries in least recently used
+// order. It only does the bookkeeping; Assets holds the data and drops the
+// entries it reports as evicted. It has its own lock so entries can be marked
+// as used while Assets is only read locked.
+type memoryCache struct {
+	mu      sync.Mutex
+	budget  int64
+	used    int64
+	order   *list.List // Of *cacheEntry, most recently used first
+	entries map[cacheKey]*list.Element
+	pinned  map[string]bool // By asset name
+
+	hits, misses, evictions uint64
+}
+
+func newMemoryCache() *memoryCache {
+	cache := &memoryCache{
+		order:   list.New(),
+		entries: make(map[cacheKey]*list.Element),
+		pinned:  make(map[string]bool),
+	}
+	for _, name := range coreAssets {
+		cache.pinned[name] = true
+	}
+	return cache
+}
+
+// add records an entry of size bytes as most recently used and returns the
+// entries to drop to stay within the budget, which may include the new one
+func (c *memoryCache) add(key cacheKey, size int64) []cacheKey {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.used -= element.Value.(*cacheEntry).size
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, size: size})
+	c.used += size
+	return c.evict()
+}
+
+// evict removes the least recently used unpinned entries until the budget is
+// met and returns them. The lock must be held.
+func (c *memoryCache) evict() []cacheKey {
+	var evicted []cacheKey
+	for element := c.order.Back(); element != nil && c.budget > 0 && c.used > c.budget; {
+		previous := element.Prev()
+		entry := element.Value.(*cacheEntry)
+		if !c.isPinned(entry.key) {
+			c.order.Remove(element)
+			delete(c.entries, entry.key)
+			c.used -= entry.size
+			c.evictions++
+			evicted = append(evicted, entry.key)
+		}
+		element = previous
+	}
+	return evicted
+}
+
+// isPinned reports whether an entry must not be evicted. Variants such as
+// resized textures are requested in any number of sizes, so only the entries
+// without one are pinned. The lock must be held.
+func (c *memoryCache) isPinned(key cacheKey) bool {
+	return key.variant == "" && c.pinned[key.asset]
+}
+
+// remove forgets an entry that was dropped or replaced
+func (c *memoryCache) remove(key cacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.used -= element.Value.(*cacheEntry).size
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// touch marks an entry as used and counts a hit
+func (c *memoryCache) touch(key cacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		c.hits++
+	}
+}
+
+// miss counts a request for data that had to be built
+func (c *memoryCache) miss() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.misses++
+}
+
+// SetMemoryBudget limits the bytes of cached data to budget, evicting the least
+// recently used entries of unpinned assets beyond it. 0 removes the limit.
+func (a *Assets) SetMemoryBudget(budget int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache.mu.Lock()
+	a.cache.budget = max(budget, 0)
+	evicted := a.cache.evict()
+	a.cache.mu.Unlock()
+	a.dropCached(evicted)
+}
+
+// PinAsset keeps the cached data of the mesh or texture name from being evicted.
+// The water, terrain and default textures are pinned from the start.
+func (a *Assets) PinAsset(name string) {
+	a.cache.mu.Lock()
+	defer a.cache.mu.Unlock()
+	a.cache.pinned[name] = true
+}
+
+// UnpinAsset lets the cached data of name be evicted again
+func (a *Assets) UnpinAsset(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache.mu.Lock()
+	delete(a.cache.pinned, name)
+	evicted := a.cache.evict()
+	a.cache.mu.Unlock()
+	a.dropCached(evicted)
+}
+
+// CacheStats reports the memory used by cached data and by meshes
+func (a *Assets) CacheStats() CacheStats {
+	a.mu.RLock()
+	var meshBytes int64
+	for _, mesh := range a.meshes {
+		meshBytes += int64(4 * (len(mesh.Vertices) + len(mesh.Normals) + len(mesh.TexCoords) + len(mesh.Tangents) + len(mesh.Indices)))
+	}
+	a.mu.RUnlock()
+
+	c := a.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := CacheStats{
+		Budget:    c.budget,
+		Used:      c.used,
+		Entries:   len(c.entries),
+		ByKind:    make(map[string]int64),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		MeshBytes: meshBytes,
+		Pins:      make([]string, 0, len(c.pinned)),
+	}
+	for _, element := range c.entries {
+		entry := element.Value.(*cacheEntry)
+		stats.ByKind[entry.key.kind] += entry.size
+		if c.isPinned(entry.key) {
+			stats.Pinned += entry.size
+		}
+	}
+	for name := range c.pinned {
+		stats.Pins = append(stats.Pins, name)
+	}
+	sort.Strings(stats.Pins)
+	return stats
+}
+
+// cacheAdd records cached data and drops whatever the budget evicts. The write lock must be held.
+func (a *Assets) cacheAdd(key cacheKey, size int64) {
+	a.dropCached(a.cache.add(key, size))
+}
+
+// dropCached deletes evicted entries from where Assets holds them. The write lock must be held.
+func (a *Assets) dropCached(keys []cacheKey) {
+	for _, key := range keys {
+		switch key.kind {
+		case CacheKindMeshJSON:
+			delete(a.precompressed.meshes, key.asset)
+		case CacheKindMeshBinary:
+			delete(a.precompressed.binaries, key.asset)
+		case CacheKindMipmaps:
+			delete(a.mipmaps, key.asset)
+		case CacheKindResized:
+			delete(a.resized, key)
+		}
+	}
+}
+
+// encodedSize returns the bytes held by content and its encodings
+func encodedSize(content *EncodedContent) int64 {
+	size := int64(len(content.Data))
+	for _, encoding := range content.Encodings {
+		size += int64(len(encoding))
+	}
+	return size
+}
@@ -0,0 +1,154 @@
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A collection is a set of assets with its own namespace, so scenes can use the
+// same names for different meshes and textures. Each collection is an asset
+// manager of its own, rooted at a subdirectory of CollectionsDir; files missing
+// from it are read from the default collection. Collections are loaded when
+// first requested, and Watch only reloads the default collection.
+
+// DefaultCollection names the assets directory itself
+const DefaultCollection = "default"
+
+// CollectionsDir is the subdirectory of the assets directory holding one
+// directory per collection
+const CollectionsDir = "collections"
+
+// ErrCollectionNotFound is returned for collections that are not registered
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// CollectionInfo describes a registered collection
+type CollectionInfo struct {
+	Name   string `json:"name"`
+	Loaded bool   `json:"loaded"`          // Whether its assets have been loaded
+	Error  string `json:"error,omitempty"` // Why loading failed
+}
+
+// collection is a registered collection, loaded on first use
+type collection struct {
+	dir    string
+	assets *Assets
+	once   sync.Once
+	loaded bool
+	err    error
+}
+
+// RegisterCollection adds a collection of the assets in dir, loaded when first
+// requested. Registering a name again replaces the collection.
+func (a *Assets) RegisterCollection(name, dir string) error {
+	if name == "" || name == DefaultCollection || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid collection name '%s'", name)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("collection '%s': %w", name, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("collection '%s': '%s' is not a directory", name, dir)
+	}
+
+	a.collectionsMu.Lock()
+	defer a.collectionsMu.Unlock()
+	a.collections[name] = &collection{dir: dir}
+	return nil
+}
+
+// ScanCollections registers every directory in CollectionsDir as a collection
+// of its name. Collections already registered are kept.
+func (a *Assets) ScanCollections() error {
+	entries, err := os.ReadDir(filepath.Join(a.basePath, CollectionsDir))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		a.collectionsMu.Lock()
+		_, exists := a.collections[entry.Name()]
+		a.collectionsMu.Unlock()
+		if exists {
+			continue
+		}
+		if err := a.RegisterCollection(entry.Name(), filepath.Join(a.basePath, CollectionsDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Collection returns the asset manager of a collection, loading it on first
+// use. An empty name or DefaultCollection returns a itself.
+func (a *Assets) Collection(name string) (*Assets, error) {
+	if name == "" || name == DefaultCollection {
+		return a, nil
+	}
+
+	a.collectionsMu.Lock()
+	c, ok := a.collections[name]
+	a.collectionsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrCollectionNotFound, name)
+	}
+
+	c.once.Do(func() {
+		c.assets = a.newCollectionAssets(c.dir)
+		if err := c.assets.Initialize(); err != nil {
+			c.err = fmt.Errorf("failed to load collection '%s': %w", name, err)
+		}
+		a.collectionsMu.Lock()
+		c.loaded = true
+		a.collectionsMu.Unlock()
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.assets, nil
+}
+
+// newCollectionAssets creates the asset manager of a collection in dir, with
+// the settings of a and a's files as its fallback
+func (a *Assets) newCollectionAssets(dir string) *Assets {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	assets := NewAssets(dir)
+	assets.shaderDir = a.shaderDir
+	assets.fallback = a.Files()
+	assets.shaderFallback = a.shaderFallback
+	assets.precompressCodecs = a.precompressCodecs
+	assets.loadWorkers = a.loadWorkers
+
+	a.cache.mu.Lock()
+	defer a.cache.mu.Unlock()
+	assets.cache.budget = a.cache.budget
+	for name := range a.cache.pinned {
+		assets.cache.pinned[name] = true
+	}
+	return assets
+}
+
+// ListCollections returns the default collection followed by the registered
+// collections in name order
+func (a *Assets) ListCollections() []CollectionInfo {
+	a.collectionsMu.Lock()
+	defer a.collectionsMu.Unlock()
+	collections := []CollectionInfo{{Name: DefaultCollection, Loaded: a.LoadProgress().Ready}}
+	for name, c := range a.collections {
+		info := CollectionInfo{Name: name, Loaded: c.loaded && c.err == nil}
+		if c.loaded && c.err != nil {
+			info.Error = c.err.Error()
+		}
+		collections = append(collections, info)
+	}
+	sort.Slice(collections[1:], func(i, j int) bool { return collections[i+1].Name < collections[j+1].Name })
+	return collections
+}
@@ -0,0 +1,160 @@
+package assets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DDS files start with the magic "DDS " followed by a 124-byte header:
+//
+//	size, flags, height, width, pitchOrLinearSize, depth, mipMapCount,
+//	reserved[11], pixel format (32 bytes), caps[4], reserved (all uint32)
+//
+// Block-compressed formats are identified by the pixel format's FourCC.
+// The texel data of every mip level follows the header, largest first.
+
+// ddsMagic identifies a DDS file
+const ddsMagic = "DDS "
+
+// DDSMimeType is the media type of DDS files
+const DDSMimeType = "image/vnd-ms.dds"
+
+// ddsHeaderSize is the size of the magic and header preceding the texel data
+const ddsHeaderSize = 4 + 124
+
+// ddsPixelFormatFourCC is the pixel format flag marking a valid FourCC
+const ddsPixelFormatFourCC = 0x4
+
+// DDS texture formats reported to clients
+const (
+	TextureFormatDXT1 = "dxt1"
+	TextureFormatDXT5 = "dxt5"
+)
+
+// DDSHeader is the header of a DDS file
+type DDSHeader struct {
+	Size              uint32
+	Flags             uint32
+	Height            uint32
+	Width             uint32
+	PitchOrLinearSize uint32
+	Depth             uint32
+	MipMapCount       uint32
+	Reserved1         [11]uint32
+	PixelFormat       struct {
+		Size        uint32
+		Flags       uint32
+		FourCC      [4]byte
+		RGBBitCount uint32
+		RBitMask    uint32
+		GBitMask    uint32
+		BBitMask    uint32
+		ABitMask    uint32
+	}
+	Caps      [4]uint32
+	Reserved2 uint32
+}
+
+// ReadDDSHeader decodes the header of a DDS file
+func ReadDDSHeader(r io.Reader) (DDSHeader, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return DDSHeader{}, fmt.Errorf("failed to read DDS magic: %w", err)
+	}
+	if string(magic[:]) != ddsMagic {
+		return DDSHeader{}, fmt.Errorf("not a DDS file")
+	}
+
+	var header DDSHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return DDSHeader{}, fmt.Errorf("failed to read DDS header: %w", err)
+	}
+	if header.Size != 124 {
+		return DDSHeader{}, fmt.Errorf("DDS header has size %d, expected 124", header.Size)
+	}
+	if header.Width == 0 || header.Height == 0 {
+		return DDSHeader{}, fmt.Errorf("DDS file has zero size")
+	}
+	// Bounded before ddsDataSize walks the levels
+	if levels := mipLevelsFor(int(header.Width), int(header.Height)); header.Levels() > levels {
+		return DDSHeader{}, fmt.Errorf("DDS file has %d mip levels, a %dx%d texture has at most %d",
+			header.MipMapCount, header.Width, header.Height, levels)
+	}
+	return header, nil
+}
+
+// Format returns the texture format of a DDS file, or an error for
+// anything but DXT1 and DXT5 block compression
+func (h DDSHeader) Format() (string, error) {
+	if h.PixelFormat.Flags&ddsPixelFormatFourCC == 0 {
+		return "", fmt.Errorf("uncompressed DDS files are not supported")
+	}
+	switch string(h.PixelFormat.FourCC[:]) {
+	case "DXT1":
+		return TextureFormatDXT1, nil
+	case "DXT5":
+		return TextureFormatDXT5, nil
+	default:
+		return "", fmt.Errorf("unsupported DDS format '%s'", h.PixelFormat.FourCC[:])
+	}
+}
+
+// Levels returns the number of mip levels stored in a DDS file
+func (h DDSHeader) Levels() int {
+	return max(int(h.MipMapCount), 1)
+}
+
+// ddsDataSize returns the number of bytes of texel data in levels mip levels
+// of a block-compressed texture
+func ddsDataSize(width, height, levels int, format string) int64 {
+	blockSize := int64(16)
+	if format == TextureFormatDXT1 {
+		blockSize = 8
+	}
+
+	var size int64
+	for i := 0; i < levels; i++ {
+		blocksWide := int64(max((width+3)/4, 1))
+		blocksHigh := int64(max((height+3)/4, 1))
+		size += blocksWide * blocksHigh * blockSize
+		width, height = max(width/2, 1), max(height/2, 1)
+	}
+	return size
+}
+
+// registerDDSTexture registers a DDS texture with the format and mip levels
+// read from its header
+func (a *Assets) registerDDSTexture(name, filePath string) error {
+	file, err := a.openFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, err := ReadDDSHeader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read texture '%s': %w", name, err)
+	}
+	format, err := header.Format()
+	if err != nil {
+		return fmt.Errorf("failed to read texture '%s': %w", name, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	width, height, levels := int(header.Width), int(header.Height), header.Levels()
+	if info.Size() < ddsHeaderSize+ddsDataSize(width, height, levels, format) {
+		return fmt.Errorf("texture '%s': DDS file is truncated", name)
+	}
+
+	a.registerTexture(name, filePath, width, height, format)
+
+	// Compressed mip levels come from the file; clients cannot generate them
+	texture := a.textures[name]
+	texture.Mipmapped = false
+	texture.Levels = levels
+	return nil
+}
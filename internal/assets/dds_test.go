@@ -0,0 +1,113 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ddsFile encodes a DDS header of the given size and FourCC followed by
+// dataSize bytes of texel data
+func ddsFile(width, height, mipMapCount uint32, fourCC string, dataSize int64) []byte {
+	var header DDSHeader
+	header.Size = 124
+	header.Width, header.Height, header.MipMapCount = width, height, mipMapCount
+	header.PixelFormat.Size = 32
+	header.PixelFormat.Flags = ddsPixelFormatFourCC
+	copy(header.PixelFormat.FourCC[:], fourCC)
+
+	var file bytes.Buffer
+	file.WriteString(ddsMagic)
+	binary.Write(&file, binary.LittleEndian, header)
+	file.Write(make([]byte, dataSize))
+	return file.Bytes()
+}
+
+func TestReadDDSHeader(t *testing.T) {
+	valid := ddsFile(64, 32, 7, "DXT5", 0)
+
+	badMagic := bytes.Clone(valid)
+	copy(badMagic, "PNG ")
+
+	badSize := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(badSize[4:], 128)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantFormat string
+		wantErr    bool
+	}{
+		{"dxt5", valid, TextureFormatDXT5, false},
+		{"dxt1", ddsFile(4, 4, 0, "DXT1", 0), TextureFormatDXT1, false},
+		{"truncated", valid[:100], "", true},
+		{"oversized mip count", ddsFile(64, 32, 0xFFFFFFFF, "DXT5", 0), "", true},
+		{"bad magic", badMagic, "", true},
+		{"bad header size", badSize, "", true},
+		{"zero size", ddsFile(0, 32, 1, "DXT5", 0), "", true},
+		{"unsupported format", ddsFile(64, 32, 1, "ATI2", 0), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := ReadDDSHeader(bytes.NewReader(tt.data))
+			var format string
+			if err == nil {
+				format, err = header.Format()
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("got format %s, want %s", format, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestRegisterDDSTexture(t *testing.T) {
+	// 64x32 DXT5 with 7 levels: 128 + 32 + 8 + 2 + 1 + 1 + 1 blocks of 16 bytes
+	const dataSize = 173 * 16
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"valid", ddsFile(64, 32, 7, "DXT5", dataSize), false},
+		{"truncated texel data", ddsFile(64, 32, 7, "DXT5", dataSize-1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "water.dds"), tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			a := NewAssets(dir)
+			err := a.registerDDSTexture("water", "water.dds")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			texture, err := a.GetTexture("water")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if texture.Levels != 7 || texture.Format != TextureFormatDXT5 {
+				t.Errorf("got %d levels of %s, want 7 of %s", texture.Levels, texture.Format, TextureFormatDXT5)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package assets
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+)
+
+// MaxDudvSize bounds the width and height of generated dudv maps
+const MaxDudvSize = 2048
+
+// DudvParams configures the procedural dudv map generator. The red and green
+// channels hold two independent fractal noise fields, centered on 128, that the
+// water shader turns into texture coordinate offsets.
+type DudvParams struct {
+	Seed        int64   `json:"seed"`
+	Size        int     `json:"size"`        // Width and height in pixels, a power of two
+	Scale       int     `json:"scale"`       // Noise features across the map in the first octave
+	Octaves     int     `json:"octaves"`     // Number of noise layers summed
+	Persistence float32 `json:"persistence"` // Amplitude multiplier per octave; lower is smoother
+	Strength    float32 `json:"strength"`    // 0 gives no distortion, 1 uses the full channel range
+}
+
+// DefaultDudvParams returns settings close to the character of the bundled dudvmap.png
+func DefaultDudvParams() DudvParams {
+	return DudvParams{
+		Seed:        1,
+		Size:        256,
+		Scale:       4,
+		Octaves:     3,
+		Persistence: 0.5,
+		Strength:    1,
+	}
+}
+
+// Validate reports an error if the parameters cannot produce a dudv map
+func (p DudvParams) Validate() error {
+	for name, value := range map[string]float32{"persistence": p.Persistence, "strength": p.Strength} {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("dudv %s must be finite, got %v", name, value)
+		}
+	}
+	switch {
+	case p.Size < 1 || p.Size > MaxDudvSize || p.Size&(p.Size-1) != 0:
+		return fmt.Errorf("dudv size must be a power of two up to %d, got %d", MaxDudvSize, p.Size)
+	case p.Octaves < 1 || p.Octaves > 8:
+		return fmt.Errorf("dudv octaves must be between 1 and 8, got %d", p.Octaves)
+	case p.Scale < 1 || p.Scale<<(p.Octaves-1) > 256:
+		return fmt.Errorf("dudv scale doubled for each octave must stay between 1 and 256, got %d", p.Scale)
+	case p.Persistence <= 0 || p.Persistence > 1:
+		return fmt.Errorf("dudv persistence must be above 0 and at most 1, got %v", p.Persistence)
+	case p.Strength < 0 || p.Strength > 1:
+		return fmt.Errorf("dudv strength must be between 0 and 1, got %v", p.Strength)
+	}
+	return nil
+}
+
+// Image generates the dudv map. Every octave repeats a whole number of times
+// across the map, so it tiles seamlessly.
+func (p DudvParams) Image() *image.NRGBA {
+	du, dv := NewNoise2D(p.Seed), NewNoise2D(p.Seed+1)
+	img := image.NewNRGBA(image.Rect(0, 0, p.Size, p.Size))
+	for y := 0; y < p.Size; y++ {
+		for x := 0; x < p.Size; x++ {
+			u, v := float64(x)/float64(p.Size), float64(y)/float64(p.Size)
+			i := img.PixOffset(x, y)
+			img.Pix[i] = p.channel(du, u, v)
+			img.Pix[i+1] = p.channel(dv, u, v)
+			img.Pix[i+2] = 0
+			img.Pix[i+3] = 255
+		}
+	}
+	return img
+}
+
+// channel returns the fractal noise at (u, v), both in [0, 1] across the map, as a byte around 128
+func (p DudvParams) channel(noise *Noise2D, u, v float64) uint8 {
+	var sum, total float64
+	amplitude, period := 1.0, p.Scale
+	for octave := 0; octave < p.Octaves; octave++ {
+		sum += amplitude * noise.Tiled(u*float64(period), v*float64(period), period)
+		total += amplitude
+		amplitude *= float64(p.Persistence)
+		period *= 2
+	}
+	// Gradient noise peaks at about ±√½, so scale it to reach the whole range
+	value := 0.5 + 0.5*math.Sqrt2*sum/total*float64(p.Strength)
+	return uint8(math.Round(math.Max(0, math.Min(1, value)) * 255))
+}
+
+// GenerateDudvMap returns a PNG-encoded dudv map generated from params
+func GenerateDudvMap(params DudvParams) ([]byte, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, params.Image()); err != nil {
+		return nil, fmt.Errorf("failed to encode dudv map: %w", err)
+	}
+	return buf.Bytes(), nil
+}
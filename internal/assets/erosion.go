@@ -0,0 +1,145 @@
+package assets
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MaxErosionDroplets bounds the erosion work clients may request
+const MaxErosionDroplets = 1_000_000
+
+// ErosionParams configures droplet-based hydraulic erosion. Each droplet is
+// dropped at a random grid position and runs downhill, picking up sediment
+// where it speeds up and depositing it where it slows down or climbs, which
+// carves valleys and builds up flat coastlines and fans.
+type ErosionParams struct {
+	Droplets    int     `json:"droplets"`    // Number of droplets simulated; 0 disables erosion
+	Rain        float32 `json:"rain"`        // Initial water volume of each droplet
+	Inertia     float32 `json:"inertia"`     // 0 follows the slope exactly, 1 keeps the previous direction
+	Capacity    float32 `json:"capacity"`    // Sediment carried per unit of speed, water and slope
+	ErodeRate   float32 `json:"erodeRate"`   // Fraction of free capacity taken from the terrain per step
+	DepositRate float32 `json:"depositRate"` // Fraction of excess sediment dropped per step
+	Evaporation float32 `json:"evaporation"` // Fraction of water lost per step
+	Gravity     float32 `json:"gravity"`     // Acceleration of droplets going downhill
+	MaxSteps    int     `json:"maxSteps"`    // Lifetime of a droplet in grid steps
+}
+
+// DefaultErosionParams returns erosion settings that suit the default terrain, with erosion disabled
+func DefaultErosionParams() ErosionParams {
+	return ErosionParams{
+		Droplets:    0,
+		Rain:        1,
+		Inertia:     0.05,
+		Capacity:    4,
+		ErodeRate:   0.3,
+		DepositRate: 0.3,
+		Evaporation: 0.01,
+		Gravity:     4,
+		MaxSteps:    30,
+	}
+}
+
+// Validate reports an error if the parameters cannot be simulated
+func (p ErosionParams) Validate() error {
+	for name, value := range map[string]float32{
+		"rain": p.Rain, "inertia": p.Inertia, "capacity": p.Capacity, "erodeRate": p.ErodeRate,
+		"depositRate": p.DepositRate, "evaporation": p.Evaporation, "gravity": p.Gravity,
+	} {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) || value < 0 {
+			return fmt.Errorf("erosion %s must be finite and not negative, got %v", name, value)
+		}
+	}
+	switch {
+	case p.Droplets < 0 || p.Droplets > MaxErosionDroplets:
+		return fmt.Errorf("erosion droplets must be between 0 and %d, got %d", MaxErosionDroplets, p.Droplets)
+	case p.MaxSteps < 1 || p.MaxSteps > 1000:
+		return fmt.Errorf("erosion maxSteps must be between 1 and 1000, got %d", p.MaxSteps)
+	case p.Inertia > 1 || p.ErodeRate > 1 || p.DepositRate > 1 || p.Evaporation > 1:
+		return fmt.Errorf("erosion inertia and rates must be at most 1")
+	}
+	return nil
+}
+
+// Erode runs hydraulic erosion on a columns×rows grid of heights in place.
+// Droplet positions are drawn from seed, so the result is reproducible.
+func (p ErosionParams) Erode(heights []float32, columns, rows int, seed int64) {
+	if columns < 2 || rows < 2 {
+		return
+	}
+	random := rand.New(rand.NewSource(seed))
+	const minCapacity = 0.01
+
+	// sample returns the bilinear height and gradient at (x, z)
+	sample := func(x, z float32) (height, gradX, gradZ float32) {
+		cx, cz := int(x), int(z)
+		fx, fz := x-float32(cx), z-float32(cz)
+		i := cz*columns + cx
+		h00, h10 := heights[i], heights[i+1]
+		h01, h11 := heights[i+columns], heights[i+columns+1]
+
+		gradX = (h10-h00)*(1-fz) + (h11-h01)*fz
+		gradZ = (h01-h00)*(1-fx) + (h11-h10)*fx
+		height = h00*(1-fx)*(1-fz) + h10*fx*(1-fz) + h01*(1-fx)*fz + h11*fx*fz
+		return
+	}
+
+	// spread adds amount to the four grid points around (x, z), weighted bilinearly
+	spread := func(x, z, amount float32) {
+		cx, cz := int(x), int(z)
+		fx, fz := x-float32(cx), z-float32(cz)
+		i := cz*columns + cx
+		heights[i] += amount * (1 - fx) * (1 - fz)
+		heights[i+1] += amount * fx * (1 - fz)
+		heights[i+columns] += amount * (1 - fx) * fz
+		heights[i+columns+1] += amount * fx * fz
+	}
+
+	for droplet := 0; droplet < p.Droplets; droplet++ {
+		x := random.Float32() * float32(columns-1)
+		z := random.Float32() * float32(rows-1)
+		var dirX, dirZ, sediment float32
+		speed, water := float32(1), p.Rain
+
+		for step := 0; step < p.MaxSteps; step++ {
+			height, gradX, gradZ := sample(x, z)
+
+			// Blend the previous direction with the downhill direction
+			dirX = dirX*p.Inertia - gradX*(1-p.Inertia)
+			dirZ = dirZ*p.Inertia - gradZ*(1-p.Inertia)
+			length := float32(math.Sqrt(float64(dirX*dirX + dirZ*dirZ)))
+			if length == 0 {
+				break
+			}
+			dirX, dirZ = dirX/length, dirZ/length
+
+			newX, newZ := x+dirX, z+dirZ
+			if newX < 0 || newZ < 0 || newX >= float32(columns-1) || newZ >= float32(rows-1) {
+				break
+			}
+
+			newHeight, _, _ := sample(newX, newZ)
+			deltaHeight := newHeight - height
+			capacity := max(-deltaHeight*speed*water*p.Capacity, minCapacity)
+
+			if sediment > capacity || deltaHeight > 0 {
+				// Fill the pit it climbs out of, or drop what it cannot carry
+				amount := (sediment - capacity) * p.DepositRate
+				if deltaHeight > 0 {
+					amount = min(deltaHeight, sediment)
+				}
+				sediment -= amount
+				spread(x, z, amount)
+			} else {
+				// Never dig deeper than the step down, or the droplet would carve spikes
+				amount := min((capacity-sediment)*p.ErodeRate, -deltaHeight)
+				sediment += amount
+				spread(x, z, -amount)
+			}
+
+			speed = float32(math.Sqrt(float64(max(speed*speed-deltaHeight*p.Gravity, 0))))
+			water *= 1 - p.Evaporation
+			x, z = newX, newZ
+		}
+	}
+}
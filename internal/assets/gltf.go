@@ -0,0 +1,755 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// glTF 2.0 files (.gltf with external or data URI buffers, or binary .glb) are
+// imported into the same Scene, Mesh and Texture types as .wgscene files.
+// Only what the renderer can use is read: triangle primitives with positions,
+// normals, the first UV set and indices, node TRS/matrix transforms and
+// metallic-roughness material factors with a base color texture. Missing
+// normals are computed from the triangles.
+
+// glTF component types used by accessors
+const (
+	gltfUnsignedByte  = 5121
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+// gltfModeTriangles is the primitive mode for triangle lists (the default)
+const gltfModeTriangles = 4
+
+// glbMagic identifies a binary glTF container
+var glbMagic = [4]byte{'g', 'l', 'T', 'F'}
+
+// glTF chunk types inside a .glb container
+const (
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// gltfDocument mirrors the parts of the glTF JSON schema used by the importer
+type gltfDocument struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes []struct {
+		Name        string     `json:"name"`
+		Children    []int      `json:"children"`
+		Mesh        *int       `json:"mesh"`
+		Translation []float32  `json:"translation"`
+		Rotation    []float32  `json:"rotation"`
+		Scale       []float32  `json:"scale"`
+		Matrix      []float32  `json:"matrix"`
+		Extras      gltfExtras `json:"extras"`
+	} `json:"nodes"`
+	Meshes []struct {
+		Name       string `json:"name"`
+		Primitives []struct {
+			Attributes map[string]int `json:"attributes"`
+			Indices    *int           `json:"indices"`
+			Material   *int           `json:"material"`
+			Mode       *int           `json:"mode"`
+		} `json:"primitives"`
+	} `json:"meshes"`
+	Materials []struct {
+		Name                 string `json:"name"`
+		PbrMetallicRoughness *struct {
+			BaseColorFactor  []float32 `json:"baseColorFactor"`
+			BaseColorTexture *struct {
+				Index int `json:"index"`
+			} `json:"baseColorTexture"`
+			MetallicFactor  *float32 `json:"metallicFactor"`
+			RoughnessFactor *float32 `json:"roughnessFactor"`
+		} `json:"pbrMetallicRoughness"`
+	} `json:"materials"`
+	Textures []struct {
+		Source *int `json:"source"`
+	} `json:"textures"`
+	Images []struct {
+		Name     string `json:"name"`
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType"`
+	} `json:"images"`
+	Accessors []struct {
+		BufferView    *int            `json:"bufferView"`
+		ByteOffset    int             `json:"byteOffset"`
+		ComponentType int             `json:"componentType"`
+		Count         int             `json:"count"`
+		Type          string          `json:"type"`
+		Sparse        json.RawMessage `json:"sparse"`
+	} `json:"accessors"`
+	BufferViews []struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		ByteStride int `json:"byteStride"`
+	} `json:"bufferViews"`
+	Buffers []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+}
+
+// gltfExtras holds custom node properties, which exporters write to extras
+type gltfExtras map[string]interface{}
+
+// UnmarshalJSON accepts any extras value but only keeps JSON objects
+func (e *gltfExtras) UnmarshalJSON(data []byte) error {
+	var values map[string]interface{}
+	if json.Unmarshal(data, &values) == nil {
+		*e = values
+	}
+	return nil
+}
+
+// GLTFTexture is an image referenced by a glTF material
+type GLTFTexture struct {
+	Name   string
+	URI    string // Path relative to the glTF file, empty for embedded images
+	Format string
+}
+
+// gltfImporter decodes one glTF document
+type gltfImporter struct {
+	name    string
+	doc     gltfDocument
+	buffers [][]byte
+}
+
+// ReadGLTF decodes a .gltf or .glb file. External buffers are resolved relative to dir.
+// Meshes and textures referenced by the scene are returned alongside it.
+func ReadGLTF(name string, data []byte, dir string) (*Scene, []*Mesh, []GLTFTexture, error) {
+	imp := &gltfImporter{name: name}
+
+	jsonData, binChunk, err := splitGLB(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := json.Unmarshal(jsonData, &imp.doc); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid glTF JSON: %w", err)
+	}
+	if !strings.HasPrefix(imp.doc.Asset.Version, "2.") {
+		return nil, nil, nil, fmt.Errorf("unsupported glTF version '%s'", imp.doc.Asset.Version)
+	}
+
+	for i, buffer := range imp.doc.Buffers {
+		var bufferData []byte
+		switch {
+		case buffer.URI == "":
+			if i != 0 || binChunk == nil {
+				return nil, nil, nil, fmt.Errorf("buffer %d has no data", i)
+			}
+			bufferData = binChunk
+		case strings.HasPrefix(buffer.URI, "data:"):
+			comma := strings.IndexByte(buffer.URI, ',')
+			if comma < 0 || !strings.HasSuffix(buffer.URI[:comma], ";base64") {
+				return nil, nil, nil, fmt.Errorf("buffer %d has an unsupported data URI", i)
+			}
+			bufferData, err = base64.StdEncoding.DecodeString(buffer.URI[comma+1:])
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("buffer %d: %w", i, err)
+			}
+		default:
+			bufferData, err = os.ReadFile(filepath.Join(dir, filepath.FromSlash(buffer.URI)))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("buffer %d: %w", i, err)
+			}
+		}
+		if len(bufferData) < buffer.ByteLength {
+			return nil, nil, nil, fmt.Errorf("buffer %d is shorter than its declared length", i)
+		}
+		imp.buffers = append(imp.buffers, bufferData)
+	}
+
+	scene := &Scene{Name: name}
+	textures := imp.textures()
+	scene.Materials = imp.materials(textures)
+
+	// Every glTF primitive becomes its own Mesh
+	primitiveMeshes := make([][]*Mesh, len(imp.doc.Meshes))
+	var meshes []*Mesh
+	for i := range imp.doc.Meshes {
+		primitiveMeshes[i], err = imp.mesh(i)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, mesh := range primitiveMeshes[i] {
+			meshes = append(meshes, mesh)
+			scene.Meshes = append(scene.Meshes, mesh.Name)
+		}
+	}
+
+	if err := imp.nodes(scene, primitiveMeshes); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return scene, meshes, textures, nil
+}
+
+// splitGLB returns the JSON and binary chunks of a .glb container, or data itself for plain .gltf
+func splitGLB(data []byte) ([]byte, []byte, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], glbMagic[:]) {
+		return data, nil, nil
+	}
+	if version := binary.LittleEndian.Uint32(data[4:8]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported GLB version %d", version)
+	}
+
+	var jsonChunk, binChunk []byte
+	for pos := 12; pos+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[pos : pos+4]))
+		chunkType := binary.LittleEndian.Uint32(data[pos+4 : pos+8])
+		pos += 8
+		if length < 0 || pos+length > len(data) {
+			return nil, nil, fmt.Errorf("truncated GLB chunk")
+		}
+		switch chunkType {
+		case glbChunkJSON:
+			jsonChunk = data[pos : pos+length]
+		case glbChunkBIN:
+			binChunk = data[pos : pos+length]
+		}
+		pos += length
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("GLB file has no JSON chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+// maxZeroAccessorElements bounds accessors without a buffer view, which have
+// no data to check their count against
+const maxZeroAccessorElements = 1 << 24
+
+// accessorLayout locates the elements of an accessor in its buffer view
+type accessorLayout struct {
+	count         int
+	components    int
+	componentType int
+	componentSize int
+	stride        int
+	data          []byte // From the first element; nil when every element is zero
+}
+
+// component returns the bytes of component c of element i
+func (l accessorLayout) component(i, c int) []byte {
+	return l.data[i*l.stride+c*l.componentSize:]
+}
+
+// layout checks that an accessor of the expected type lies within its buffer
+// view, before anything is allocated for its elements
+func (imp *gltfImporter) layout(index int, wantType string) (accessorLayout, error) {
+	if index < 0 || index >= len(imp.doc.Accessors) {
+		return accessorLayout{}, fmt.Errorf("accessor %d does not exist", index)
+	}
+	acc := imp.doc.Accessors[index]
+	if acc.Sparse != nil {
+		return accessorLayout{}, fmt.Errorf("accessor %d: sparse accessors are not supported", index)
+	}
+	if acc.Type != wantType {
+		return accessorLayout{}, fmt.Errorf("accessor %d: expected %s, got %s", index, wantType, acc.Type)
+	}
+	if acc.Count < 0 || acc.ByteOffset < 0 {
+		return accessorLayout{}, fmt.Errorf("accessor %d: count and byte offset must not be negative", index)
+	}
+
+	layout := accessorLayout{
+		count:         acc.Count,
+		components:    map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4}[acc.Type],
+		componentType: acc.ComponentType,
+		componentSize: map[int]int{gltfUnsignedByte: 1, gltfUnsignedShort: 2, gltfUnsignedInt: 4, gltfFloat: 4}[acc.ComponentType],
+	}
+	if layout.componentSize == 0 {
+		return accessorLayout{}, fmt.Errorf("accessor %d: unsupported component type %d", index, acc.ComponentType)
+	}
+	if acc.BufferView == nil {
+		// Accessors without a buffer view are all zeros
+		if acc.Count > maxZeroAccessorElements {
+			return accessorLayout{}, fmt.Errorf("accessor %d: too many elements", index)
+		}
+		return layout, nil
+	}
+	if *acc.BufferView < 0 || *acc.BufferView >= len(imp.doc.BufferViews) {
+		return accessorLayout{}, fmt.Errorf("accessor %d: buffer view %d does not exist", index, *acc.BufferView)
+	}
+	view := imp.doc.BufferViews[*acc.BufferView]
+	if view.Buffer < 0 || view.Buffer >= len(imp.buffers) {
+		return accessorLayout{}, fmt.Errorf("accessor %d: buffer %d does not exist", index, view.Buffer)
+	}
+
+	buffer := imp.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset > len(buffer) || view.ByteLength > len(buffer)-view.ByteOffset {
+		return accessorLayout{}, fmt.Errorf("accessor %d: buffer view out of range", index)
+	}
+	if view.ByteStride < 0 {
+		return accessorLayout{}, fmt.Errorf("accessor %d: byte stride must not be negative", index)
+	}
+	data := buffer[view.ByteOffset : view.ByteOffset+view.ByteLength]
+
+	elementSize := layout.components * layout.componentSize
+	layout.stride = view.ByteStride
+	if layout.stride == 0 {
+		layout.stride = elementSize
+	}
+	if layout.stride < elementSize {
+		return accessorLayout{}, fmt.Errorf("accessor %d: byte stride %d is smaller than its %d byte elements", index, view.ByteStride, elementSize)
+	}
+	if acc.ByteOffset > len(data) {
+		return accessorLayout{}, fmt.Errorf("accessor %d: data out of range", index)
+	}
+	layout.data = data[acc.ByteOffset:]
+	// Compared by division so huge counts cannot overflow
+	if acc.Count > 0 && (len(layout.data) < elementSize || acc.Count-1 > (len(layout.data)-elementSize)/layout.stride) {
+		return accessorLayout{}, fmt.Errorf("accessor %d: data out of range", index)
+	}
+	return layout, nil
+}
+
+// accessor returns the elements of an accessor of the expected type as float32 values
+func (imp *gltfImporter) accessor(index int, wantType string) ([]float32, error) {
+	layout, err := imp.layout(index, wantType)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float32, layout.count*layout.components)
+	if layout.data == nil {
+		return values, nil
+	}
+	for i := 0; i < layout.count; i++ {
+		for c := 0; c < layout.components; c++ {
+			b := layout.component(i, c)
+			switch layout.componentType {
+			case gltfUnsignedByte:
+				values[i*layout.components+c] = float32(b[0])
+			case gltfUnsignedShort:
+				values[i*layout.components+c] = float32(binary.LittleEndian.Uint16(b))
+			case gltfUnsignedInt:
+				values[i*layout.components+c] = float32(binary.LittleEndian.Uint32(b))
+			case gltfFloat:
+				values[i*layout.components+c] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// indexAccessor returns the elements of a SCALAR index accessor. They are read
+// straight into uint32, as float32 cannot hold indices above 2^24 exactly.
+func (imp *gltfImporter) indexAccessor(index int) ([]uint32, error) {
+	layout, err := imp.layout(index, "SCALAR")
+	if err != nil {
+		return nil, err
+	}
+	if layout.componentType == gltfFloat {
+		return nil, fmt.Errorf("accessor %d: indices must be unsigned integers", index)
+	}
+
+	indices := make([]uint32, layout.count)
+	if layout.data == nil {
+		return indices, nil
+	}
+	for i := range indices {
+		b := layout.component(i, 0)
+		switch layout.componentType {
+		case gltfUnsignedByte:
+			indices[i] = uint32(b[0])
+		case gltfUnsignedShort:
+			indices[i] = uint32(binary.LittleEndian.Uint16(b))
+		case gltfUnsignedInt:
+			indices[i] = binary.LittleEndian.Uint32(b)
+		}
+	}
+
+	return indices, nil
+}
+
+// mesh converts the primitives of a glTF mesh
+func (imp *gltfImporter) mesh(index int) ([]*Mesh, error) {
+	source := imp.doc.Meshes[index]
+	baseName := source.Name
+	if baseName == "" {
+		baseName = fmt.Sprintf("%s_mesh%d", imp.name, index)
+	}
+
+	var meshes []*Mesh
+	for p, primitive := range source.Primitives {
+		name := baseName
+		if len(source.Primitives) > 1 {
+			name = fmt.Sprintf("%s_%d", baseName, p)
+		}
+		if primitive.Mode != nil && *primitive.Mode != gltfModeTriangles {
+			return nil, fmt.Errorf("mesh '%s': only triangle primitives are supported", name)
+		}
+
+		positionIndex, ok := primitive.Attributes["POSITION"]
+		if !ok {
+			return nil, fmt.Errorf("mesh '%s' has no positions", name)
+		}
+		vertices, err := imp.accessor(positionIndex, "VEC3")
+		if err != nil {
+			return nil, fmt.Errorf("mesh '%s': %w", name, err)
+		}
+		vertexCount := len(vertices) / 3
+
+		var indices []uint32
+		if primitive.Indices != nil {
+			if indices, err = imp.indexAccessor(*primitive.Indices); err != nil {
+				return nil, fmt.Errorf("mesh '%s': %w", name, err)
+			}
+			for _, i := range indices {
+				if int64(i) >= int64(vertexCount) {
+					return nil, fmt.Errorf("mesh '%s' has an out of range index", name)
+				}
+			}
+		} else {
+			indices = make([]uint32, vertexCount)
+			for i := range indices {
+				indices[i] = uint32(i)
+			}
+		}
+
+		var normals []float32
+		if normalIndex, ok := primitive.Attributes["NORMAL"]; ok {
+			if normals, err = imp.accessor(normalIndex, "VEC3"); err != nil {
+				return nil, fmt.Errorf("mesh '%s': %w", name, err)
+			}
+		} else {
+			normals = computeVertexNormals(vertices, indices)
+		}
+
+		texCoords := make([]float32, vertexCount*2)
+		if uvIndex, ok := primitive.Attributes["TEXCOORD_0"]; ok {
+			if texCoords, err = imp.accessor(uvIndex, "VEC2"); err != nil {
+				return nil, fmt.Errorf("mesh '%s': %w", name, err)
+			}
+		}
+
+		// Authored tangents are kept; the rest are generated when the mesh is stored
+		var tangents []float32
+		if tangentIndex, ok := primitive.Attributes["TANGENT"]; ok {
+			if tangents, err = imp.accessor(tangentIndex, "VEC4"); err != nil {
+				return nil, fmt.Errorf("mesh '%s': %w", name, err)
+			}
+		}
+
+		if len(normals) != len(vertices) || len(texCoords)/2 != vertexCount ||
+			(tangents != nil && len(tangents)/4 != vertexCount) {
+			return nil, fmt.Errorf("mesh '%s' has attributes of different lengths", name)
+		}
+
+		meshes = append(meshes, &Mesh{
+			Name:          name,
+			Vertices:      vertices,
+			Normals:       normals,
+			TexCoords:     texCoords,
+			Tangents:      tangents,
+			Indices:       indices,
+			IndexWidth:    IndexWidthFor(vertexCount),
+			VertexCount:   vertexCount,
+			TriangleCount: len(indices) / 3,
+		})
+	}
+
+	return meshes, nil
+}
+
+// computeVertexNormals returns area-weighted smooth normals for an indexed triangle list
+func computeVertexNormals(vertices []float32, indices []uint32) []float32 {
+	accumulated := make([]math3d.Vec3, len(vertices)/3)
+	vertex := func(index uint32) math3d.Vec3 {
+		i := int(index)
+		return math3d.NewVec3(vertices[i*3], vertices[i*3+1], vertices[i*3+2])
+	}
+
+	for t := 0; t+2 < len(indices); t += 3 {
+		a, b, c := indices[t], indices[t+1], indices[t+2]
+		// The unnormalized cross product weights each face by its area
+		normal := vertex(b).Sub(vertex(a)).Cross(vertex(c).Sub(vertex(a)))
+		accumulated[a] = accumulated[a].Add(normal)
+		accumulated[b] = accumulated[b].Add(normal)
+		accumulated[c] = accumulated[c].Add(normal)
+	}
+
+	normals := make([]float32, len(vertices))
+	for i, n := range accumulated {
+		n = n.Normalize()
+		normals[i*3], normals[i*3+1], normals[i*3+2] = n.X, n.Y, n.Z
+	}
+	return normals
+}
+
+// textures returns one entry per glTF texture, named after its image
+func (imp *gltfImporter) textures() []GLTFTexture {
+	textures := make([]GLTFTexture, len(imp.doc.Textures))
+	for i, texture := range imp.doc.Textures {
+		textures[i].Name = fmt.Sprintf("%s_texture%d", imp.name, i)
+		if texture.Source == nil || *texture.Source < 0 || *texture.Source >= len(imp.doc.Images) {
+			continue
+		}
+
+		image := imp.doc.Images[*texture.Source]
+		if !strings.HasPrefix(image.URI, "data:") {
+			textures[i].URI = image.URI
+		}
+		switch {
+		case image.Name != "":
+			textures[i].Name = image.Name
+		case textures[i].URI != "":
+			base := filepath.Base(filepath.FromSlash(image.URI))
+			textures[i].Name = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+
+		switch {
+		case image.MimeType == "image/jpeg" || strings.HasSuffix(strings.ToLower(image.URI), ".jpg") ||
+			strings.HasSuffix(strings.ToLower(image.URI), ".jpeg"):
+			textures[i].Format = "rgb"
+		default:
+			textures[i].Format = "rgba"
+		}
+	}
+	return textures
+}
+
+// materials converts glTF metallic-roughness materials to scene materials
+func (imp *gltfImporter) materials(textures []GLTFTexture) []SceneMaterial {
+	materials := make([]SceneMaterial, len(imp.doc.Materials))
+	for i, source := range imp.doc.Materials {
+		material := SceneMaterial{
+			Name:      source.Name,
+			BaseColor: math3d.NewColor(1, 1, 1, 1),
+			Roughness: 1,
+			Metallic:  1,
+			Alpha:     1,
+		}
+		if material.Name == "" {
+			material.Name = fmt.Sprintf("%s_material%d", imp.name, i)
+		}
+
+		if pbr := source.PbrMetallicRoughness; pbr != nil {
+			if len(pbr.BaseColorFactor) == 4 {
+				factor := pbr.BaseColorFactor
+				material.BaseColor = math3d.NewColor(factor[0], factor[1], factor[2], factor[3])
+				material.Alpha = factor[3]
+			}
+			if pbr.BaseColorTexture != nil && pbr.BaseColorTexture.Index >= 0 && pbr.BaseColorTexture.Index < len(textures) {
+				material.Texture = textures[pbr.BaseColorTexture.Index].Name
+			}
+			if pbr.MetallicFactor != nil {
+				material.Metallic = *pbr.MetallicFactor
+			}
+			if pbr.RoughnessFactor != nil {
+				material.Roughness = *pbr.RoughnessFactor
+			}
+		}
+
+		materials[i] = material
+	}
+	return materials
+}
+
+// nodes flattens the glTF node tree into scene nodes, parents before children
+func (imp *gltfImporter) nodes(scene *Scene, primitiveMeshes [][]*Mesh) error {
+	var roots []int
+	switch {
+	case imp.doc.Scene != nil && *imp.doc.Scene >= 0 && *imp.doc.Scene < len(imp.doc.Scenes):
+		roots = imp.doc.Scenes[*imp.doc.Scene].Nodes
+	case len(imp.doc.Scenes) > 0:
+		roots = imp.doc.Scenes[0].Nodes
+	default:
+		// Without scenes, every node that is nobody's child is a root
+		isChild := make([]bool, len(imp.doc.Nodes))
+		for _, node := range imp.doc.Nodes {
+			for _, child := range node.Children {
+				if child >= 0 && child < len(isChild) {
+					isChild[child] = true
+				}
+			}
+		}
+		for i := range imp.doc.Nodes {
+			if !isChild[i] {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	visited := make([]bool, len(imp.doc.Nodes))
+	var visit func(index, parent int) error
+	visit = func(index, parent int) error {
+		if index < 0 || index >= len(imp.doc.Nodes) {
+			return fmt.Errorf("node %d does not exist", index)
+		}
+		if visited[index] {
+			return fmt.Errorf("node %d appears more than once in the hierarchy", index)
+		}
+		visited[index] = true
+
+		source := imp.doc.Nodes[index]
+		node := SceneNode{
+			Name:      source.Name,
+			Parent:    parent,
+			Transform: gltfTransform(source.Translation, source.Rotation, source.Scale, source.Matrix),
+		}
+		if node.Name == "" {
+			node.Name = fmt.Sprintf("%s_node%d", imp.name, index)
+		}
+		if len(source.Extras) > 0 {
+			node.Properties = make(map[string]string, len(source.Extras))
+			for key, value := range source.Extras {
+				node.Properties[key] = fmt.Sprint(value)
+			}
+		}
+
+		// The first primitive sits on the node itself, the rest on child nodes
+		var extra []*Mesh
+		if source.Mesh != nil {
+			if *source.Mesh < 0 || *source.Mesh >= len(primitiveMeshes) {
+				return fmt.Errorf("node '%s' references a mesh that does not exist", node.Name)
+			}
+			if meshes := primitiveMeshes[*source.Mesh]; len(meshes) > 0 {
+				node.Mesh = meshes[0].Name
+				node.Material = imp.primitiveMaterial(scene, *source.Mesh, 0)
+				extra = meshes[1:]
+			}
+		}
+
+		nodeIndex := len(scene.Nodes)
+		scene.Nodes = append(scene.Nodes, node)
+		for p, mesh := range extra {
+			scene.Nodes = append(scene.Nodes, SceneNode{
+				Name:      mesh.Name,
+				Parent:    nodeIndex,
+				Mesh:      mesh.Name,
+				Material:  imp.primitiveMaterial(scene, *source.Mesh, p+1),
+				Transform: *math3d.NewTransform(),
+			})
+		}
+
+		for _, child := range source.Children {
+			if err := visit(child, nodeIndex); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := visit(root, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// primitiveMaterial returns the scene material name used by a primitive, if any
+func (imp *gltfImporter) primitiveMaterial(scene *Scene, mesh, primitive int) string {
+	material := imp.doc.Meshes[mesh].Primitives[primitive].Material
+	if material == nil || *material < 0 || *material >= len(scene.Materials) {
+		return ""
+	}
+	return scene.Materials[*material].Name
+}
+
+// gltfTransform builds a transform from glTF TRS properties or a column-major matrix
+func gltfTransform(translation, rotation, scale, matrix []float32) math3d.Transform {
+	transform := *math3d.NewTransform()
+
+	if len(matrix) == 16 {
+		var m math3d.Mat4
+		copy(m[:], matrix)
+
+		transform.Position = m.GetTranslation()
+		transform.Scale = math3d.NewVec3(
+			math3d.NewVec3(m[0], m[1], m[2]).Length(),
+			math3d.NewVec3(m[4], m[5], m[6]).Length(),
+			math3d.NewVec3(m[8], m[9], m[10]).Length(),
+		)
+		if transform.Scale.X != 0 && transform.Scale.Y != 0 && transform.Scale.Z != 0 {
+			for i := 0; i < 3; i++ {
+				m[i] /= transform.Scale.X
+				m[4+i] /= transform.Scale.Y
+				m[8+i] /= transform.Scale.Z
+			}
+			transform.Rotation = math3d.QuatFromMat4(m).Normalize()
+		}
+		return transform
+	}
+
+	if len(translation) == 3 {
+		transform.Position = math3d.NewVec3(translation[0], translation[1], translation[2])
+	}
+	if len(rotation) == 4 {
+		transform.Rotation = math3d.NewQuat(rotation[0], rotation[1], rotation[2], rotation[3])
+	}
+	if len(scale) == 3 {
+		transform.Scale = math3d.NewVec3(scale[0], scale[1], scale[2])
+	}
+	return transform
+}
+
+// LoadGLTF imports a .gltf or .glb file, registering its meshes, its external
+// textures and the scene itself under name
+func (a *Assets) LoadGLTF(name, path string) (*Scene, error) {
+	scene, meshes, textures, err := readGLTFFile(name, path)
+	if err != nil {
+		return nil, err
+	}
+	a.registerGLTF(name, path, scene, meshes, textures)
+	return scene, nil
+}
+
+// readGLTFFile parses a .gltf or .glb file without registering anything
+func readGLTFFile(name, path string) (*Scene, []*Mesh, []GLTFTexture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	scene, meshes, textures, err := ReadGLTF(name, data, filepath.Dir(path))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load glTF '%s': %w", name, err)
+	}
+	return scene, meshes, textures, nil
+}
+
+// registerGLTF registers a parsed glTF scene with its meshes and textures. Texture
+// URIs are resolved against path, the file the scene was read from.
+func (a *Assets) registerGLTF(name, path string, scene *Scene, meshes []*Mesh, textures []GLTFTexture) {
+	for _, mesh := range meshes {
+		a.storeMesh(mesh.Name, mesh)
+	}
+	for _, texture := range textures {
+		if texture.URI == "" {
+			continue
+		}
+		// Texture paths are relative to the assets directory
+		texturePath := filepath.Join(filepath.Dir(path), filepath.FromSlash(texture.URI))
+		if rel, err := filepath.Rel(a.basePath, texturePath); err == nil {
+			texturePath = rel
+		}
+		if err := a.registerTextureFile(texture.Name, texturePath); err != nil {
+			a.registerTexture(texture.Name, texturePath, 0, 0, texture.Format)
+		}
+	}
+	a.storeScene(name, scene)
+}
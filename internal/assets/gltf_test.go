@@ -0,0 +1,140 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+// glbTriangle returns the glTF JSON and binary buffer of a single indexed
+// triangle: 36 bytes of float positions followed by 6 bytes of uint16 indices
+func glbTriangle() (map[string]interface{}, []byte) {
+	var bin bytes.Buffer
+	binary.Write(&bin, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 0, 1})
+	binary.Write(&bin, binary.LittleEndian, []uint16{0, 1, 2, 0}) // Padded to 4 bytes
+
+	doc := map[string]interface{}{
+		"asset":  map[string]interface{}{"version": "2.0"},
+		"scene":  0,
+		"scenes": []interface{}{map[string]interface{}{"nodes": []int{0}}},
+		"nodes":  []interface{}{map[string]interface{}{"name": "water", "mesh": 0}},
+		"meshes": []interface{}{map[string]interface{}{
+			"name": "triangle",
+			"primitives": []interface{}{map[string]interface{}{
+				"attributes": map[string]int{"POSITION": 0},
+				"indices":    1,
+			}},
+		}},
+		"accessors": []interface{}{
+			map[string]interface{}{"bufferView": 0, "componentType": gltfFloat, "count": 3, "type": "VEC3"},
+			map[string]interface{}{"bufferView": 1, "componentType": gltfUnsignedShort, "count": 3, "type": "SCALAR"},
+		},
+		"bufferViews": []interface{}{
+			map[string]interface{}{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+			map[string]interface{}{"buffer": 0, "byteOffset": 36, "byteLength": 6},
+		},
+		"buffers": []interface{}{map[string]interface{}{"byteLength": bin.Len()}},
+	}
+	return doc, bin.Bytes()
+}
+
+// glb packs a glTF document and its binary buffer into a .glb container
+func glb(doc map[string]interface{}, bin []byte) []byte {
+	jsonData, _ := json.Marshal(doc)
+	for len(jsonData)%4 != 0 {
+		jsonData = append(jsonData, ' ')
+	}
+
+	var file bytes.Buffer
+	file.Write(glbMagic[:])
+	binary.Write(&file, binary.LittleEndian, []uint32{2, uint32(12 + 8 + len(jsonData) + 8 + len(bin))})
+	binary.Write(&file, binary.LittleEndian, []uint32{uint32(len(jsonData)), glbChunkJSON})
+	file.Write(jsonData)
+	binary.Write(&file, binary.LittleEndian, []uint32{uint32(len(bin)), glbChunkBIN})
+	file.Write(bin)
+	return file.Bytes()
+}
+
+// gltfField returns element index of the named top-level array of doc
+func gltfField(doc map[string]interface{}, array string, index int) map[string]interface{} {
+	return doc[array].([]interface{})[index].(map[string]interface{})
+}
+
+func TestReadGLTF(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(doc map[string]interface{})
+		wantErr bool
+	}{
+		{"valid", func(map[string]interface{}) {}, false},
+		{"count past the view", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 0)["count"] = 4
+		}, true},
+		{"huge count", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 0)["count"] = 1 << 40
+		}, true},
+		{"negative count", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 0)["count"] = -1
+		}, true},
+		{"negative byte offset", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 0)["byteOffset"] = -4
+		}, true},
+		{"byte offset past the view", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 0)["byteOffset"] = 40
+		}, true},
+		{"stride smaller than the element", func(doc map[string]interface{}) {
+			gltfField(doc, "bufferViews", 0)["byteStride"] = 4
+		}, true},
+		{"view past the buffer", func(doc map[string]interface{}) {
+			gltfField(doc, "bufferViews", 1)["byteLength"] = 1 << 30
+		}, true},
+		{"missing buffer view", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 0)["bufferView"] = 5
+		}, true},
+		{"float indices", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 1)["componentType"] = gltfFloat
+			gltfField(doc, "accessors", 1)["count"] = 1 // Fits in the view
+		}, true},
+		{"index past the vertices", func(doc map[string]interface{}) {
+			gltfField(doc, "accessors", 0)["count"] = 2
+		}, true},
+		{"huge accessor without a view", func(doc map[string]interface{}) {
+			delete(gltfField(doc, "accessors", 0), "bufferView")
+			gltfField(doc, "accessors", 0)["count"] = maxZeroAccessorElements + 1
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, bin := glbTriangle()
+			tt.modify(doc)
+			scene, meshes, _, err := ReadGLTF("test", glb(doc, bin), t.TempDir())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(meshes) != 1 || meshes[0].VertexCount != 3 || meshes[0].TriangleCount != 1 {
+				t.Fatalf("got meshes %v, want one triangle", meshes)
+			}
+			if len(meshes[0].Normals) != 9 {
+				t.Errorf("got %d normal values, want 9 computed from the triangle", len(meshes[0].Normals))
+			}
+			if len(scene.Nodes) != 1 || scene.Nodes[0].Mesh != meshes[0].Name {
+				t.Errorf("got nodes %+v", scene.Nodes)
+			}
+		})
+	}
+}
+
+func TestReadGLTFTruncatedChunk(t *testing.T) {
+	doc, bin := glbTriangle()
+	data := glb(doc, bin)
+	if _, _, _, err := ReadGLTF("test", data[:len(data)-8], t.TempDir()); err == nil {
+		t.Error("expected an error for a truncated binary chunk")
+	}
+}
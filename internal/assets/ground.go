@@ -0,0 +1,129 @@
+package assets
+
+import (
+	"math"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// groundCells is the number of buckets along each side of a Ground's grid
+const groundCells = 64
+
+// Ground answers height queries against a mesh seen from above, such as the
+// terrain. Triangles are bucketed by their extent on the XZ plane, so a query
+// only tests the few triangles below the point.
+type Ground struct {
+	triangles [][3]math3d.Vec3
+	minX      float32
+	minZ      float32
+	cellSize  float32
+	cells     [groundCells * groundCells][]int32 // Triangle indices overlapping each cell, row by row
+}
+
+// NewGround builds height queries for mesh. Triangles seen edge-on from above
+// are left out.
+func NewGround(mesh *Mesh) *Ground {
+	g := &Ground{}
+	vertexCount := uint32(len(mesh.Vertices) / 3)
+	bounds := struct{ minX, minZ, maxX, maxZ float32 }{math.MaxFloat32, math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		if mesh.Indices[i] >= vertexCount || mesh.Indices[i+1] >= vertexCount || mesh.Indices[i+2] >= vertexCount {
+			continue
+		}
+		t := [3]math3d.Vec3{
+			terrainVertex(mesh, mesh.Indices[i]),
+			terrainVertex(mesh, mesh.Indices[i+1]),
+			terrainVertex(mesh, mesh.Indices[i+2]),
+		}
+		if t[1].Sub(t[0]).Cross(t[2].Sub(t[0])).Y == 0 {
+			continue
+		}
+		g.triangles = append(g.triangles, t)
+		for _, v := range t {
+			bounds.minX, bounds.maxX = min(bounds.minX, v.X), max(bounds.maxX, v.X)
+			bounds.minZ, bounds.maxZ = min(bounds.minZ, v.Z), max(bounds.maxZ, v.Z)
+		}
+	}
+	if len(g.triangles) == 0 {
+		return g
+	}
+
+	g.minX, g.minZ = bounds.minX, bounds.minZ
+	g.cellSize = max(bounds.maxX-bounds.minX, bounds.maxZ-bounds.minZ, 1e-6) / groundCells
+	for i, t := range g.triangles {
+		x0, z0 := g.cell(min(t[0].X, t[1].X, t[2].X), min(t[0].Z, t[1].Z, t[2].Z))
+		x1, z1 := g.cell(max(t[0].X, t[1].X, t[2].X), max(t[0].Z, t[1].Z, t[2].Z))
+		for z := z0; z <= z1; z++ {
+			for x := x0; x <= x1; x++ {
+				g.cells[z*groundCells+x] = append(g.cells[z*groundCells+x], int32(i))
+			}
+		}
+	}
+	return g
+}
+
+// cell returns the grid cell containing (x, z), clamped to the grid
+func (g *Ground) cell(x, z float32) (int, int) {
+	column := int((x - g.minX) / g.cellSize)
+	row := int((z - g.minZ) / g.cellSize)
+	return max(min(column, groundCells-1), 0), max(min(row, groundCells-1), 0)
+}
+
+// HeightAt returns the height of the highest triangle above or below (x, z),
+// or false if no triangle covers the point
+func (g *Ground) HeightAt(x, z float32) (float32, bool) {
+	if len(g.triangles) == 0 {
+		return 0, false
+	}
+	column, row := int(math.Floor(float64((x-g.minX)/g.cellSize))), int(math.Floor(float64((z-g.minZ)/g.cellSize)))
+	if column < 0 || row < 0 || column > groundCells || row > groundCells {
+		return 0, false
+	}
+	// Points on the far edge of the grid belong to the last cell
+	column, row = min(column, groundCells-1), min(row, groundCells-1)
+
+	height, found := float32(0), false
+	for _, i := range g.cells[row*groundCells+column] {
+		if y, ok := triangleHeight(g.triangles[i], x, z); ok && (!found || y > height) {
+			height, found = y, true
+		}
+	}
+	return height, found
+}
+
+// triangleHeight interpolates the height of t at (x, z) if the point lies in
+// t seen from above
+func triangleHeight(t [3]math3d.Vec3, x, z float32) (float32, bool) {
+	const epsilon = 1e-5
+	denominator := (t[1].Z-t[2].Z)*(t[0].X-t[2].X) + (t[2].X-t[1].X)*(t[0].Z-t[2].Z)
+	a := ((t[1].Z-t[2].Z)*(x-t[2].X) + (t[2].X-t[1].X)*(z-t[2].Z)) / denominator
+	b := ((t[2].Z-t[0].Z)*(x-t[2].X) + (t[0].X-t[2].X)*(z-t[2].Z)) / denominator
+	c := 1 - a - b
+	if a < -epsilon || b < -epsilon || c < -epsilon {
+		return 0, false
+	}
+	return a*t[0].Y + b*t[1].Y + c*t[2].Y, true
+}
+
+// TerrainHeightAt returns the height of the terrain mesh at (x, z), or false
+// outside the terrain or before it exists
+func (a *Assets) TerrainHeightAt(x, z float32) (float32, bool) {
+	a.mu.RLock()
+	ground := a.ground
+	a.mu.RUnlock()
+
+	if ground == nil {
+		a.mu.Lock()
+		if a.ground == nil {
+			if terrain, ok := a.meshes["terrain"]; ok {
+				a.ground = NewGround(terrain)
+			}
+		}
+		ground = a.ground
+		a.mu.Unlock()
+		if ground == nil {
+			return 0, false
+		}
+	}
+	return ground.HeightAt(x, z)
+}
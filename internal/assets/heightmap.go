@@ -0,0 +1,105 @@
+package assets
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+)
+
+// heightmapFile is the image Initialize builds the terrain from when it exists
+const heightmapFile = "heightmap.png"
+
+// CreateTerrainFromHeightmap builds a terrain mesh from a grayscale image and
+// registers it as "terrain", replacing the generated one. Each pixel becomes a
+// vertex of a size×size grid centered on the origin. Black maps to a height of
+// -heightScale/2 and white to +heightScale/2, so mid-gray lies at the water level.
+// Color images are converted to luminance; 16-bit grayscale keeps its precision.
+func (a *Assets) CreateTerrainFromHeightmap(path string, size, heightScale float32) (*Mesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read heightmap '%s': %w", path, err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() < 2 || bounds.Dy() < 2 {
+		return nil, fmt.Errorf("heightmap '%s' must be at least 2×2 pixels", path)
+	}
+
+	mesh := heightfieldMesh("terrain", bounds.Dx(), bounds.Dy(), size, func(x, z int) float32 {
+		gray := color.Gray16Model.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+z)).(color.Gray16)
+		return (float32(gray.Y)/0xffff - 0.5) * heightScale
+	})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.storeMesh("terrain", mesh)
+	return mesh, nil
+}
+
+// heightfieldMesh builds a size×size grid of columns×rows vertices centered on
+// the origin, with heights given per grid point and normals from the surface
+func heightfieldMesh(name string, columns, rows int, size float32, height func(x, z int) float32) *Mesh {
+	vertexCount := columns * rows
+	vertices := make([]float32, vertexCount*3)
+	normals := make([]float32, vertexCount*3)
+	texCoords := make([]float32, vertexCount*2)
+
+	halfSize := size * 0.5
+	for z := 0; z < rows; z++ {
+		for x := 0; x < columns; x++ {
+			index := z*columns + x
+			u := float32(x) / float32(columns-1)
+			v := float32(z) / float32(rows-1)
+			vertices[index*3] = u*size - halfSize
+			vertices[index*3+1] = height(x, z)
+			vertices[index*3+2] = v*size - halfSize
+			texCoords[index*2] = u
+			texCoords[index*2+1] = v
+		}
+	}
+
+	indices := make([]uint32, 0, (columns-1)*(rows-1)*6)
+	for z := 0; z < rows-1; z++ {
+		for x := 0; x < columns-1; x++ {
+			topLeft := uint32(z*columns + x)
+			topRight := topLeft + 1
+			bottomLeft := topLeft + uint32(columns)
+			bottomRight := bottomLeft + 1
+			// Same winding as CreateWaterMesh, so the faces point up
+			indices = append(indices, topLeft, bottomLeft, topRight, topRight, bottomLeft, bottomRight)
+		}
+	}
+
+	calculateNormals(vertices, indices, normals)
+
+	return &Mesh{
+		Name:          name,
+		Vertices:      vertices,
+		Normals:       normals,
+		TexCoords:     texCoords,
+		Indices:       indices,
+		IndexWidth:    IndexWidthFor(vertexCount),
+		VertexCount:   vertexCount,
+		TriangleCount: len(indices) / 3,
+	}
+}
+
+// CreateClipmapGrid registers "clipmap_grid", a flat grid of cells×cells unit
+// cells centered on the origin that clients scale and move for every clipmap level
+func (a *Assets) CreateClipmapGrid(cells int) *Mesh {
+	mesh := heightfieldMesh("clipmap_grid", cells+1, cells+1, float32(cells), func(x, z int) float32 {
+		return 0
+	})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.storeMesh(mesh.Name, mesh)
+	return mesh
+}
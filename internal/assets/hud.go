@@ -0,0 +1,239 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// HUD assets live in the hud directory of the assets directory. Each sprite
+// sheet or bitmap font is a JSON file describing one image next to it:
+//
+//	hud/controls.sprites.json
+//	{"image": "controls.png", "frames": {"knob": {"x": 0, "y": 0, "width": 16, "height": 16}}}
+//
+//	hud/ui.font.json
+//	{"image": "ui.png", "size": 16, "lineHeight": 20, "base": 15,
+//	 "glyphs": {"A": {"x": 0, "y": 0, "width": 9, "height": 12, "xOffset": 0, "yOffset": 3, "xAdvance": 10}},
+//	 "kerning": {"AV": -1}}
+
+// hudDir is the directory under the assets directory holding sprite sheets and fonts
+const hudDir = "hud"
+
+// Suffixes of the metadata files ScanHUD registers
+const (
+	spriteSheetSuffix = ".sprites.json"
+	bitmapFontSuffix  = ".font.json"
+)
+
+// Rect is a rectangle of an image in pixels, from its top-left corner
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// within reports an error if the rectangle is negative or reaches outside a
+// width×height image
+func (r Rect) within(width, height int) error {
+	if r.X < 0 || r.Y < 0 || r.Width < 0 || r.Height < 0 || r.X+r.Width > width || r.Y+r.Height > height {
+		return fmt.Errorf("rect %d,%d %d×%d is outside the %d×%d image", r.X, r.Y, r.Width, r.Height, width, height)
+	}
+	return nil
+}
+
+// SpriteSheet is an image packed with named frames, such as the icons and
+// slider parts of the control overlay
+type SpriteSheet struct {
+	Name   string          `json:"name"`
+	Image  string          `json:"image"`  // Image path relative to the assets directory
+	Width  int             `json:"width"`  // Image width in pixels
+	Height int             `json:"height"` // Image height in pixels
+	Frames map[string]Rect `json:"frames"` // Area of each frame in the image, by frame name
+}
+
+// Glyph is the area of one character in a bitmap font's image and how to place it
+type Glyph struct {
+	Rect
+	XOffset  int `json:"xOffset"`  // From the pen position to the left of the glyph
+	YOffset  int `json:"yOffset"`  // From the top of the line to the top of the glyph
+	XAdvance int `json:"xAdvance"` // How far the pen moves after the glyph
+}
+
+// BitmapFont is an image of pre-rendered glyphs for drawing text in WebGL
+type BitmapFont struct {
+	Name       string           `json:"name"`
+	Image      string           `json:"image"`             // Image path relative to the assets directory
+	Width      int              `json:"width"`             // Image width in pixels
+	Height     int              `json:"height"`            // Image height in pixels
+	Size       int              `json:"size"`              // Size in pixels the glyphs were rendered at
+	LineHeight int              `json:"lineHeight"`        // Distance between baselines
+	Base       int              `json:"base"`              // From the top of the line to the baseline
+	Glyphs     map[string]Glyph `json:"glyphs"`            // By the character they draw
+	Kerning    map[string]int   `json:"kerning,omitempty"` // Pen adjustment between two characters, by the pair
+}
+
+// LoadSpriteSheet registers the sprite sheet described by the JSON file at
+// filePath, relative to the assets directory. Its image path is relative to the
+// JSON file.
+func (a *Assets) LoadSpriteSheet(name, filePath string) (*SpriteSheet, error) {
+	var sheet SpriteSheet
+	if err := a.readHUDFile(filePath, &sheet); err != nil {
+		return nil, fmt.Errorf("sprite sheet '%s': %w", name, err)
+	}
+	sheet.Name = name
+	if err := a.resolveHUDImage(filePath, &sheet.Image, &sheet.Width, &sheet.Height); err != nil {
+		return nil, fmt.Errorf("sprite sheet '%s': %w", name, err)
+	}
+	for frame, rect := range sheet.Frames {
+		if err := rect.within(sheet.Width, sheet.Height); err != nil {
+			return nil, fmt.Errorf("sprite sheet '%s' frame '%s': %w", name, frame, err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.spriteSheets[name] = &sheet
+	return &sheet, nil
+}
+
+// LoadBitmapFont registers the bitmap font described by the JSON file at
+// filePath, relative to the assets directory. Its image path is relative to the
+// JSON file.
+func (a *Assets) LoadBitmapFont(name, filePath string) (*BitmapFont, error) {
+	var font BitmapFont
+	if err := a.readHUDFile(filePath, &font); err != nil {
+		return nil, fmt.Errorf("font '%s': %w", name, err)
+	}
+	font.Name = name
+	if err := a.resolveHUDImage(filePath, &font.Image, &font.Width, &font.Height); err != nil {
+		return nil, fmt.Errorf("font '%s': %w", name, err)
+	}
+	if font.Size <= 0 || font.LineHeight <= 0 {
+		return nil, fmt.Errorf("font '%s' must have a positive size and lineHeight", name)
+	}
+	for char, glyph := range font.Glyphs {
+		if utf8.RuneCountInString(char) != 1 {
+			return nil, fmt.Errorf("font '%s' glyph '%s' must be a single character", name, char)
+		}
+		if err := glyph.within(font.Width, font.Height); err != nil {
+			return nil, fmt.Errorf("font '%s' glyph '%s': %w", name, char, err)
+		}
+	}
+	for pair := range font.Kerning {
+		if utf8.RuneCountInString(pair) != 2 {
+			return nil, fmt.Errorf("font '%s' kerning pair '%s' must be two characters", name, pair)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fonts[name] = &font
+	return &font, nil
+}
+
+// readHUDFile decodes a sprite sheet or font description
+func (a *Assets) readHUDFile(filePath string, v interface{}) error {
+	data, err := a.readFile(filePath)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// resolveHUDImage makes the image path of a sprite sheet or font relative to the
+// assets directory instead of its JSON file and reads the image's size
+func (a *Assets) resolveHUDImage(filePath string, image *string, width, height *int) error {
+	if *image == "" {
+		return fmt.Errorf("no image given")
+	}
+	resolved := path.Join(path.Dir(filepath.ToSlash(filePath)), *image)
+	if !fs.ValidPath(resolved) {
+		return fmt.Errorf("image '%s' must be inside the assets directory", *image)
+	}
+	w, h, err := a.imageSize(resolved)
+	if err != nil {
+		return err
+	}
+	*image, *width, *height = resolved, w, h
+	return nil
+}
+
+// ScanHUD registers every sprite sheet and bitmap font in the hud directory
+func (a *Assets) ScanHUD() error {
+	entries, err := fs.ReadDir(a.Files(), hudDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		filePath := path.Join(hudDir, entry.Name())
+		switch {
+		case entry.IsDir():
+		case strings.HasSuffix(entry.Name(), spriteSheetSuffix):
+			if _, err := a.LoadSpriteSheet(strings.TrimSuffix(entry.Name(), spriteSheetSuffix), filePath); err != nil {
+				return err
+			}
+		case strings.HasSuffix(entry.Name(), bitmapFontSuffix):
+			if _, err := a.LoadBitmapFont(strings.TrimSuffix(entry.Name(), bitmapFontSuffix), filePath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetSpriteSheet returns a sprite sheet by name
+func (a *Assets) GetSpriteSheet(name string) (*SpriteSheet, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	sheet, ok := a.spriteSheets[name]
+	if !ok {
+		return nil, fmt.Errorf("sprite sheet '%s' not found", name)
+	}
+	return sheet, nil
+}
+
+// ListSpriteSheets returns the names of all sprite sheets in sorted order
+func (a *Assets) ListSpriteSheets() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.spriteSheets))
+	for name := range a.spriteSheets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetBitmapFont returns a bitmap font by name
+func (a *Assets) GetBitmapFont(name string) (*BitmapFont, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	font, ok := a.fonts[name]
+	if !ok {
+		return nil, fmt.Errorf("font '%s' not found", name)
+	}
+	return font, nil
+}
+
+// ListBitmapFonts returns the names of all bitmap fonts in sorted order
+func (a *Assets) ListBitmapFonts() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.fonts))
+	for name := range a.fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
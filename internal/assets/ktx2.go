@@ -0,0 +1,159 @@
+package assets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// KTX2 files start with a 12-byte identifier followed by a fixed header:
+//
+//	vkFormat, typeSize, pixelWidth, pixelHeight, pixelDepth,
+//	layerCount, faceCount, levelCount, supercompressionScheme (all uint32)
+//
+// Basis Universal textures use vkFormat 0 (VK_FORMAT_UNDEFINED) and are
+// transcoded by the client to whatever compressed format its GPU supports.
+
+// ktx2Identifier identifies a KTX 2.0 file
+var ktx2Identifier = [12]byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+// KTX2MimeType is the media type clients list in Accept to receive KTX2 variants
+const KTX2MimeType = "image/ktx2"
+
+// KTX2 supercompression schemes
+const (
+	ktx2SupercompressionNone  = 0
+	ktx2SupercompressionBasis = 1 // BasisLZ (ETC1S)
+	ktx2SupercompressionZstd  = 2 // Typically UASTC + Zstandard
+)
+
+// KTX2Header is the fixed header of a KTX2 file
+type KTX2Header struct {
+	VkFormat               uint32
+	TypeSize               uint32
+	PixelWidth             uint32
+	PixelHeight            uint32
+	PixelDepth             uint32
+	LayerCount             uint32
+	FaceCount              uint32
+	LevelCount             uint32
+	SupercompressionScheme uint32
+}
+
+// TextureVariant is an alternative encoding of a texture served through content negotiation
+type TextureVariant struct {
+	MimeType string `json:"mimeType"`
+	FilePath string `json:"filePath"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Levels   int    `json:"levels"`
+	Encoding string `json:"encoding"` // e.g. "basis-etc1s", "uastc" or "raw"
+}
+
+// ReadKTX2Header decodes the header of a KTX2 file
+func ReadKTX2Header(r io.Reader) (KTX2Header, error) {
+	var identifier [12]byte
+	if _, err := io.ReadFull(r, identifier[:]); err != nil {
+		return KTX2Header{}, fmt.Errorf("failed to read KTX2 identifier: %w", err)
+	}
+	if identifier != ktx2Identifier {
+		return KTX2Header{}, fmt.Errorf("not a KTX2 file")
+	}
+
+	var header KTX2Header
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return KTX2Header{}, fmt.Errorf("failed to read KTX2 header: %w", err)
+	}
+	if header.PixelWidth == 0 {
+		return KTX2Header{}, fmt.Errorf("KTX2 file has zero width")
+	}
+	if levels := mipLevelsFor(int(header.PixelWidth), int(header.PixelHeight)); int(header.LevelCount) > levels {
+		return KTX2Header{}, fmt.Errorf("KTX2 file has %d levels, a %dx%d texture has at most %d",
+			header.LevelCount, header.PixelWidth, header.PixelHeight, levels)
+	}
+	return header, nil
+}
+
+// ktx2Encoding describes how the texel data of a KTX2 file is encoded
+func ktx2Encoding(header KTX2Header) string {
+	switch {
+	case header.SupercompressionScheme == ktx2SupercompressionBasis:
+		return "basis-etc1s"
+	case header.VkFormat == 0:
+		return "uastc"
+	case header.SupercompressionScheme == ktx2SupercompressionZstd:
+		return "zstd"
+	default:
+		return "raw"
+	}
+}
+
+// attachKTX2Variant registers a .ktx2 file next to a texture's image as a compressed variant
+func (a *Assets) attachKTX2Variant(texture *Texture) error {
+	variantPath := strings.TrimSuffix(texture.FilePath, filepath.Ext(texture.FilePath)) + ".ktx2"
+	file, err := a.openFile(variantPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, err := ReadKTX2Header(file)
+	if err != nil {
+		return fmt.Errorf("texture '%s': %w", texture.Name, err)
+	}
+
+	texture.Variants = append(texture.Variants, TextureVariant{
+		MimeType: KTX2MimeType,
+		FilePath: variantPath,
+		Width:    int(header.PixelWidth),
+		Height:   int(header.PixelHeight),
+		Levels:   max(int(header.LevelCount), 1),
+		Encoding: ktx2Encoding(header),
+	})
+	return nil
+}
+
+// NegotiateTextureFile returns the file to serve for a request for filePath
+// (relative to the assets directory) given the request's Accept header.
+// It returns the path and media type of the first variant the client accepts,
+// or empty strings to serve the original.
+func (a *Assets) NegotiateTextureFile(filePath, accept string) (string, string) {
+	if accept == "" {
+		return "", ""
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, texture := range a.textures {
+		if filepath.Clean(texture.FilePath) != filepath.Clean(filePath) {
+			continue
+		}
+		for _, variant := range texture.Variants {
+			if acceptsMimeType(accept, variant.MimeType) {
+				return filepath.Join(a.basePath, variant.FilePath), variant.MimeType
+			}
+		}
+	}
+	return "", ""
+}
+
+// acceptsMimeType reports whether an Accept header explicitly lists mimeType.
+// Wildcards do not count, since browsers send image/* without KTX2 support.
+func acceptsMimeType(accept, mimeType string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		if strings.TrimSpace(fields[0]) != mimeType {
+			continue
+		}
+		for _, param := range fields[1:] {
+			if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == "q=0" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ktx2File encodes the identifier and header of a KTX2 file
+func ktx2File(header KTX2Header) []byte {
+	var file bytes.Buffer
+	file.Write(ktx2Identifier[:])
+	binary.Write(&file, binary.LittleEndian, header)
+	return file.Bytes()
+}
+
+func TestReadKTX2Header(t *testing.T) {
+	basis := KTX2Header{PixelWidth: 256, PixelHeight: 128, FaceCount: 1, LevelCount: 9, SupercompressionScheme: ktx2SupercompressionBasis}
+	valid := ktx2File(basis)
+
+	oversized := basis
+	oversized.LevelCount = 10
+
+	zeroWidth := basis
+	zeroWidth.PixelWidth = 0
+
+	badMagic := bytes.Clone(valid)
+	badMagic[1] = 'k'
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"truncated", valid[:len(valid)-4], true},
+		{"truncated identifier", valid[:8], true},
+		{"oversized level count", ktx2File(oversized), true},
+		{"bad magic", badMagic, true},
+		{"zero width", ktx2File(zeroWidth), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := ReadKTX2Header(bytes.NewReader(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header != basis {
+				t.Errorf("got header %+v, want %+v", header, basis)
+			}
+			if encoding := ktx2Encoding(header); encoding != "basis-etc1s" {
+				t.Errorf("got encoding %s, want basis-etc1s", encoding)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package assets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Initialize parses mesh files, builds mipmaps and compresses meshes with a
+// pool of load workers. Workers only run code that needs no lock; what they
+// produce is registered in a fixed order afterwards, so the result does not
+// depend on which worker finishes first.
+
+// LoadPhase names a step of Initialize
+type LoadPhase string
+
+// Steps of Initialize in the order they run
+const (
+	LoadPhasePending     LoadPhase = "pending"     // Initialize has not started
+	LoadPhaseMeshes      LoadPhase = "meshes"      // Generating and importing meshes
+	LoadPhaseTextures    LoadPhase = "textures"    // Registering textures, skyboxes, sounds and materials
+	LoadPhaseMipmaps     LoadPhase = "mipmaps"     // Building mipmap chains
+	LoadPhaseCompression LoadPhase = "compression" // Hashing and precompressing meshes and shaders
+	LoadPhaseReady       LoadPhase = "ready"       // Everything is loaded
+	LoadPhaseFailed      LoadPhase = "failed"      // Initialize returned an error
+)
+
+// loadPhases are the phases counted by LoadProgress.Step
+var loadPhases = []LoadPhase{LoadPhaseMeshes, LoadPhaseTextures, LoadPhaseMipmaps, LoadPhaseCompression}
+
+// LoadProgress reports how far Initialize has come. A loading screen can show
+// (Step - 1 + Done/Total) / Steps as the overall fraction.
+type LoadProgress struct {
+	Phase   LoadPhase `json:"phase"`
+	Step    int       `json:"step"`              // 1-based index of Phase among the Steps phases, Steps once ready
+	Steps   int       `json:"steps"`             // Number of phases
+	Done    int       `json:"done"`              // Tasks of the phase finished
+	Total   int       `json:"total"`             // Tasks of the phase; 0 if it is not split into tasks
+	Current string    `json:"current,omitempty"` // Asset the last finished task loaded
+	Ready   bool      `json:"ready"`
+	Error   string    `json:"error,omitempty"` // Why loading failed
+}
+
+// loadProgress holds the progress of Initialize and notifies the listener
+type loadProgress struct {
+	mu       sync.Mutex
+	current  LoadProgress
+	listener func(LoadProgress)
+}
+
+// update changes the progress and notifies the listener. Updates are delivered
+// in order, one at a time.
+func (p *loadProgress) update(change func(*LoadProgress)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	change(&p.current)
+	if p.listener != nil {
+		p.listener(p.current)
+	}
+}
+
+// begin starts phase with total tasks
+func (p *loadProgress) begin(phase LoadPhase, total int) {
+	p.update(func(progress *LoadProgress) {
+		*progress = LoadProgress{Phase: phase, Steps: len(loadPhases), Total: total}
+		for i, step := range loadPhases {
+			if step == phase {
+				progress.Step = i + 1
+			}
+		}
+	})
+}
+
+// finish reports the end of loading, failed if err is not nil
+func (p *loadProgress) finish(err error) {
+	p.update(func(progress *LoadProgress) {
+		if err != nil {
+			progress.Phase, progress.Error = LoadPhaseFailed, err.Error()
+			return
+		}
+		*progress = LoadProgress{Phase: LoadPhaseReady, Step: len(loadPhases), Steps: len(loadPhases), Ready: true}
+	})
+}
+
+// SetLoadWorkers sets how many goroutines Initialize loads assets with. It
+// defaults to GOMAXPROCS; 1 loads everything serially.
+func (a *Assets) SetLoadWorkers(workers int) {
+	a.loadWorkers = max(workers, 1)
+}
+
+// OnLoadProgress makes Initialize call listener whenever its progress changes.
+// Calls come from the load workers one at a time and should return quickly.
+// It must be called before Initialize.
+func (a *Assets) OnLoadProgress(listener func(LoadProgress)) {
+	a.progress.mu.Lock()
+	defer a.progress.mu.Unlock()
+	a.progress.listener = listener
+}
+
+// LoadProgress returns how far Initialize has come
+func (a *Assets) LoadProgress() LoadProgress {
+	a.progress.mu.Lock()
+	defer a.progress.mu.Unlock()
+	return a.progress.current
+}
+
+// loadTask is one unit of work for the load workers
+type loadTask struct {
+	name string // Asset the task loads, reported as the current asset
+	run  func() error
+}
+
+// runLoadTasks runs tasks on the load workers as phase of Initialize and
+// returns the error of the first failed task in task order
+func (a *Assets) runLoadTasks(phase LoadPhase, tasks []loadTask) error {
+	a.progress.begin(phase, len(tasks))
+
+	workers := a.loadWorkers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	errs := make([]error, len(tasks))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(tasks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = tasks[i].run()
+				a.progress.update(func(progress *LoadProgress) {
+					progress.Done++
+					progress.Current = tasks[i].name
+				})
+			}
+		}()
+	}
+	for i := range tasks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importMeshes reads the meshes data file and the scenes, glTF files and STL
+// meshes in the assets directory on the load workers, then registers them in
+// that order. The water mesh is regenerated between the meshes data file and
+// the scenes, as the data file may contain a water plane of its own.
+func (a *Assets) importMeshes() error {
+	var meshes []*Mesh
+	tasks := []loadTask{{name: "meshes.json", run: func() error {
+		var err error
+		meshes, err = a.readMeshes()
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		for _, mesh := range meshes {
+			prepareMesh(mesh)
+		}
+		return err
+	}}}
+
+	// Each import is parsed into a function registering it
+	importers := []struct {
+		pattern string
+		parse   func(name, path string) (func(), error)
+	}{
+		{"*.wgscene", a.parseScene},
+		{"*.gltf", a.parseGLTF},
+		{"*.glb", a.parseGLTF},
+		{"*.stl", a.parseSTL},
+	}
+	var registers []func()
+	for _, importer := range importers {
+		paths, _ := filepath.Glob(filepath.Join(a.basePath, importer.pattern))
+		sort.Strings(paths)
+		for _, path := range paths {
+			i := len(registers)
+			registers = append(registers, nil)
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			parse := importer.parse
+			tasks = append(tasks, loadTask{name: filepath.Base(path), run: func() error {
+				var err error
+				registers[i], err = parse(name, path)
+				return err
+			}})
+		}
+	}
+
+	if err := a.runLoadTasks(LoadPhaseMeshes, tasks); err != nil {
+		return err
+	}
+
+	a.registerMeshes(meshes)
+	// A water mesh other than the default grid replaces the exported water plane
+	if params := a.WaterMeshParams(); params != DefaultWaterMeshParams() {
+		if _, err := a.GenerateWaterMesh(params); err != nil {
+			return err
+		}
+	}
+	for _, register := range registers {
+		register()
+	}
+	return nil
+}
+
+// parseScene reads a scene file for importMeshes
+func (a *Assets) parseScene(name, path string) (func(), error) {
+	scene, meshes, err := readSceneFile(name, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, mesh := range meshes {
+		prepareMesh(mesh)
+	}
+	return func() { a.registerScene(name, scene, meshes) }, nil
+}
+
+// parseGLTF reads a .gltf or .glb file for importMeshes
+func (a *Assets) parseGLTF(name, path string) (func(), error) {
+	scene, meshes, textures, err := readGLTFFile(name, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, mesh := range meshes {
+		prepareMesh(mesh)
+	}
+	return func() { a.registerGLTF(name, path, scene, meshes, textures) }, nil
+}
+
+// parseSTL reads an STL file for importMeshes
+func (a *Assets) parseSTL(name, path string) (func(), error) {
+	mesh, err := readSTLFile(name, path)
+	if err != nil {
+		return nil, err
+	}
+	prepareMesh(mesh)
+	return func() { a.storeMesh(name, mesh) }, nil
+}
@@ -0,0 +1,144 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestEntry identifies the current content of one asset
+type ManifestEntry struct {
+	Name   string `json:"name"`
+	File   string `json:"file,omitempty"` // File name the asset is served under, for textures and shaders
+	SHA256 string `json:"sha256"`         // Hex-encoded hash of the bytes the server sends for the asset
+	Size   int64  `json:"size"`           // Number of bytes the server sends for the asset
+}
+
+// Manifest lists every mesh, texture and shader with a content hash, so clients
+// can bust caches and preload only what changed since their last visit
+type Manifest struct {
+	Meshes   []ManifestEntry `json:"meshes"`
+	Textures []ManifestEntry `json:"textures"`
+	Shaders  []ManifestEntry `json:"shaders"`
+}
+
+// manifest holds the hashes computed so far by asset kind and name. It is nil
+// until Initialize has loaded everything, so assets registered while loading
+// are hashed once at the end rather than on every registration.
+type manifest map[string]map[string]ManifestEntry
+
+// SetShaderDir sets the directory shader sources are served from, so they can be
+// listed in the manifest. It must be called before Initialize.
+func (a *Assets) SetShaderDir(dir string) {
+	a.shaderDir = dir
+}
+
+// Manifest returns the hashes of all assets, sorted by name within each kind
+func (a *Assets) Manifest() Manifest {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return Manifest{
+		Meshes:   a.manifest.sorted(AssetKindMesh),
+		Textures: a.manifest.sorted(AssetKindTexture),
+		Shaders:  a.manifest.sorted(AssetKindShader),
+	}
+}
+
+func (m manifest) sorted(kind string) []ManifestEntry {
+	entries := make([]ManifestEntry, 0, len(m[kind]))
+	for _, entry := range m[kind] {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// buildManifest hashes every registered mesh and texture and every shader in the
+// shader directory
+func (a *Assets) buildManifest() error {
+	a.manifest = manifest{
+		AssetKindMesh:    make(map[string]ManifestEntry),
+		AssetKindTexture: make(map[string]ManifestEntry),
+		AssetKindShader:  make(map[string]ManifestEntry),
+	}
+	for name := range a.meshes {
+		a.hashMesh(name)
+	}
+	for name := range a.textures {
+		if err := a.hashTexture(name); err != nil {
+			return err
+		}
+	}
+
+	if a.shaderDir == "" && a.shaderFallback == nil {
+		return nil
+	}
+	entries, err := fs.ReadDir(a.shaderFiles(), ".")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !shaderExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		if err := a.hashShader(entry.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashMesh updates the manifest entry of a mesh. Meshes are hashed in the JSON
+// encoding the API serves them in; a mesh that cannot be encoded is left out.
+func (a *Assets) hashMesh(name string) {
+	if a.manifest == nil {
+		return
+	}
+	data, err := meshJSON(a.meshes[name])
+	if err != nil {
+		delete(a.manifest[AssetKindMesh], name)
+		return
+	}
+	a.manifest[AssetKindMesh][name] = manifestEntry(name, "", data)
+}
+
+// hashTexture updates the manifest entry of a texture. Textures registered without
+// a file are left out, as there is nothing to download for them.
+func (a *Assets) hashTexture(name string) error {
+	if a.manifest == nil {
+		return nil
+	}
+	texture := a.textures[name]
+	data, err := a.readFile(texture.FilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		delete(a.manifest[AssetKindTexture], name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	a.manifest[AssetKindTexture][name] = manifestEntry(name, texture.FilePath, data)
+	return nil
+}
+
+// hashShader updates the manifest entry of the shader stored in file
+func (a *Assets) hashShader(file string) error {
+	if a.manifest == nil {
+		return nil
+	}
+	data, err := fs.ReadFile(a.shaderFiles(), file)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(file, filepath.Ext(file))
+	a.manifest[AssetKindShader][name] = manifestEntry(name, file, data)
+	return nil
+}
+
+func manifestEntry(name, file string, data []byte) ManifestEntry {
+	sum := sha256.Sum256(data)
+	return ManifestEntry{Name: name, File: file, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+}
@@ -0,0 +1,197 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// materialsFile lists materials and the meshes they are assigned to, relative
+// to the assets directory
+const materialsFile = "materials.json"
+
+// Material describes how a mesh is shaded: the textures it samples and its
+// surface parameters. Meshes reference materials by name.
+type Material struct {
+	Name           string      `json:"name"`
+	DiffuseTexture string      `json:"diffuseTexture,omitempty"` // Base color; white if empty
+	NormalTexture  string      `json:"normalTexture,omitempty"`  // Tangent-space normal map; the mesh normals if empty
+	Tiling         math3d.Vec2 `json:"tiling"`                   // Texture repeats across the mesh's texture coordinates
+	Specular       float32     `json:"specular"`                 // Strength of specular highlights, 0 for none
+	Shininess      float32     `json:"shininess"`                // Specular exponent, higher is glossier
+	Opacity        float32     `json:"opacity"`                  // 1 is opaque, 0 fully transparent
+}
+
+// NewMaterial returns an opaque, untextured material with the defaults used by
+// the mesh shader
+func NewMaterial(name string) Material {
+	return Material{
+		Name:      name,
+		Tiling:    math3d.NewVec2(1, 1),
+		Specular:  0.4,
+		Shininess: 32,
+		Opacity:   1,
+	}
+}
+
+// Validate reports an error if the material parameters are out of range.
+// Texture references are checked by SetMaterial.
+func (m Material) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("material name must not be empty")
+	}
+	for name, value := range map[string]float32{
+		"tiling": m.Tiling.X, "specular": m.Specular, "shininess": m.Shininess, "opacity": m.Opacity,
+	} {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("material %s must be finite", name)
+		}
+	}
+	switch {
+	case math.IsNaN(float64(m.Tiling.Y)) || math.IsInf(float64(m.Tiling.Y), 0) || m.Tiling.X <= 0 || m.Tiling.Y <= 0:
+		return fmt.Errorf("material tiling must be positive")
+	case m.Specular < 0 || m.Shininess < 0:
+		return fmt.Errorf("material specular and shininess must not be negative")
+	case m.Opacity < 0 || m.Opacity > 1:
+		return fmt.Errorf("material opacity must be between 0 and 1, got %v", m.Opacity)
+	}
+	return nil
+}
+
+// SetMaterial adds a material or replaces the one with the same name. Its
+// textures must be registered.
+func (a *Assets) SetMaterial(material Material) error {
+	if err := material.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, texture := range []string{material.DiffuseTexture, material.NormalTexture} {
+		if _, ok := a.textures[texture]; texture != "" && !ok {
+			return fmt.Errorf("texture '%s' not found", texture)
+		}
+	}
+	a.materials[material.Name] = &material
+	return nil
+}
+
+// GetMaterial returns a material by name
+func (a *Assets) GetMaterial(name string) (Material, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	material, ok := a.materials[name]
+	if !ok {
+		return Material{}, fmt.Errorf("material '%s' not found", name)
+	}
+	return *material, nil
+}
+
+// ListMaterials returns the names of all materials in sorted order
+func (a *Assets) ListMaterials() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.materials))
+	for name := range a.materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoveMaterial removes a material. A material still assigned to a mesh
+// cannot be removed.
+func (a *Assets) RemoveMaterial(name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.materials[name]; !ok {
+		return fmt.Errorf("material '%s' not found", name)
+	}
+	for meshName, mesh := range a.meshes {
+		if mesh.Material == name {
+			return fmt.Errorf("material '%s' is used by mesh '%s'", name, meshName)
+		}
+	}
+	delete(a.materials, name)
+	return nil
+}
+
+// AssignMaterial makes a mesh use a material, or its default shading if
+// material is empty
+func (a *Assets) AssignMaterial(meshName, material string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	mesh, ok := a.meshes[meshName]
+	if !ok {
+		return fmt.Errorf("mesh '%s' not found", meshName)
+	}
+	if _, ok := a.materials[material]; material != "" && !ok {
+		return fmt.Errorf("material '%s' not found", material)
+	}
+
+	// Meshes are shared with readers, so replace rather than modify it
+	assigned := *mesh
+	assigned.Material = material
+	a.meshes[meshName] = &assigned
+	a.hashMesh(meshName)
+	a.precompressMesh(meshName)
+	return nil
+}
+
+// MeshMaterials returns the material assigned to each mesh that has one
+func (a *Assets) MeshMaterials() map[string]string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	materials := make(map[string]string)
+	for name, mesh := range a.meshes {
+		if mesh.Material != "" {
+			materials[name] = mesh.Material
+		}
+	}
+	return materials
+}
+
+// LoadMaterials adds the materials in materials.json and assigns them to the
+// meshes it lists:
+//
+//	{"materials": [{"name": "stone", "diffuseTexture": "stone"}], "meshes": {"terrain": "stone"}}
+//
+// Fields a material leaves out take the defaults of NewMaterial.
+func (a *Assets) LoadMaterials() error {
+	file, err := a.openFile(materialsFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	var document struct {
+		Materials []json.RawMessage `json:"materials"`
+		Meshes    map[string]string `json:"meshes"` // Material of each mesh, by mesh name
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", materialsFile, err)
+	}
+	for _, entry := range document.Materials {
+		material := NewMaterial("")
+		if err := json.Unmarshal(entry, &material); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", materialsFile, err)
+		}
+		if err := a.SetMaterial(material); err != nil {
+			return fmt.Errorf("%s: material '%s': %w", materialsFile, material.Name, err)
+		}
+	}
+	for mesh, material := range document.Meshes {
+		if err := a.AssignMaterial(mesh, material); err != nil {
+			return fmt.Errorf("%s: %w", materialsFile, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// The binary mesh format lets clients upload a mesh into WebGL buffers without
+// parsing JSON. All values are little-endian. A 28-byte header
+//
+//	magic       [4]byte "WGMB"
+//	version     uint32  MeshBinaryVersion
+//	attributes  uint32  MeshAttribute flags of the attributes each vertex has
+//	stride      uint32  Bytes per vertex
+//	vertexCount uint32
+//	indexCount  uint32
+//	indexWidth  uint32  16 or 32
+//
+// is followed by vertexCount interleaved vertices and indexCount indices of
+// indexWidth bits. A vertex holds float32 values for its attributes in the
+// order position (3), normal (3), texture coordinates (2), tangent (4).
+// The header and vertices are multiples of 4 bytes, so both arrays can be
+// viewed in place as typed arrays.
+
+// MeshBinaryMagic starts every binary mesh
+const MeshBinaryMagic = "WGMB"
+
+// MeshBinaryVersion is the version of the binary mesh format
+const MeshBinaryVersion = 1
+
+// MeshBinaryHeaderSize is the size of the binary mesh header in bytes
+const MeshBinaryHeaderSize = 28
+
+// MeshAttribute flags the vertex attributes present in a binary mesh
+type MeshAttribute uint32
+
+// Vertex attributes in the order they are interleaved
+const (
+	MeshAttributePosition MeshAttribute = 1 << iota
+	MeshAttributeNormal
+	MeshAttributeTexCoord
+	MeshAttributeTangent
+)
+
+// meshBinaryHeader is the header of a binary mesh after the magic
+type meshBinaryHeader struct {
+	Version     uint32
+	Attributes  MeshAttribute
+	Stride      uint32
+	VertexCount uint32
+	IndexCount  uint32
+	IndexWidth  uint32
+}
+
+// MarshalMeshBinary encodes mesh in the binary mesh format. Normals, texture
+// coordinates and tangents are included when mesh has one for every vertex.
+func MarshalMeshBinary(mesh *Mesh) ([]byte, error) {
+	vertexCount := len(mesh.Vertices) / 3
+	if vertexCount*3 != len(mesh.Vertices) {
+		return nil, fmt.Errorf("mesh '%s' has an invalid vertex array", mesh.Name)
+	}
+
+	attributes := []struct {
+		flag       MeshAttribute
+		values     []float32
+		components int
+	}{
+		{MeshAttributePosition, mesh.Vertices, 3},
+		{MeshAttributeNormal, mesh.Normals, 3},
+		{MeshAttributeTexCoord, mesh.TexCoords, 2},
+		{MeshAttributeTangent, mesh.Tangents, 4},
+	}
+	header := meshBinaryHeader{
+		Version:     MeshBinaryVersion,
+		VertexCount: uint32(vertexCount),
+		IndexCount:  uint32(len(mesh.Indices)),
+		IndexWidth:  uint32(IndexWidthFor(vertexCount)),
+	}
+	present := attributes[:0]
+	for _, attribute := range attributes {
+		if len(attribute.values) == vertexCount*attribute.components {
+			header.Attributes |= attribute.flag
+			header.Stride += uint32(attribute.components * 4)
+			present = append(present, attribute)
+		}
+	}
+
+	size := MeshBinaryHeaderSize + vertexCount*int(header.Stride) + len(mesh.Indices)*int(header.IndexWidth)/8
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	buf.WriteString(MeshBinaryMagic)
+	binary.Write(buf, binary.LittleEndian, header)
+
+	var word [4]byte
+	for v := 0; v < vertexCount; v++ {
+		for _, attribute := range present {
+			for _, value := range attribute.values[v*attribute.components : (v+1)*attribute.components] {
+				binary.LittleEndian.PutUint32(word[:], math.Float32bits(value))
+				buf.Write(word[:])
+			}
+		}
+	}
+	for _, index := range mesh.Indices {
+		if int(index) >= vertexCount {
+			return nil, fmt.Errorf("mesh '%s' has index %d out of range", mesh.Name, index)
+		}
+		if header.IndexWidth == 16 {
+			binary.LittleEndian.PutUint16(word[:], uint16(index))
+			buf.Write(word[:2])
+		} else {
+			binary.LittleEndian.PutUint32(word[:], index)
+			buf.Write(word[:])
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// MeshBinary returns a mesh in the binary mesh format, with precompressed
+// encodings if precompression is enabled
+func (a *Assets) MeshBinary(name string) (*EncodedContent, error) {
+	return a.encodedMesh(CacheKindMeshBinary, func(p *precompressed) map[string]*EncodedContent { return p.binaries }, name, MarshalMeshBinary)
+}
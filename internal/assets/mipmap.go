@@ -0,0 +1,184 @@
+package assets
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math/bits"
+	"sort"
+)
+
+// BoxDownsample halves an image with a 2×2 box filter. Odd edges reuse their last
+// row or column, and each dimension stops shrinking at 1.
+func BoxDownsample(src *image.NRGBA) *image.NRGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := max(w/2, 1), max(h/2, 1)
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0, y1 := min(y*2, h-1), min(y*2+1, h-1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := min(x*2, w-1), min(x*2+1, w-1)
+
+			var sum [4]int
+			for _, p := range [4]int{src.PixOffset(x0, y0), src.PixOffset(x1, y0), src.PixOffset(x0, y1), src.PixOffset(x1, y1)} {
+				for c := 0; c < 4; c++ {
+					sum[c] += int(src.Pix[p+c])
+				}
+			}
+
+			d := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[d+c] = uint8((sum[c] + 2) / 4)
+			}
+		}
+	}
+	return dst
+}
+
+// MipChain returns every mipmap level of img, from the full-size base down to 1×1
+func MipChain(img image.Image) []*image.NRGBA {
+	base := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(base, base.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	levels := []*image.NRGBA{base}
+	for level := base; level.Bounds().Dx() > 1 || level.Bounds().Dy() > 1; {
+		level = BoxDownsample(level)
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// mipLevelsFor returns the number of levels in a full mipmap chain for a
+// texture of the given size
+func mipLevelsFor(width, height int) int {
+	return bits.Len(uint(max(width, height, 1)))
+}
+
+// GenerateMipmaps builds PNG-encoded mipmap chains for every registered texture
+// whose file can be decoded, on the load workers. Textures that cannot be read
+// are skipped.
+func (a *Assets) GenerateMipmaps() error {
+	names := make([]string, 0, len(a.textures))
+	for name := range a.textures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	chains := make([][][]byte, len(names))
+	tasks := make([]loadTask, len(names))
+	for i, name := range names {
+		texture := a.textures[name]
+		tasks[i] = loadTask{name: name, run: func() error {
+			var err error
+			chains[i], err = a.buildMipmaps(name, texture)
+			return err
+		}}
+	}
+	if err := a.runLoadTasks(LoadPhaseMipmaps, tasks); err != nil {
+		return err
+	}
+
+	for i, name := range names {
+		a.storeMipmaps(name, chains[i])
+	}
+	return nil
+}
+
+// generateMipmaps builds the mipmap chain of one texture, dropping any stale
+// chain if its file cannot be decoded. The write lock must be held once Initialize is done.
+func (a *Assets) generateMipmaps(name string, texture *Texture) error {
+	encoded, err := a.buildMipmaps(name, texture)
+	a.storeMipmaps(name, encoded)
+	return err
+}
+
+// storeMipmaps replaces the mipmap chain of a texture, or drops it if encoded
+// is nil. The write lock must be held once Initialize is done.
+func (a *Assets) storeMipmaps(name string, encoded [][]byte) {
+	key := cacheKey{kind: CacheKindMipmaps, asset: name}
+	delete(a.mipmaps, name)
+	a.cache.remove(key)
+	if encoded != nil {
+		a.mipmaps[name] = encoded
+		a.cacheAdd(key, mipmapsSize(encoded))
+	}
+}
+
+// buildMipmaps returns the PNG-encoded mipmap chain of a texture, or nil if its
+// file cannot be decoded
+func (a *Assets) buildMipmaps(name string, texture *Texture) ([][]byte, error) {
+	file, err := a.openFile(texture.FilePath)
+	if err != nil {
+		return nil, nil
+	}
+	img, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return nil, nil
+	}
+
+	chain := MipChain(img)
+	encoded := make([][]byte, len(chain))
+	for i, level := range chain {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, level); err != nil {
+			return nil, fmt.Errorf("failed to encode mip level %d of '%s': %w", i, name, err)
+		}
+		encoded[i] = buf.Bytes()
+	}
+	return encoded, nil
+}
+
+func mipmapsSize(levels [][]byte) int64 {
+	var size int64
+	for _, level := range levels {
+		size += int64(len(level))
+	}
+	return size
+}
+
+// GetMipLevel returns a PNG-encoded mipmap level of a texture (0 is the full-size image).
+// A chain evicted from the cache is rebuilt.
+func (a *Assets) GetMipLevel(name string, level int) ([]byte, error) {
+	key := cacheKey{kind: CacheKindMipmaps, asset: name}
+	a.mu.RLock()
+	levels, exists := a.mipmaps[name]
+	texture := a.textures[name]
+	a.mu.RUnlock()
+
+	if exists {
+		a.cache.touch(key)
+	} else if texture != nil {
+		a.cache.miss()
+		built, err := a.buildMipmaps(name, texture)
+		if err != nil {
+			return nil, err
+		}
+		if built != nil {
+			levels, exists = built, true
+			a.mu.Lock()
+			if a.textures[name] == texture {
+				a.mipmaps[name] = built
+				a.cacheAdd(key, mipmapsSize(built))
+			}
+			a.mu.Unlock()
+		}
+	}
+	if !exists {
+		return nil, fmt.Errorf("no mipmaps for texture '%s'", name)
+	}
+	if level < 0 || level >= len(levels) {
+		return nil, fmt.Errorf("texture '%s' has no mip level %d", name, level)
+	}
+	return levels[level], nil
+}
+
+// MipLevelCount returns the number of mipmap levels generated for a texture
+func (a *Assets) MipLevelCount(name string) int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.mipmaps[name])
+}
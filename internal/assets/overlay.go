@@ -0,0 +1,102 @@
+package assets
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Overlay returns a file system that opens each file from the first layer that
+// has it, so files on disk can override defaults embedded in the binary.
+// Directory listings merge all layers. Nil layers are skipped.
+func Overlay(layers ...fs.FS) fs.FS {
+	var overlay overlayFS
+	for _, layer := range layers {
+		if layer != nil {
+			overlay = append(overlay, layer)
+		}
+	}
+	return overlay
+}
+
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	for _, layer := range o {
+		file, err := layer.Open(name)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return file, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	found := false
+	for _, layer := range o {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, entry := range layerEntries {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// SetFallbackFS sets where asset and shader files missing from their directories
+// on disk are read from instead, such as the defaults embedded in the binary.
+// It must be called before Initialize. Either may be nil.
+func (a *Assets) SetFallbackFS(assets, shaders fs.FS) {
+	a.fallback, a.shaderFallback = assets, shaders
+}
+
+// Files returns the asset files: the assets directory over the fallback files
+func (a *Assets) Files() fs.FS {
+	return Overlay(os.DirFS(a.basePath), a.fallback)
+}
+
+// shaderFiles returns the shader directory over the shader fallback files
+func (a *Assets) shaderFiles() fs.FS {
+	var dir fs.FS
+	if a.shaderDir != "" {
+		dir = os.DirFS(a.shaderDir)
+	}
+	return Overlay(dir, a.shaderFallback)
+}
+
+// openFile opens a file relative to the assets directory. Paths that leave the
+// directory, as glTF textures may, are opened on disk without a fallback.
+func (a *Assets) openFile(filePath string) (fs.File, error) {
+	slashed := filepath.ToSlash(filePath)
+	if !fs.ValidPath(slashed) {
+		return os.Open(filepath.Join(a.basePath, filePath))
+	}
+	return a.Files().Open(slashed)
+}
+
+// readFile reads a file relative to the assets directory like openFile
+func (a *Assets) readFile(filePath string) ([]byte, error) {
+	file, err := a.openFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
@@ -0,0 +1,291 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ku3ppi/webgl-water/internal/codec"
+)
+
+// Mesh JSON and shader sources are compressed once when they are loaded rather
+// than on every request; the terrain mesh alone is several MB of JSON.
+
+// EncodedContent is the body served for a mesh or shader together with its
+// precompressed encodings
+type EncodedContent struct {
+	Data      []byte            // Uncompressed body
+	SHA256    [sha256.Size]byte // Hash of Data
+	Encodings map[string][]byte // Data compressed by each precompression codec, by codec name
+}
+
+// precompressed holds the encoded meshes and shaders. It is nil until
+// Initialize has loaded everything, like the manifest.
+type precompressed struct {
+	meshes   map[string]*EncodedContent
+	binaries map[string]*EncodedContent // Meshes in the binary mesh format
+	shaders  map[string]*EncodedContent // By file name
+}
+
+// EnablePrecompression makes Initialize compress mesh JSON and shader sources
+// with each of codecs, and keeps the encodings up to date as assets change.
+// It must be called before Initialize.
+func (a *Assets) EnablePrecompression(codecs ...codec.Codec) {
+	a.precompressCodecs = nil
+	for _, c := range codecs {
+		if c.Name() != codec.NameNone {
+			a.precompressCodecs = append(a.precompressCodecs, c)
+		}
+	}
+}
+
+// MeshJSON returns the JSON encoding of a mesh, with precompressed encodings
+// if precompression is enabled
+func (a *Assets) MeshJSON(name string) (*EncodedContent, error) {
+	return a.encodedMesh(CacheKindMeshJSON, func(p *precompressed) map[string]*EncodedContent { return p.meshes }, name, meshJSON)
+}
+
+// ShaderSource returns the source of the shader stored in file with the color
+// space defines inserted, with precompressed encodings if precompression is
+// enabled. Encodings of a source or defines that changed since the last call
+// are rebuilt.
+func (a *Assets) ShaderSource(file string) (*EncodedContent, error) {
+	source, err := fs.ReadFile(a.shaderFiles(), file)
+	if err != nil {
+		return nil, err
+	}
+	data := []byte(InjectShaderDefines(string(source), a.ColorSpaceDefines()))
+
+	a.mu.RLock()
+	enabled := a.precompressed != nil
+	content := &EncodedContent{Data: data, SHA256: sha256.Sum256(data)}
+	if enabled {
+		if cached, ok := a.precompressed.shaders[file]; ok && bytes.Equal(cached.Data, data) {
+			content = cached
+		}
+	}
+	a.mu.RUnlock()
+	if !enabled || content.Encodings != nil {
+		return content, nil
+	}
+
+	if content.Encodings, err = a.encode(data); err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.precompressed.shaders[file] = content
+	a.mu.Unlock()
+	return content, nil
+}
+
+// InjectShaderDefines inserts preprocessor lines into a shader source.
+// GLSL requires #version to come first, so the lines go right after it when present.
+func InjectShaderDefines(source, defines string) string {
+	if strings.HasPrefix(strings.TrimLeft(source, " \t\r\n"), "#version") {
+		start := strings.Index(source, "#version")
+		if end := strings.IndexByte(source[start:], '\n'); end >= 0 {
+			split := start + end + 1
+			return source[:split] + defines + source[split:]
+		}
+		return source + "\n" + defines
+	}
+	return defines + source
+}
+
+// meshJSON encodes a mesh as the API serves it. json.Encoder ends the document
+// with a newline, so the encoding does too.
+func meshJSON(mesh *Mesh) ([]byte, error) {
+	data, err := json.Marshal(mesh)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// buildPrecompressed compresses every mesh and every shader in the shader directory
+func (a *Assets) buildPrecompressed() error {
+	if len(a.precompressCodecs) == 0 {
+		return nil
+	}
+	a.precompressed = &precompressed{
+		meshes:   make(map[string]*EncodedContent),
+		binaries: make(map[string]*EncodedContent),
+		shaders:  make(map[string]*EncodedContent),
+	}
+	if err := a.precompressMeshes(); err != nil {
+		return err
+	}
+
+	if a.shaderDir == "" && a.shaderFallback == nil {
+		return nil
+	}
+	entries, err := fs.ReadDir(a.shaderFiles(), ".")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !shaderExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		if err := a.precompressShader(entry.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// precompressMesh updates the encodings of a mesh in JSON and the binary mesh
+// format. A mesh that cannot be encoded is left out and encoded on request instead.
+func (a *Assets) precompressMesh(name string) {
+	if a.precompressed == nil {
+		return
+	}
+	a.precompressMeshAs(CacheKindMeshJSON, a.precompressed.meshes, name, meshJSON)
+	a.precompressMeshAs(CacheKindMeshBinary, a.precompressed.binaries, name, MarshalMeshBinary)
+}
+
+func (a *Assets) precompressMeshAs(kind string, contents map[string]*EncodedContent, name string, marshal func(*Mesh) ([]byte, error)) {
+	a.storePrecompressed(kind, contents, name, a.encodeMesh(a.meshes[name], marshal))
+}
+
+// encodeMesh returns a mesh encoded by marshal and compressed with every
+// precompression codec, or nil if it cannot be encoded. It needs no lock.
+func (a *Assets) encodeMesh(mesh *Mesh, marshal func(*Mesh) ([]byte, error)) *EncodedContent {
+	data, err := marshal(mesh)
+	if err != nil {
+		return nil
+	}
+	encodings, err := a.encode(data)
+	if err != nil {
+		return nil
+	}
+	return &EncodedContent{Data: data, SHA256: sha256.Sum256(data), Encodings: encodings}
+}
+
+// storePrecompressed replaces the encoded mesh name in contents, or drops it if
+// content is nil. The write lock must be held.
+func (a *Assets) storePrecompressed(kind string, contents map[string]*EncodedContent, name string, content *EncodedContent) {
+	key := cacheKey{kind: kind, asset: name}
+	delete(contents, name)
+	a.cache.remove(key)
+	if content != nil {
+		contents[name] = content
+		a.cacheAdd(key, encodedSize(content))
+	}
+}
+
+// precompressMeshes encodes every mesh in JSON and the binary mesh format on the load workers
+func (a *Assets) precompressMeshes() error {
+	names := make([]string, 0, len(a.meshes))
+	for name := range a.meshes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	jsons := make([]*EncodedContent, len(names))
+	binaries := make([]*EncodedContent, len(names))
+	tasks := make([]loadTask, len(names))
+	for i, name := range names {
+		mesh := a.meshes[name]
+		tasks[i] = loadTask{name: name, run: func() error {
+			jsons[i] = a.encodeMesh(mesh, meshJSON)
+			binaries[i] = a.encodeMesh(mesh, MarshalMeshBinary)
+			return nil
+		}}
+	}
+	if err := a.runLoadTasks(LoadPhaseCompression, tasks); err != nil {
+		return err
+	}
+
+	for i, name := range names {
+		a.storePrecompressed(CacheKindMeshJSON, a.precompressed.meshes, name, jsons[i])
+		a.storePrecompressed(CacheKindMeshBinary, a.precompressed.binaries, name, binaries[i])
+	}
+	return nil
+}
+
+// encodedMesh returns a mesh encoded by marshal, with the precompressed
+// encodings kept in contents if precompression is enabled. Encodings evicted
+// from the cache are rebuilt.
+func (a *Assets) encodedMesh(kind string, contents func(*precompressed) map[string]*EncodedContent, name string, marshal func(*Mesh) ([]byte, error)) (*EncodedContent, error) {
+	a.mu.RLock()
+	mesh, ok := a.meshes[name]
+	enabled := a.precompressed != nil
+	var cached *EncodedContent
+	if ok && enabled {
+		cached = contents(a.precompressed)[name]
+	}
+	a.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("mesh '%s' not found", name)
+	}
+	key := cacheKey{kind: kind, asset: name}
+	if cached != nil {
+		a.cache.touch(key)
+		return cached, nil
+	}
+
+	data, err := marshal(mesh)
+	if err != nil {
+		return nil, err
+	}
+	content := &EncodedContent{Data: data, SHA256: sha256.Sum256(data)}
+	if !enabled {
+		return content, nil
+	}
+	a.cache.miss()
+	if content.Encodings, err = a.encode(data); err != nil {
+		return &EncodedContent{Data: data, SHA256: content.SHA256}, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.meshes[name] == mesh {
+		contents(a.precompressed)[name] = content
+		a.cacheAdd(key, encodedSize(content))
+	}
+	return content, nil
+}
+
+// precompressShader updates the encodings of the shader stored in file
+func (a *Assets) precompressShader(file string) error {
+	if a.precompressed == nil {
+		return nil
+	}
+	source, err := fs.ReadFile(a.shaderFiles(), file)
+	if err != nil {
+		return err
+	}
+	data := []byte(InjectShaderDefines(string(source), a.colorSpaceDefines()))
+	encodings, err := a.encode(data)
+	if err != nil {
+		return err
+	}
+	a.precompressed.shaders[file] = &EncodedContent{Data: data, SHA256: sha256.Sum256(data), Encodings: encodings}
+	return nil
+}
+
+// encode compresses data with every precompression codec
+func (a *Assets) encode(data []byte) (map[string][]byte, error) {
+	encodings := make(map[string][]byte, len(a.precompressCodecs))
+	for _, c := range a.precompressCodecs {
+		var buf bytes.Buffer
+		writer, err := c.NewWriter(&buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to precompress with %s: %w", c.Name(), err)
+		}
+		if _, err := writer.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to precompress with %s: %w", c.Name(), err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to precompress with %s: %w", c.Name(), err)
+		}
+		encodings[c.Name()] = buf.Bytes()
+	}
+	return encodings, nil
+}
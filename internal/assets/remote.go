@@ -0,0 +1,296 @@
+package assets
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// remoteTimeout bounds each request to a remote store when no client is given
+const remoteTimeout = 2 * time.Minute
+
+// HTTPIndexFile is the file an HTTPStore reads its listing from
+const HTTPIndexFile = "index.json"
+
+// HTTPStore is a Store served by any static HTTP server or CDN. Since HTTP has
+// no directory listing, the files are listed in index.json at the base URL, as
+// a JSON array of StoreObject values:
+//
+//	[{"path": "stone-texture.png", "size": 12345, "etag": "3f2a..."}, ...]
+type HTTPStore struct {
+	BaseURL string
+	Client  *http.Client // http.Client with a two minute timeout if nil
+}
+
+// List reads the index file
+func (h HTTPStore) List(ctx context.Context) ([]StoreObject, error) {
+	body, err := h.Open(ctx, HTTPIndexFile)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var objects []StoreObject
+	if err := json.NewDecoder(body).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", HTTPIndexFile, err)
+	}
+	return objects, nil
+}
+
+// Open fetches a file relative to the base URL
+func (h HTTPStore) Open(ctx context.Context, objectPath string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(h.BaseURL, "/")+"/"+escapePath(objectPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	return fetch(remoteClient(h.Client), req)
+}
+
+// S3Store is a Store backed by an S3 bucket or an S3-compatible object store
+// (MinIO, R2, ...). Requests are signed with AWS Signature Version 4 when an
+// access key is set; otherwise the bucket must allow anonymous reads.
+type S3Store struct {
+	Bucket   string
+	Prefix   string // Key prefix of the asset files, e.g. "assets/"
+	Region   string // us-east-1 if empty
+	Endpoint string // Base URL of an S3-compatible service, addressed path-style; AWS if empty
+
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // For temporary credentials
+
+	Client *http.Client // http.Client with a two minute timeout if nil
+}
+
+// NewS3StoreFromEnv creates an S3Store with credentials from the standard
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables
+func NewS3StoreFromEnv(bucket, prefix, region, endpoint string) *S3Store {
+	return &S3Store{
+		Bucket:       bucket,
+		Prefix:       prefix,
+		Region:       region,
+		Endpoint:     endpoint,
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// s3ListResult is the part of a ListObjectsV2 response the store needs
+type s3ListResult struct {
+	Contents []struct {
+		Key  string
+		Size int64
+		ETag string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List returns every object under the prefix, with paths relative to it
+func (s *S3Store) List(ctx context.Context) ([]StoreObject, error) {
+	var objects []StoreObject
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		body, err := s.do(ctx, "", query)
+		if err != nil {
+			return nil, err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(body).Decode(&result)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket listing: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			// Keys ending in a slash are folder placeholders created by consoles
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			objects = append(objects, StoreObject{
+				Path: strings.TrimPrefix(object.Key, s.Prefix),
+				Size: object.Size,
+				ETag: strings.Trim(object.ETag, `"`),
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Open fetches the object at path under the prefix
+func (s *S3Store) Open(ctx context.Context, objectPath string) (io.ReadCloser, error) {
+	return s.do(ctx, s.Prefix+objectPath, nil)
+}
+
+// do sends a signed GET request for key (the bucket itself if empty)
+func (s *S3Store) do(ctx context.Context, key string, query url.Values) (io.ReadCloser, error) {
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	var target string
+	if s.Endpoint != "" {
+		target = strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + escapePath(key)
+	} else {
+		target = "https://" + s.Bucket + ".s3." + region + ".amazonaws.com/" + escapePath(key)
+	}
+	if len(query) > 0 {
+		target += "?" + canonicalQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.AccessKey != "" {
+		if s.SessionToken != "" {
+			req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+		}
+		signV4(req, s.AccessKey, s.SecretKey, region, "s3", time.Now())
+	}
+	return fetch(remoteClient(s.Client), req)
+}
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signV4 adds an AWS Signature Version 4 Authorization header to a request
+// without a body, signing the host and every header already set
+func signV4(req *http.Request, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key with every reserved character
+// percent-encoded, as both S3 and Signature Version 4 expect
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath percent-encodes each segment of a slash-separated path
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes everything but unreserved characters (RFC 3986)
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// fetch sends req and returns the body of a successful response
+func fetch(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", req.URL.Redacted(), resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func remoteClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: remoteTimeout}
+}
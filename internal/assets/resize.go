@@ -0,0 +1,173 @@
+package assets
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+)
+
+// MaxResizeDimension bounds the width and height a texture may be resized to
+const MaxResizeDimension = 4096
+
+// maxResizedTextures bounds how many resized textures are cached at once, even
+// without a memory budget
+const maxResizedTextures = 256
+
+// ResizedTexture is an encoded, downscaled copy of a texture
+type ResizedTexture struct {
+	Data     []byte
+	MimeType string
+	Width    int
+	Height   int
+}
+
+// FitSize returns the largest size with the aspect ratio of a width×height image
+// that fits in maxWidth×maxHeight without enlarging it. A bound of 0 leaves that
+// dimension unconstrained.
+func FitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && maxWidth < width {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && float64(maxHeight) < float64(height)*scale {
+		scale = float64(maxHeight) / float64(height)
+	}
+	return max(int(float64(width)*scale+0.5), 1), max(int(float64(height)*scale+0.5), 1)
+}
+
+// AreaDownsample shrinks an image to width×height by averaging the source pixels
+// each destination pixel covers. It is meant for reducing, not enlarging.
+func AreaDownsample(src *image.NRGBA, width, height int) *image.NRGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					p := src.PixOffset(sx, sy)
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[p+c])
+					}
+				}
+			}
+
+			count := (x1 - x0) * (y1 - y0)
+			d := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[d+c] = uint8((sum[c] + count/2) / count)
+			}
+		}
+	}
+	return dst
+}
+
+// ResizeTexture returns a texture scaled down to fit in maxWidth×maxHeight,
+// keeping its aspect ratio; a bound of 0 leaves that dimension unconstrained.
+// Textures are never enlarged. JPEG textures are encoded as JPEG, all others as
+// PNG. Results are cached until the texture is reloaded or the memory budget
+// evicts them.
+func (a *Assets) ResizeTexture(name string, maxWidth, maxHeight int) (*ResizedTexture, error) {
+	if maxWidth < 0 || maxHeight < 0 || maxWidth > MaxResizeDimension || maxHeight > MaxResizeDimension {
+		return nil, fmt.Errorf("resize dimensions must be between 0 and %d", MaxResizeDimension)
+	}
+	if maxWidth == 0 && maxHeight == 0 {
+		return nil, fmt.Errorf("a width or height to resize to is required")
+	}
+
+	texture, err := a.GetTexture(name)
+	if err != nil {
+		return nil, err
+	}
+	width, height := FitSize(texture.Width, texture.Height, maxWidth, maxHeight)
+	key := resizedKey(name, width, height)
+
+	a.mu.RLock()
+	cached, ok := a.resized[key]
+	a.mu.RUnlock()
+	if ok {
+		a.cache.touch(key)
+		return cached, nil
+	}
+	a.cache.miss()
+
+	file, err := a.openFile(texture.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	img, format, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("texture '%s' cannot be resized: %w", name, err)
+	}
+
+	// The file may have changed size since it was registered
+	width, height = FitSize(img.Bounds().Dx(), img.Bounds().Dy(), maxWidth, maxHeight)
+	key = resizedKey(name, width, height)
+	src := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	scaled := AreaDownsample(src, width, height)
+
+	resized := &ResizedTexture{Width: width, Height: height}
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 90})
+		resized.MimeType = "image/jpeg"
+	} else {
+		err = png.Encode(&buf, scaled)
+		resized.MimeType = "image/png"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resized texture '%s': %w", name, err)
+	}
+	resized.Data = buf.Bytes()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.resized) >= maxResizedTextures {
+		for evicted := range a.resized {
+			delete(a.resized, evicted)
+			a.cache.remove(evicted)
+			break
+		}
+	}
+	a.resized[key] = resized
+	a.cacheAdd(key, int64(len(resized.Data)))
+	return resized, nil
+}
+
+// resizedKey identifies a texture resized to width×height
+func resizedKey(name string, width, height int) cacheKey {
+	return cacheKey{kind: CacheKindResized, asset: name, variant: fmt.Sprintf("%dx%d", width, height)}
+}
+
+// TextureForFile returns the name of the texture stored in filePath, relative
+// to the assets directory
+func (a *Assets) TextureForFile(filePath string) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for name, texture := range a.textures {
+		if filepath.Clean(texture.FilePath) == filepath.Clean(filePath) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// dropResized removes the cached resized copies of a texture. The write lock must be held.
+func (a *Assets) dropResized(name string) {
+	for key := range a.resized {
+		if key.asset == name {
+			delete(a.resized, key)
+			a.cache.remove(key)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package assets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// MaxScatterCount bounds the number of instances one scatter may place
+const MaxScatterCount = 100000
+
+// scatterAttempts is how many candidate points are drawn per requested
+// instance before giving up on constraints that leave too little room
+const scatterAttempts = 20
+
+// ScatterParams configures how instances of a mesh are scattered over a
+// terrain mesh. Points are spread evenly across the terrain as seen from above;
+// points outside the height range or on steeper ground than MaxSlope are skipped.
+type ScatterParams struct {
+	Seed      int64   `json:"seed"`
+	Count     int     `json:"count"`     // Instances to place
+	Terrain   string  `json:"terrain"`   // Mesh the instances stand on
+	MinHeight float32 `json:"minHeight"` // Lowest ground height an instance may stand at
+	MaxHeight float32 `json:"maxHeight"` // Highest ground height an instance may stand at
+	MaxSlope  float32 `json:"maxSlope"`  // Steepest ground in degrees from horizontal, 90 allows any
+	MinScale  float32 `json:"minScale"`
+	MaxScale  float32 `json:"maxScale"`
+}
+
+// DefaultScatterParams returns settings that place instances on the terrain
+// around the water line
+func DefaultScatterParams() ScatterParams {
+	return ScatterParams{
+		Seed:      1,
+		Count:     100,
+		Terrain:   "terrain",
+		MinHeight: -1,
+		MaxHeight: 1,
+		MaxSlope:  30,
+		MinScale:  0.8,
+		MaxScale:  1.2,
+	}
+}
+
+// Validate reports an error if the parameters cannot place instances
+func (p ScatterParams) Validate() error {
+	for name, value := range map[string]float32{
+		"minHeight": p.MinHeight, "maxHeight": p.MaxHeight, "maxSlope": p.MaxSlope,
+		"minScale": p.MinScale, "maxScale": p.MaxScale,
+	} {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("scatter %s must be finite, got %v", name, value)
+		}
+	}
+	switch {
+	case p.Count < 0 || p.Count > MaxScatterCount:
+		return fmt.Errorf("scatter count must be between 0 and %d, got %d", MaxScatterCount, p.Count)
+	case p.Terrain == "":
+		return fmt.Errorf("scatter terrain must name a mesh")
+	case p.MinHeight > p.MaxHeight:
+		return fmt.Errorf("scatter minHeight must not exceed maxHeight")
+	case p.MaxSlope < 0 || p.MaxSlope > 90:
+		return fmt.Errorf("scatter maxSlope must be between 0 and 90 degrees, got %v", p.MaxSlope)
+	case p.MinScale <= 0 || p.MinScale > p.MaxScale:
+		return fmt.Errorf("scatter scales must be positive with minScale not above maxScale")
+	}
+	return nil
+}
+
+// Instance is one placement of a scattered mesh
+type Instance struct {
+	Position  math3d.Vec3 `json:"position"`
+	Yaw       float32     `json:"yaw"` // Rotation about the Y axis in radians
+	Scale     float32     `json:"scale"`
+	Transform math3d.Mat4 `json:"transform"` // Model matrix combining the three
+}
+
+// Scatter places instances on terrain. Fewer than params.Count are returned
+// when the constraints leave too little of the terrain to place them on.
+func Scatter(terrain *Mesh, params ScatterParams) []Instance {
+	type candidate struct {
+		a, b, c math3d.Vec3
+	}
+
+	// Pick triangles by their area seen from above, so instances are spread
+	// evenly over the ground regardless of how the terrain is tessellated
+	minNormalY := float32(math.Cos(float64(params.MaxSlope) * math.Pi / 180))
+	var triangles []candidate
+	var cumulative []float32
+	var total float32
+	vertexCount := uint32(len(terrain.Vertices) / 3)
+	for i := 0; i+2 < len(terrain.Indices); i += 3 {
+		if terrain.Indices[i] >= vertexCount || terrain.Indices[i+1] >= vertexCount || terrain.Indices[i+2] >= vertexCount {
+			continue
+		}
+		a := terrainVertex(terrain, terrain.Indices[i])
+		b := terrainVertex(terrain, terrain.Indices[i+1])
+		c := terrainVertex(terrain, terrain.Indices[i+2])
+		normal := b.Sub(a).Cross(c.Sub(a))
+		length := normal.Length()
+		if length == 0 || float32(math.Abs(float64(normal.Y)))/length < minNormalY-1e-6 {
+			continue
+		}
+		total += float32(math.Abs(float64(normal.Y))) / 2
+		triangles = append(triangles, candidate{a, b, c})
+		cumulative = append(cumulative, total)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	rng := math3d.NewRand(params.Seed)
+	instances := make([]Instance, 0, params.Count)
+	for attempt := 0; attempt < params.Count*scatterAttempts && len(instances) < params.Count; attempt++ {
+		pick := rng.Range(0, total)
+		t := triangles[min(sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > pick }), len(triangles)-1)]
+
+		// Uniform point in the triangle, folding the far half of the parallelogram back
+		u, v := rng.Float32(), rng.Float32()
+		if u+v > 1 {
+			u, v = 1-u, 1-v
+		}
+		position := t.a.Add(t.b.Sub(t.a).Scale(u)).Add(t.c.Sub(t.a).Scale(v))
+		if position.Y < params.MinHeight || position.Y > params.MaxHeight {
+			continue
+		}
+
+		yaw := rng.Range(0, 2*math.Pi)
+		scale := rng.Range(params.MinScale, params.MaxScale)
+		instances = append(instances, Instance{
+			Position: position,
+			Yaw:      yaw,
+			Scale:    scale,
+			Transform: math3d.TranslationVec3(position).
+				Multiply(math3d.RotationY(yaw)).
+				Multiply(math3d.ScaleUniform(scale)),
+		})
+	}
+	return instances
+}
+
+func terrainVertex(mesh *Mesh, index uint32) math3d.Vec3 {
+	i := int(index) * 3
+	return math3d.NewVec3(mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2])
+}
+
+// scatterSet is the scatter of one mesh with the instances last placed
+type scatterSet struct {
+	params    ScatterParams
+	instances []Instance
+}
+
+// SetScatter scatters instances of mesh over params.Terrain, replacing any
+// earlier scatter of mesh. Instances are placed again whenever the terrain
+// mesh changes; until it exists there are none.
+func (a *Assets) SetScatter(mesh string, params ScatterParams) ([]Instance, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	set := &scatterSet{params: params}
+	if terrain, ok := a.meshes[params.Terrain]; ok {
+		set.instances = Scatter(terrain, params)
+	}
+	a.scatters[mesh] = set
+	return set.instances, nil
+}
+
+// RemoveScatter removes the scatter of mesh and reports whether there was one
+func (a *Assets) RemoveScatter(mesh string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.scatters[mesh]
+	delete(a.scatters, mesh)
+	return ok
+}
+
+// Instances returns the scatter settings and instances of mesh
+func (a *Assets) Instances(mesh string) (ScatterParams, []Instance, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	set, ok := a.scatters[mesh]
+	if !ok {
+		return ScatterParams{}, nil, fmt.Errorf("mesh '%s' is not scattered", mesh)
+	}
+	return set.params, set.instances, nil
+}
+
+// ListScatters returns the names of all scattered meshes in sorted order
+func (a *Assets) ListScatters() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.scatters))
+	for name := range a.scatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rescatter places the instances standing on terrain again. The write lock must be held.
+func (a *Assets) rescatter(terrain string) {
+	for _, set := range a.scatters {
+		if set.params.Terrain == terrain {
+			set.instances = Scatter(a.meshes[terrain], set.params)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package assets
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// Scene files (.wgscene) are written by tools/blender/export_scene.py and use a
+// simple chunked little-endian layout:
+//
+//	header:  "WGSC" magic, uint32 version
+//	chunk:   4-byte tag, uint32 payload length, payload
+//
+// Strings are a uint32 byte length followed by UTF-8 bytes, arrays are a uint32
+// element count followed by the elements. Chunks are read in order and unknown
+// tags are skipped, so newer exporters stay loadable.
+//
+//	MESH  name, positions []f32, normals []f32, uvs []f32, indices []u32
+//	MATL  name, base color 4×f32, texture name, roughness f32, metallic f32, alpha f32
+//	NODE  name, parent index i32 (-1 for roots), mesh name, material name,
+//	      position 3×f32, rotation 4×f32 (x, y, z, w), scale 3×f32,
+//	      property count u32 followed by key/value string pairs
+//	END   terminates the file
+
+// sceneMagic identifies a scene file
+var sceneMagic = [4]byte{'W', 'G', 'S', 'C'}
+
+// sceneVersion is the scene format version understood by this loader
+const sceneVersion uint32 = 1
+
+// Scene represents an imported scene with its object hierarchy and materials
+type Scene struct {
+	Name      string          `json:"name"`
+	Nodes     []SceneNode     `json:"nodes"`
+	Materials []SceneMaterial `json:"materials"`
+	Meshes    []string        `json:"meshes"`
+	Revision  int             `json:"revision"` // Incremented by every patch and reload
+}
+
+// SceneNode represents an object in the scene hierarchy.
+// Custom properties set in Blender (e.g. water body markers or spawn points) are kept in Properties.
+type SceneNode struct {
+	Name       string            `json:"name"`
+	Parent     int               `json:"parent"` // Index into Scene.Nodes, -1 for root nodes
+	Mesh       string            `json:"mesh,omitempty"`
+	Material   string            `json:"material,omitempty"`
+	Transform  math3d.Transform  `json:"transform"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// SceneMaterial represents material parameters exported with a scene
+type SceneMaterial struct {
+	Name      string       `json:"name"`
+	BaseColor math3d.Color `json:"baseColor"`
+	Texture   string       `json:"texture,omitempty"`
+	Roughness float32      `json:"roughness"`
+	Metallic  float32      `json:"metallic"`
+	Alpha     float32      `json:"alpha"`
+}
+
+// Children returns the indices of the direct children of the node at index
+func (s *Scene) Children(index int) []int {
+	var children []int
+	for i, node := range s.Nodes {
+		if node.Parent == index {
+			children = append(children, i)
+		}
+	}
+	return children
+}
+
+// WorldTransforms returns the transforms of all nodes linked to their parents,
+// so LocalToWorld yields each node's world matrix
+func (s *Scene) WorldTransforms() []*math3d.Transform {
+	transforms := make([]*math3d.Transform, len(s.Nodes))
+	for i := range s.Nodes {
+		transform := s.Nodes[i].Transform
+		transforms[i] = &transform
+	}
+	for i, node := range s.Nodes {
+		if node.Parent >= 0 {
+			transforms[i].SetParent(transforms[node.Parent])
+		}
+	}
+	return transforms
+}
+
+// sceneReader decodes the primitive values used in scene chunks. Reads are
+// limited to the chunk's payload.
+type sceneReader struct {
+	r   *io.LimitedReader
+	err error
+}
+
+func (r *sceneReader) read(v interface{}) {
+	if r.err == nil {
+		r.err = binary.Read(r.r, binary.LittleEndian, v)
+	}
+}
+
+func (r *sceneReader) uint32() uint32 {
+	var v uint32
+	r.read(&v)
+	return v
+}
+
+func (r *sceneReader) float32() float32 {
+	var v float32
+	r.read(&v)
+	return v
+}
+
+// count reads an element count, checking that that many elements of size
+// bytes fit in what is left of the chunk before anything is allocated for them
+func (r *sceneReader) count(size int) int {
+	n := r.uint32()
+	if r.err == nil && int64(n)*int64(size) > r.r.N {
+		r.err = fmt.Errorf("%d elements do not fit in the %d bytes left in the chunk", n, r.r.N)
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (r *sceneReader) string() string {
+	n := r.count(1)
+	if r.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		r.err = err
+		return ""
+	}
+	return string(b)
+}
+
+func (r *sceneReader) float32s() []float32 {
+	n := r.count(4)
+	if r.err != nil {
+		return nil
+	}
+	values := make([]float32, n)
+	r.read(values)
+	return values
+}
+
+func (r *sceneReader) uint32s() []uint32 {
+	n := r.count(4)
+	if r.err != nil {
+		return nil
+	}
+	values := make([]uint32, n)
+	r.read(values)
+	return values
+}
+
+// ReadScene decodes a scene file. Meshes contained in the scene are returned alongside it.
+func ReadScene(name string, input io.Reader) (*Scene, []*Mesh, error) {
+	var header struct {
+		Magic   [4]byte
+		Version uint32
+	}
+	if err := binary.Read(input, binary.LittleEndian, &header); err != nil {
+		return nil, nil, fmt.Errorf("failed to read scene header: %w", err)
+	}
+	if header.Magic != sceneMagic {
+		return nil, nil, fmt.Errorf("not a scene file")
+	}
+	if header.Version != sceneVersion {
+		return nil, nil, fmt.Errorf("unsupported scene version %d", header.Version)
+	}
+
+	scene := &Scene{Name: name}
+	var meshes []*Mesh
+
+	for {
+		var chunk struct {
+			Tag    [4]byte
+			Length uint32
+		}
+		if err := binary.Read(input, binary.LittleEndian, &chunk); err != nil {
+			return nil, nil, fmt.Errorf("failed to read scene chunk: %w", err)
+		}
+
+		payload := &io.LimitedReader{R: input, N: int64(chunk.Length)}
+		r := &sceneReader{r: payload}
+
+		switch string(chunk.Tag[:]) {
+		case "MESH":
+			mesh, err := readSceneMesh(r)
+			if err != nil {
+				return nil, nil, err
+			}
+			meshes = append(meshes, mesh)
+			scene.Meshes = append(scene.Meshes, mesh.Name)
+		case "MATL":
+			material := SceneMaterial{
+				Name:      r.string(),
+				BaseColor: math3d.NewColor(r.float32(), r.float32(), r.float32(), r.float32()),
+				Texture:   r.string(),
+				Roughness: r.float32(),
+				Metallic:  r.float32(),
+				Alpha:     r.float32(),
+			}
+			if r.err != nil {
+				return nil, nil, fmt.Errorf("invalid material chunk: %w", r.err)
+			}
+			scene.Materials = append(scene.Materials, material)
+		case "NODE":
+			node, err := readSceneNode(r)
+			if err != nil {
+				return nil, nil, err
+			}
+			if node.Parent >= len(scene.Nodes) {
+				return nil, nil, fmt.Errorf("node '%s' references a parent that is not defined before it", node.Name)
+			}
+			scene.Nodes = append(scene.Nodes, node)
+		case "END ":
+			return scene, meshes, nil
+		}
+
+		// Skip whatever is left of the chunk (all of it for unknown tags)
+		if _, err := io.Copy(io.Discard, payload); err != nil {
+			return nil, nil, fmt.Errorf("failed to read scene chunk: %w", err)
+		}
+	}
+}
+
+// readSceneMesh decodes a MESH chunk
+func readSceneMesh(r *sceneReader) (*Mesh, error) {
+	name := r.string()
+	vertices := r.float32s()
+	normals := r.float32s()
+	texCoords := r.float32s()
+	indices := r.uint32s()
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid mesh chunk: %w", r.err)
+	}
+
+	if len(vertices)%3 != 0 {
+		return nil, fmt.Errorf("mesh '%s' has %d position values, not a multiple of 3", name, len(vertices))
+	}
+	vertexCount := len(vertices) / 3
+	if len(normals) != len(vertices) {
+		return nil, fmt.Errorf("mesh '%s' has %d normal values for %d vertices", name, len(normals), vertexCount)
+	}
+	if len(texCoords) != vertexCount*2 {
+		return nil, fmt.Errorf("mesh '%s' has %d texture coordinate values for %d vertices", name, len(texCoords), vertexCount)
+	}
+	for _, index := range indices {
+		if int(index) >= vertexCount {
+			return nil, fmt.Errorf("mesh '%s' has an out of range index", name)
+		}
+	}
+
+	return &Mesh{
+		Name:          name,
+		Vertices:      vertices,
+		Normals:       normals,
+		TexCoords:     texCoords,
+		Indices:       indices,
+		IndexWidth:    IndexWidthFor(vertexCount),
+		VertexCount:   vertexCount,
+		TriangleCount: len(indices) / 3,
+	}, nil
+}
+
+// readSceneNode decodes a NODE chunk
+func readSceneNode(r *sceneReader) (SceneNode, error) {
+	node := SceneNode{
+		Name:     r.string(),
+		Parent:   int(int32(r.uint32())),
+		Mesh:     r.string(),
+		Material: r.string(),
+	}
+	node.Transform = math3d.Transform{
+		Position: math3d.NewVec3(r.float32(), r.float32(), r.float32()),
+		Rotation: math3d.NewQuat(r.float32(), r.float32(), r.float32(), r.float32()),
+		Scale:    math3d.NewVec3(r.float32(), r.float32(), r.float32()),
+	}
+
+	count := r.uint32()
+	if r.err == nil && count > 0 {
+		node.Properties = make(map[string]string, count)
+		for i := uint32(0); i < count && r.err == nil; i++ {
+			key := r.string()
+			node.Properties[key] = r.string()
+		}
+	}
+
+	if r.err != nil {
+		return SceneNode{}, fmt.Errorf("invalid node chunk: %w", r.err)
+	}
+	return node, nil
+}
+
+// LoadScene imports a scene file, registering its meshes and the scene itself under name
+func (a *Assets) LoadScene(name, path string) (*Scene, error) {
+	scene, meshes, err := readSceneFile(name, path)
+	if err != nil {
+		return nil, err
+	}
+	a.registerScene(name, scene, meshes)
+	return scene, nil
+}
+
+// readSceneFile parses a scene file without registering anything
+func readSceneFile(name, path string) (*Scene, []*Mesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	scene, meshes, err := ReadScene(name, bufio.NewReader(file))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load scene '%s': %w", name, err)
+	}
+	return scene, meshes, nil
+}
+
+// registerScene registers a parsed scene and its meshes
+func (a *Assets) registerScene(name string, scene *Scene, meshes []*Mesh) {
+	for _, mesh := range meshes {
+		a.storeMesh(mesh.Name, mesh)
+	}
+	a.storeScene(name, scene)
+}
+
+// GetScene returns a scene by name
+func (a *Assets) GetScene(name string) (*Scene, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	scene, exists := a.scenes[name]
+	if !exists {
+		return nil, fmt.Errorf("scene '%s' not found", name)
+	}
+	return scene, nil
+}
+
+// ListScenes returns a list of all loaded scene names
+func (a *Assets) ListScenes() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.scenes))
+	for name := range a.scenes {
+		names = append(names, name)
+	}
+	return names
+}
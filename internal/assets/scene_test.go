@@ -0,0 +1,115 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// sceneChunk encodes a chunk payload the way tools/blender/export_scene.py does
+type sceneChunk struct {
+	bytes.Buffer
+}
+
+func (c *sceneChunk) uint32(v uint32) {
+	binary.Write(&c.Buffer, binary.LittleEndian, v)
+}
+
+func (c *sceneChunk) string(s string) {
+	c.uint32(uint32(len(s)))
+	c.WriteString(s)
+}
+
+func (c *sceneChunk) float32s(values ...float32) {
+	c.uint32(uint32(len(values)))
+	binary.Write(&c.Buffer, binary.LittleEndian, values)
+}
+
+func (c *sceneChunk) uint32s(values ...uint32) {
+	c.uint32(uint32(len(values)))
+	binary.Write(&c.Buffer, binary.LittleEndian, values)
+}
+
+// sceneFile encodes a scene file holding the given chunks followed by END
+func sceneFile(chunks map[string]*sceneChunk, order ...string) []byte {
+	var file bytes.Buffer
+	file.Write(sceneMagic[:])
+	binary.Write(&file, binary.LittleEndian, sceneVersion)
+	for _, tag := range append(order, "END ") {
+		file.WriteString(tag)
+		var payload []byte
+		if chunk, ok := chunks[tag]; ok {
+			payload = chunk.Bytes()
+		}
+		binary.Write(&file, binary.LittleEndian, uint32(len(payload)))
+		file.Write(payload)
+	}
+	return file.Bytes()
+}
+
+// sceneTriangle encodes a MESH chunk with the given normal and uv value counts
+func sceneTriangle(normals, uvs int) *sceneChunk {
+	var mesh sceneChunk
+	mesh.string("triangle")
+	mesh.float32s(0, 0, 0, 1, 0, 0, 0, 0, 1)
+	mesh.float32s(make([]float32, normals)...)
+	mesh.float32s(make([]float32, uvs)...)
+	mesh.uint32s(0, 1, 2)
+	return &mesh
+}
+
+func TestReadScene(t *testing.T) {
+	var node sceneChunk
+	node.string("water")
+	node.uint32(0xFFFFFFFF) // Root
+	node.string("triangle")
+	node.string("")
+	for _, v := range []float32{0, 0, 0, 0, 0, 0, 1, 1, 1, 1} {
+		binary.Write(&node.Buffer, binary.LittleEndian, v)
+	}
+	node.uint32(1)
+	node.string("kind")
+	node.string("water_body")
+
+	valid := sceneFile(map[string]*sceneChunk{"MESH": sceneTriangle(9, 6), "NODE": &node}, "MESH", "NODE")
+
+	badMagic := bytes.Clone(valid)
+	copy(badMagic, "WGSX")
+
+	// The name length of the mesh claims far more bytes than the chunk holds
+	oversized := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(oversized[16:], 1<<31)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"truncated", valid[:len(valid)-12], true},
+		{"oversized length", oversized, true},
+		{"bad magic", badMagic, true},
+		{"missing normals", sceneFile(map[string]*sceneChunk{"MESH": sceneTriangle(6, 6)}, "MESH"), true},
+		{"missing uvs", sceneFile(map[string]*sceneChunk{"MESH": sceneTriangle(9, 4)}, "MESH"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scene, meshes, err := ReadScene("test", bytes.NewReader(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(meshes) != 1 || meshes[0].VertexCount != 3 || meshes[0].TriangleCount != 1 {
+				t.Fatalf("got meshes %v, want one triangle", meshes)
+			}
+			if len(scene.Nodes) != 1 || scene.Nodes[0].Parent != -1 || scene.Nodes[0].Properties["kind"] != "water_body" {
+				t.Errorf("got nodes %+v", scene.Nodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Scene edits are exchanged as patches in the style of JSON Patch (RFC 6902),
+// so editors send only what changed instead of whole scenes:
+//
+//	{"baseRevision": 4, "ops": [
+//	    {"op": "replace", "path": "/nodes/Boat/transform/position", "value": [1, 0, 2]},
+//	    {"op": "add", "path": "/nodes/Boat/properties/floats", "value": "true"},
+//	    {"op": "remove", "path": "/materials/Rust"}
+//	]}
+//
+// Paths address nodes and materials by name rather than by index, so they stay
+// valid while other editors add and remove objects. A node's parent is given
+// by name too, "" for root nodes. A patch applies completely or not at all.
+// Patches made against an older revision are accepted as long as none of their
+// paths overlap with a path changed since then; otherwise they conflict and the
+// editor has to fetch the scene again.
+
+// Scene patch operations
+const (
+	ScenePatchAdd     = "add"     // Add a node, material or property, or set a field
+	ScenePatchRemove  = "remove"  // Remove a node, material or property
+	ScenePatchReplace = "replace" // Replace an existing value
+	ScenePatchTest    = "test"    // Fail the patch unless the value at path equals value
+)
+
+// maxScenePatchHistory is how many applied patches per scene are kept for
+// conflict detection. Patches based on an older revision always conflict.
+const maxScenePatchHistory = 256
+
+// ErrScenePatchConflict is returned for patches that overlap with edits made
+// since their base revision
+var ErrScenePatchConflict = errors.New("scene was changed concurrently")
+
+// ScenePatch is a set of scene edits applied as one transaction
+type ScenePatch struct {
+	BaseRevision int            `json:"baseRevision"` // Scene revision the edits were made against
+	Ops          []ScenePatchOp `json:"ops"`
+}
+
+// ScenePatchOp is a single edit
+type ScenePatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"` // JSON pointer below /nodes/{name} or /materials/{name}
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// appliedScenePatch records which paths a revision changed
+type appliedScenePatch struct {
+	revision int
+	paths    [][]string
+}
+
+// storeScene registers a scene. A scene replacing one with the same name (after
+// a reload) continues its revision numbering, so edits to the old one conflict.
+func (a *Assets) storeScene(name string, scene *Scene) {
+	if previous, ok := a.scenes[name]; ok {
+		scene.Revision = previous.Revision + 1
+	}
+	a.scenes[name] = scene
+	delete(a.patches, name)
+}
+
+// PatchScene applies patch to the named scene and returns the new scene. Meshes
+// and textures referenced by the edits must be registered. It returns an error
+// wrapping ErrScenePatchConflict if the patch conflicts with concurrent edits.
+func (a *Assets) PatchScene(name string, patch ScenePatch) (*Scene, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	scene, exists := a.scenes[name]
+	if !exists {
+		return nil, fmt.Errorf("scene '%s' not found", name)
+	}
+	if len(patch.Ops) == 0 {
+		return nil, fmt.Errorf("patch has no operations")
+	}
+
+	paths := make([][]string, len(patch.Ops))
+	for i, op := range patch.Ops {
+		tokens, err := parseScenePath(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = tokens
+	}
+	if err := a.checkSceneConflicts(scene, patch.BaseRevision, paths); err != nil {
+		return nil, err
+	}
+
+	doc, err := newSceneDocument(scene)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range patch.Ops {
+		if err := doc.apply(op, paths[i]); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	patched, err := doc.scene(scene)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.validateScene(patched); err != nil {
+		return nil, err
+	}
+
+	patched.Revision = scene.Revision + 1
+	a.scenes[name] = patched
+
+	history := append(a.patches[name], appliedScenePatch{revision: patched.Revision, paths: paths})
+	if len(history) > maxScenePatchHistory {
+		history = history[len(history)-maxScenePatchHistory:]
+	}
+	a.patches[name] = history
+	return patched, nil
+}
+
+// checkSceneConflicts fails if any of paths overlaps with a path changed after base
+func (a *Assets) checkSceneConflicts(scene *Scene, base int, paths [][]string) error {
+	if base == scene.Revision {
+		return nil
+	}
+	if base > scene.Revision {
+		return fmt.Errorf("base revision %d is newer than the scene's revision %d", base, scene.Revision)
+	}
+
+	history := a.patches[scene.Name]
+	if len(history) == 0 || history[0].revision > base+1 {
+		return fmt.Errorf("%w: revision %d is too old to merge with", ErrScenePatchConflict, base)
+	}
+	for _, applied := range history {
+		if applied.revision <= base {
+			continue
+		}
+		for _, changed := range applied.paths {
+			for _, path := range paths {
+				if pathsOverlap(changed, path) {
+					return fmt.Errorf("%w: /%s was changed in revision %d",
+						ErrScenePatchConflict, strings.Join(changed, "/"), applied.revision)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// pathsOverlap reports whether one path equals or contains the other
+func pathsOverlap(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseScenePath splits a JSON pointer into its unescaped tokens. Only paths to
+// a node or material, or to something inside one, can be edited.
+func parseScenePath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid path '%s'", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	if len(tokens) < 2 || (tokens[0] != "nodes" && tokens[0] != "materials") || tokens[1] == "" {
+		return nil, fmt.Errorf("invalid path '%s': must start with /nodes/{name} or /materials/{name}", path)
+	}
+	if len(tokens) > 2 && tokens[2] == "name" {
+		return nil, fmt.Errorf("invalid path '%s': renaming is not supported", path)
+	}
+	return tokens, nil
+}
+
+// sceneDocument is a scene decoded into plain JSON values, with nodes and
+// materials keyed by name so patch paths can address them
+type sceneDocument struct {
+	root  map[string]interface{}
+	order map[string][]string // Names in scene order per collection, added names last
+}
+
+func newSceneDocument(scene *Scene) (*sceneDocument, error) {
+	doc := &sceneDocument{
+		root:  map[string]interface{}{},
+		order: map[string][]string{},
+	}
+
+	nodes := map[string]interface{}{}
+	for _, node := range scene.Nodes {
+		if _, duplicate := nodes[node.Name]; duplicate {
+			return nil, fmt.Errorf("scene cannot be patched: node name '%s' is not unique", node.Name)
+		}
+		value, err := toJSONValue(node)
+		if err != nil {
+			return nil, err
+		}
+		fields := value.(map[string]interface{})
+		fields["parent"] = ""
+		if node.Parent >= 0 && node.Parent < len(scene.Nodes) {
+			fields["parent"] = scene.Nodes[node.Parent].Name
+		}
+		nodes[node.Name] = fields
+		doc.order["nodes"] = append(doc.order["nodes"], node.Name)
+	}
+	doc.root["nodes"] = nodes
+
+	materials := map[string]interface{}{}
+	for _, material := range scene.Materials {
+		if _, duplicate := materials[material.Name]; duplicate {
+			return nil, fmt.Errorf("scene cannot be patched: material name '%s' is not unique", material.Name)
+		}
+		value, err := toJSONValue(material)
+		if err != nil {
+			return nil, err
+		}
+		materials[material.Name] = value
+		doc.order["materials"] = append(doc.order["materials"], material.Name)
+	}
+	doc.root["materials"] = materials
+	return doc, nil
+}
+
+// toJSONValue converts v to the plain values encoding/json decodes into interface{}
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	return value, json.Unmarshal(data, &value)
+}
+
+// apply performs one operation on the document
+func (d *sceneDocument) apply(op ScenePatchOp, tokens []string) error {
+	var value interface{}
+	if op.Op != ScenePatchRemove {
+		if len(op.Value) == 0 {
+			return fmt.Errorf("missing value")
+		}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+	}
+
+	collection := d.root[tokens[0]].(map[string]interface{})
+
+	// Whole nodes and materials
+	if len(tokens) == 2 {
+		_, exists := collection[tokens[1]]
+		switch op.Op {
+		case ScenePatchAdd:
+			if exists {
+				return fmt.Errorf("'%s' already exists", tokens[1])
+			}
+			if _, ok := value.(map[string]interface{}); !ok {
+				return fmt.Errorf("value must be an object")
+			}
+			collection[tokens[1]] = value
+			d.order[tokens[0]] = append(d.order[tokens[0]], tokens[1])
+			return nil
+		case ScenePatchRemove:
+			if !exists {
+				return fmt.Errorf("'%s' not found", tokens[1])
+			}
+			delete(collection, tokens[1])
+			return nil
+		}
+	}
+
+	parent, last, err := resolveParent(d.root, tokens)
+	if err != nil {
+		return err
+	}
+	return applyAt(op.Op, parent, last, value)
+}
+
+// resolveParent returns the container holding the value at tokens and its key in it
+func resolveParent(root interface{}, tokens []string) (interface{}, string, error) {
+	current := root
+	for _, token := range tokens[:len(tokens)-1] {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			next, ok := container[token]
+			if !ok {
+				return nil, "", fmt.Errorf("'%s' not found", token)
+			}
+			current = next
+		case []interface{}:
+			index, err := arrayIndex(token, len(container))
+			if err != nil {
+				return nil, "", err
+			}
+			current = container[index]
+		default:
+			return nil, "", fmt.Errorf("'%s' not found", token)
+		}
+	}
+	return current, tokens[len(tokens)-1], nil
+}
+
+// applyAt performs op on the member key of container
+func applyAt(op string, container interface{}, key string, value interface{}) error {
+	switch container := container.(type) {
+	case map[string]interface{}:
+		existing, exists := container[key]
+		switch op {
+		case ScenePatchAdd:
+			container[key] = value
+		case ScenePatchReplace, ScenePatchRemove, ScenePatchTest:
+			if !exists {
+				return fmt.Errorf("'%s' not found", key)
+			}
+			switch op {
+			case ScenePatchReplace:
+				container[key] = value
+			case ScenePatchRemove:
+				delete(container, key)
+			case ScenePatchTest:
+				if !reflect.DeepEqual(existing, value) {
+					return fmt.Errorf("test failed")
+				}
+			}
+		default:
+			return fmt.Errorf("unknown operation '%s'", op)
+		}
+		return nil
+	case []interface{}:
+		// Arrays inside a node or material have a fixed length (e.g. mesh
+		// weights), so their elements can be replaced and tested only
+		index, err := arrayIndex(key, len(container))
+		if err != nil {
+			return err
+		}
+		switch op {
+		case ScenePatchReplace:
+			container[index] = value
+		case ScenePatchTest:
+			if !reflect.DeepEqual(container[index], value) {
+				return fmt.Errorf("test failed")
+			}
+		default:
+			return fmt.Errorf("'%s' is not supported on array elements", op)
+		}
+		return nil
+	default:
+		return fmt.Errorf("'%s' not found", key)
+	}
+}
+
+func arrayIndex(token string, length int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index >= length {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	return index, nil
+}
+
+// scene converts the document back into a scene based on original
+func (d *sceneDocument) scene(original *Scene) (*Scene, error) {
+	scene := &Scene{Name: original.Name, Meshes: original.Meshes}
+
+	nodes := d.root["nodes"].(map[string]interface{})
+	names := d.liveNames("nodes")
+	indices := make(map[string]int, len(names))
+	for i, name := range names {
+		indices[name] = i
+	}
+	for _, name := range names {
+		fields := nodes[name].(map[string]interface{})
+		parentName, _ := fields["parent"].(string)
+		delete(fields, "parent")
+
+		var node SceneNode
+		if err := fromJSONValue(fields, &node); err != nil {
+			return nil, fmt.Errorf("node '%s': %w", name, err)
+		}
+		node.Name = name
+		node.Parent = -1
+		if parentName != "" {
+			parent, ok := indices[parentName]
+			if !ok {
+				return nil, fmt.Errorf("node '%s': parent '%s' not found", name, parentName)
+			}
+			node.Parent = parent
+		}
+		scene.Nodes = append(scene.Nodes, node)
+	}
+
+	materials := d.root["materials"].(map[string]interface{})
+	for _, name := range d.liveNames("materials") {
+		var material SceneMaterial
+		if err := fromJSONValue(materials[name], &material); err != nil {
+			return nil, fmt.Errorf("material '%s': %w", name, err)
+		}
+		material.Name = name
+		scene.Materials = append(scene.Materials, material)
+	}
+	return scene, nil
+}
+
+// liveNames returns the names in a collection that were not removed, in order
+func (d *sceneDocument) liveNames(collection string) []string {
+	members := d.root[collection].(map[string]interface{})
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range d.order[collection] {
+		if _, ok := members[name]; ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fromJSONValue decodes a plain JSON value into v, rejecting unknown fields
+func fromJSONValue(value interface{}, v interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// validateScene checks that a patched scene is consistent: parents form a
+// forest, and referenced meshes, materials and textures exist. a.mu must be held.
+func (a *Assets) validateScene(scene *Scene) error {
+	materials := make(map[string]bool, len(scene.Materials))
+	for _, material := range scene.Materials {
+		materials[material.Name] = true
+		if material.Texture != "" {
+			if _, ok := a.textures[material.Texture]; !ok {
+				return fmt.Errorf("material '%s': texture '%s' not found", material.Name, material.Texture)
+			}
+		}
+	}
+
+	for i, node := range scene.Nodes {
+		if node.Mesh != "" {
+			if _, ok := a.meshes[node.Mesh]; !ok {
+				return fmt.Errorf("node '%s': mesh '%s' not found", node.Name, node.Mesh)
+			}
+		}
+		if node.Material != "" && !materials[node.Material] {
+			return fmt.Errorf("node '%s': material '%s' not found", node.Name, node.Material)
+		}
+
+		// Walking up from any node must reach a root within len(Nodes) steps
+		parent := node.Parent
+		for steps := 0; parent >= 0; steps++ {
+			if steps >= len(scene.Nodes) || parent == i {
+				return fmt.Errorf("node '%s' is its own ancestor", node.Name)
+			}
+			parent = scene.Nodes[parent].Parent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package assets
+
+import (
+	"fmt"
+	"image"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Skyboxes live in the skyboxes directory of the assets directory, either as a
+// directory of six cube faces or as a single equirectangular panorama:
+//
+//	skyboxes/harbor/px.png nx.png py.png ny.png pz.png nz.png
+//	skyboxes/sunset.jpg
+
+// skyboxDir is the directory under the assets directory holding skyboxes
+const skyboxDir = "skyboxes"
+
+// SkyboxLayout tells clients how a skybox's images map onto the sky
+type SkyboxLayout string
+
+// Supported skybox layouts
+const (
+	SkyboxCubemap         SkyboxLayout = "cubemap"         // Six square faces
+	SkyboxEquirectangular SkyboxLayout = "equirectangular" // One 2:1 panorama
+)
+
+// CubemapFaces names the cube faces in the order WebGL's TEXTURE_CUBE_MAP_POSITIVE_X
+// and following targets expect them
+var CubemapFaces = [6]string{"px", "nx", "py", "ny", "pz", "nz"}
+
+// EquirectangularFace is the face name of an equirectangular skybox's only image
+const EquirectangularFace = "panorama"
+
+// Skybox is a sky environment for the scene and its reflection in the water
+type Skybox struct {
+	Name   string            `json:"name"`
+	Layout SkyboxLayout      `json:"layout"`
+	Width  int               `json:"width"`  // Face width, or panorama width
+	Height int               `json:"height"` // Face height, or panorama height
+	Faces  map[string]string `json:"faces"`  // Image path relative to the assets directory by face name
+}
+
+// RegisterCubemapSkybox registers a skybox from six square images of the same size,
+// given relative to the assets directory in CubemapFaces order
+func (a *Assets) RegisterCubemapSkybox(name string, faces [6]string) error {
+	skybox := &Skybox{Name: name, Layout: SkyboxCubemap, Faces: make(map[string]string, len(faces))}
+	for i, face := range CubemapFaces {
+		width, height, err := a.imageSize(faces[i])
+		if err != nil {
+			return fmt.Errorf("skybox '%s' face %s: %w", name, face, err)
+		}
+		if width != height {
+			return fmt.Errorf("skybox '%s' face %s is %d×%d, cube faces must be square", name, face, width, height)
+		}
+		if i > 0 && width != skybox.Width {
+			return fmt.Errorf("skybox '%s' face %s is %d pixels wide, expected %d like face %s",
+				name, face, width, skybox.Width, CubemapFaces[0])
+		}
+		skybox.Width, skybox.Height = width, height
+		skybox.Faces[face] = faces[i]
+	}
+
+	a.skyboxes[name] = skybox
+	return nil
+}
+
+// RegisterEquirectangularSkybox registers a skybox from one panorama twice as wide as
+// it is high, given relative to the assets directory
+func (a *Assets) RegisterEquirectangularSkybox(name, filePath string) error {
+	width, height, err := a.imageSize(filePath)
+	if err != nil {
+		return fmt.Errorf("skybox '%s': %w", name, err)
+	}
+	if width != 2*height {
+		return fmt.Errorf("skybox '%s' is %d×%d, equirectangular panoramas must be 2:1", name, width, height)
+	}
+
+	a.skyboxes[name] = &Skybox{
+		Name:   name,
+		Layout: SkyboxEquirectangular,
+		Width:  width,
+		Height: height,
+		Faces:  map[string]string{EquirectangularFace: filePath},
+	}
+	return nil
+}
+
+// imageSize reads the dimensions of an image relative to the assets directory
+func (a *Assets) imageSize(filePath string) (int, int, error) {
+	file, err := a.openFile(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read '%s': %w", filePath, err)
+	}
+	return config.Width, config.Height, nil
+}
+
+// ScanSkyboxes registers every skybox in the skyboxes directory
+func (a *Assets) ScanSkyboxes() error {
+	entries, err := fs.ReadDir(a.Files(), skyboxDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() {
+			if ext == ".png" || ext == ".jpg" || ext == ".jpeg" {
+				name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+				if err := a.RegisterEquirectangularSkybox(name, filepath.Join(skyboxDir, entry.Name())); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		var faces [6]string
+		for i, face := range CubemapFaces {
+			faces[i], err = a.findFaceImage(filepath.Join(skyboxDir, entry.Name()), face)
+			if err != nil {
+				return fmt.Errorf("skybox '%s': %w", entry.Name(), err)
+			}
+		}
+		if err := a.RegisterCubemapSkybox(entry.Name(), faces); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findFaceImage returns the PNG or JPEG image named face in dir
+func (a *Assets) findFaceImage(dir, face string) (string, error) {
+	for _, ext := range []string{".png", ".jpg", ".jpeg"} {
+		path := filepath.Join(dir, face+ext)
+		if _, err := fs.Stat(a.Files(), filepath.ToSlash(path)); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("missing face %s", face)
+}
+
+// GetSkybox returns a skybox by name
+func (a *Assets) GetSkybox(name string) (*Skybox, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	skybox, exists := a.skyboxes[name]
+	if !exists {
+		return nil, fmt.Errorf("skybox '%s' not found", name)
+	}
+	return skybox, nil
+}
+
+// GetSkyboxFacePath returns the full file path of one face of a skybox
+func (a *Assets) GetSkyboxFacePath(name, face string) (string, error) {
+	skybox, err := a.GetSkybox(name)
+	if err != nil {
+		return "", err
+	}
+	filePath, exists := skybox.Faces[face]
+	if !exists {
+		return "", fmt.Errorf("skybox '%s' has no face '%s'", name, face)
+	}
+	return filepath.Join(a.basePath, filePath), nil
+}
+
+// ListSkyboxes returns the names of all registered skyboxes, sorted
+func (a *Assets) ListSkyboxes() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	names := make([]string, 0, len(a.skyboxes))
+	for name := range a.skyboxes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,179 @@
+package assets
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// STL files come in two flavours:
+//
+//	binary:  80-byte header, uint32 triangle count, then per triangle
+//	         normal 3×f32, vertices 9×f32, uint16 attribute byte count
+//	ASCII:   "solid name", then "facet normal nx ny nz", "outer loop",
+//	         three "vertex x y z" lines, "endloop", "endfacet", ... "endsolid"
+//
+// Stored facet normals are frequently zero or stale, so normals are always
+// recomputed from the triangle winding. Corners are only shared between
+// triangles with the same position and face normal, which keeps CAD hard
+// edges sharp while flat regions reuse their vertices.
+
+// stlBinaryHeaderSize is the size of the header and triangle count of a binary STL
+const stlBinaryHeaderSize = 84
+
+// stlBinaryTriangleSize is the size of one triangle record in a binary STL
+const stlBinaryTriangleSize = 50
+
+// ReadSTL decodes a binary or ASCII STL file into a mesh
+func ReadSTL(name string, data []byte) (*Mesh, error) {
+	var triangles [][3]math3d.Vec3
+	var err error
+
+	if isBinarySTL(data) {
+		triangles, err = readBinarySTL(data)
+	} else {
+		triangles, err = readASCIISTL(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid STL '%s': %w", name, err)
+	}
+
+	return stlMesh(name, triangles)
+}
+
+// isBinarySTL reports whether data is a binary STL. ASCII files start with
+// "solid", but so do some binary headers, so the size is checked as well.
+func isBinarySTL(data []byte) bool {
+	if len(data) < stlBinaryHeaderSize {
+		return false
+	}
+	count := binary.LittleEndian.Uint32(data[80:84])
+	if uint64(len(data)) == stlBinaryHeaderSize+uint64(count)*stlBinaryTriangleSize {
+		return true
+	}
+	return !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid"))
+}
+
+func readBinarySTL(data []byte) ([][3]math3d.Vec3, error) {
+	count := int(binary.LittleEndian.Uint32(data[80:84]))
+	if (len(data)-stlBinaryHeaderSize)/stlBinaryTriangleSize < count {
+		return nil, fmt.Errorf("file is truncated")
+	}
+
+	triangles := make([][3]math3d.Vec3, count)
+	for i := range triangles {
+		// Skip the stored normal (12 bytes)
+		record := data[stlBinaryHeaderSize+i*stlBinaryTriangleSize+12:]
+		for v := 0; v < 3; v++ {
+			triangles[i][v] = math3d.NewVec3(
+				math.Float32frombits(binary.LittleEndian.Uint32(record[v*12:])),
+				math.Float32frombits(binary.LittleEndian.Uint32(record[v*12+4:])),
+				math.Float32frombits(binary.LittleEndian.Uint32(record[v*12+8:])),
+			)
+		}
+	}
+	return triangles, nil
+}
+
+func readASCIISTL(data []byte) ([][3]math3d.Vec3, error) {
+	var triangles [][3]math3d.Vec3
+	var corners []math3d.Vec3
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "vertex":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("line %d: vertex needs 3 coordinates", line)
+			}
+			var xyz [3]float32
+			for i := range xyz {
+				v, err := strconv.ParseFloat(fields[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				xyz[i] = float32(v)
+			}
+			corners = append(corners, math3d.NewVec3(xyz[0], xyz[1], xyz[2]))
+		case "endloop":
+			if len(corners) != 3 {
+				return nil, fmt.Errorf("line %d: facet has %d vertices, expected 3", line, len(corners))
+			}
+			triangles = append(triangles, [3]math3d.Vec3{corners[0], corners[1], corners[2]})
+			corners = corners[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(triangles) == 0 {
+		return nil, fmt.Errorf("no facets found")
+	}
+	return triangles, nil
+}
+
+// stlMesh builds an indexed mesh with face normals from a triangle soup
+func stlMesh(name string, triangles [][3]math3d.Vec3) (*Mesh, error) {
+	type vertexKey struct {
+		position, normal math3d.Vec3
+	}
+
+	mesh := &Mesh{Name: name}
+	combined := make(map[vertexKey]uint32)
+
+	for _, triangle := range triangles {
+		normal := triangle[1].Sub(triangle[0]).Cross(triangle[2].Sub(triangle[0])).Normalize()
+
+		for _, position := range triangle {
+			key := vertexKey{position: position, normal: normal}
+			index, ok := combined[key]
+			if !ok {
+				index = uint32(len(combined))
+				combined[key] = index
+				mesh.Vertices = append(mesh.Vertices, position.X, position.Y, position.Z)
+				mesh.Normals = append(mesh.Normals, normal.X, normal.Y, normal.Z)
+				mesh.TexCoords = append(mesh.TexCoords, 0, 0)
+			}
+			mesh.Indices = append(mesh.Indices, index)
+		}
+	}
+
+	mesh.VertexCount = len(mesh.Vertices) / 3
+	mesh.IndexWidth = IndexWidthFor(mesh.VertexCount)
+	mesh.TriangleCount = len(mesh.Indices) / 3
+	return mesh, nil
+}
+
+// LoadSTL imports an STL file and registers its mesh under name
+func (a *Assets) LoadSTL(name, path string) (*Mesh, error) {
+	mesh, err := readSTLFile(name, path)
+	if err != nil {
+		return nil, err
+	}
+
+	a.storeMesh(name, mesh)
+	return mesh, nil
+}
+
+// readSTLFile parses an STL file without registering its mesh
+func readSTLFile(name, path string) (*Mesh, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReadSTL(name, data)
+}
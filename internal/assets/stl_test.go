@@ -0,0 +1,80 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// stlQuad holds two triangles of a unit square in the XZ plane
+var stlQuad = [][9]float32{
+	{0, 0, 0, 0, 0, 1, 1, 0, 0},
+	{1, 0, 0, 0, 0, 1, 1, 0, 1},
+}
+
+// binarySTL encodes triangles as a binary STL claiming count triangles
+func binarySTL(count uint32, triangles [][9]float32) []byte {
+	var file bytes.Buffer
+	file.Write(make([]byte, 80))
+	binary.Write(&file, binary.LittleEndian, count)
+	for _, triangle := range triangles {
+		binary.Write(&file, binary.LittleEndian, [3]float32{}) // Stored normal, ignored
+		binary.Write(&file, binary.LittleEndian, triangle)
+		binary.Write(&file, binary.LittleEndian, uint16(0))
+	}
+	return file.Bytes()
+}
+
+const asciiSTLQuad = `solid quad
+  facet normal 0 1 0
+    outer loop
+      vertex 0 0 0
+      vertex 0 0 1
+      vertex 1 0 0
+    endloop
+  endfacet
+  facet normal 0 1 0
+    outer loop
+      vertex 1 0 0
+      vertex 0 0 1
+      vertex 1 0 1
+    endloop
+  endfacet
+endsolid quad
+`
+
+func TestReadSTL(t *testing.T) {
+	valid := binarySTL(2, stlQuad)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"binary", valid, false},
+		{"ascii", []byte(asciiSTLQuad), false},
+		{"truncated", valid[:len(valid)-10], true},
+		{"oversized count", binarySTL(0xFFFFFFFF, stlQuad), true},
+		{"bad magic", []byte("\x89PNG\r\n\x1a\n"), true},
+		{"ascii facet with two vertices", []byte("solid x\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendsolid x\n"), true},
+		{"ascii bad coordinate", []byte("solid x\nouter loop\nvertex 0 0 zero\n"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mesh, err := ReadSTL("quad", tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The coplanar triangles share the corners of their common edge
+			if mesh.VertexCount != 4 || mesh.TriangleCount != 2 {
+				t.Errorf("got %d vertices and %d triangles, want 4 and 2", mesh.VertexCount, mesh.TriangleCount)
+			}
+		})
+	}
+}
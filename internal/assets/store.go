@@ -0,0 +1,169 @@
+package assets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Asset files can come from somewhere other than a local directory, such as
+// object storage on deployments without a persistent disk. A Store lists and
+// opens them, and SyncStore mirrors them into a local cache directory that the
+// asset manager then loads from as usual. Only new and changed files are
+// downloaded, so restarts with a surviving cache are cheap.
+
+// storeIndexFile records the version of every file SyncStore downloaded into a cache directory
+const storeIndexFile = ".store-index.json"
+
+// StoreObject describes one file in a Store
+type StoreObject struct {
+	Path string `json:"path"` // Slash-separated path relative to the store root
+	Size int64  `json:"size"`
+	ETag string `json:"etag"` // Changes whenever the content does; empty if the store cannot tell
+}
+
+// Store holds asset files
+type Store interface {
+	// List returns every file in the store
+	List(ctx context.Context) ([]StoreObject, error)
+	// Open returns the content of the file at path, as listed by List
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+}
+
+// DirStore is a Store backed by a local directory
+type DirStore struct {
+	Dir string
+}
+
+// List returns every regular file below the directory. The ETag is made of the
+// size and modification time.
+func (d DirStore) List(ctx context.Context) ([]StoreObject, error) {
+	var objects []StoreObject
+	err := filepath.WalkDir(d.Dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(d.Dir, filePath)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, StoreObject{
+			Path: filepath.ToSlash(rel),
+			Size: info.Size(),
+			ETag: strconv.FormatInt(info.Size(), 16) + "-" + strconv.FormatInt(info.ModTime().UnixNano(), 16),
+		})
+		return ctx.Err()
+	})
+	return objects, err
+}
+
+// Open opens a file below the directory
+func (d DirStore) Open(ctx context.Context, objectPath string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.Dir, filepath.FromSlash(objectPath)))
+}
+
+// SyncStore makes dir a copy of the files in store, downloading only those that
+// are missing or whose ETag changed since the last sync. Files that were
+// downloaded before but are no longer in the store are removed; other files in
+// dir are left alone.
+func SyncStore(ctx context.Context, store Store, dir string) error {
+	objects, err := store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list asset store: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	previous := readStoreIndex(dir)
+	current := make(map[string]string, len(objects))
+	for _, object := range objects {
+		local, err := storeLocalPath(dir, object.Path)
+		if err != nil {
+			return err
+		}
+		current[object.Path] = object.ETag
+
+		if etag, ok := previous[object.Path]; ok && etag == object.ETag && object.ETag != "" {
+			if info, err := os.Stat(local); err == nil && info.Size() == object.Size {
+				continue
+			}
+		}
+		if err := downloadStoreObject(ctx, store, object.Path, local); err != nil {
+			return err
+		}
+	}
+
+	for objectPath := range previous {
+		if _, ok := current[objectPath]; !ok {
+			if local, err := storeLocalPath(dir, objectPath); err == nil {
+				os.Remove(local)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, storeIndexFile), data, 0o644)
+}
+
+// readStoreIndex returns the ETags recorded by the last sync into dir, or an
+// empty index if there was none
+func readStoreIndex(dir string) map[string]string {
+	index := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, storeIndexFile)); err == nil {
+		json.Unmarshal(data, &index)
+	}
+	return index
+}
+
+// storeLocalPath maps an object path into dir, rejecting paths that would escape it
+func storeLocalPath(dir, objectPath string) (string, error) {
+	clean := path.Clean("/" + objectPath)[1:]
+	if clean == "" || clean != strings.TrimPrefix(objectPath, "/") || clean == storeIndexFile {
+		return "", fmt.Errorf("invalid asset store path '%s'", objectPath)
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean)), nil
+}
+
+// downloadStoreObject copies one object to local. The file is written under a
+// temporary name and renamed, so an interrupted download never leaves a
+// truncated asset behind.
+func downloadStoreObject(ctx context.Context, store Store, objectPath, local string) error {
+	reader, err := store.Open(ctx, objectPath)
+	if err != nil {
+		return fmt.Errorf("failed to fetch '%s': %w", objectPath, err)
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(local), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to fetch '%s': %w", objectPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), local)
+}
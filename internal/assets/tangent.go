@@ -0,0 +1,108 @@
+package assets
+
+import (
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// ComputeTangents returns per-vertex tangents for tangent-space normal mapping,
+// four floats per vertex (x, y, z, w). Following Lengyel's method, each
+// triangle's tangent and bitangent are derived from its texture coordinate
+// gradients and accumulated per vertex, then the tangent is made orthogonal to
+// the vertex normal. w is +1 or -1 and gives the bitangent's handedness:
+//
+//	bitangent = cross(normal, tangent.xyz) * tangent.w
+//
+// Vertices without usable texture coordinates get an arbitrary tangent
+// perpendicular to their normal.
+func ComputeTangents(vertices, normals, texCoords []float32, indices []uint32) []float32 {
+	vertexCount := len(vertices) / 3
+	tan := make([]math3d.Vec3, vertexCount)
+	bitan := make([]math3d.Vec3, vertexCount)
+
+	position := func(i uint32) math3d.Vec3 {
+		return math3d.NewVec3(vertices[i*3], vertices[i*3+1], vertices[i*3+2])
+	}
+
+	for t := 0; t+2 < len(indices); t += 3 {
+		i0, i1, i2 := indices[t], indices[t+1], indices[t+2]
+		if int(max(i0, i1, i2)) >= vertexCount || int(max(i0, i1, i2))*2+1 >= len(texCoords) {
+			continue
+		}
+
+		e1 := position(i1).Sub(position(i0))
+		e2 := position(i2).Sub(position(i0))
+		du1, dv1 := texCoords[i1*2]-texCoords[i0*2], texCoords[i1*2+1]-texCoords[i0*2+1]
+		du2, dv2 := texCoords[i2*2]-texCoords[i0*2], texCoords[i2*2+1]-texCoords[i0*2+1]
+
+		det := du1*dv2 - du2*dv1
+		if det == 0 {
+			continue // Degenerate or unmapped triangle
+		}
+		r := 1 / det
+		sdir := e1.Scale(dv2 * r).Sub(e2.Scale(dv1 * r))
+		tdir := e2.Scale(du1 * r).Sub(e1.Scale(du2 * r))
+
+		for _, i := range [3]uint32{i0, i1, i2} {
+			tan[i] = tan[i].Add(sdir)
+			bitan[i] = bitan[i].Add(tdir)
+		}
+	}
+
+	tangents := make([]float32, vertexCount*4)
+	for i := 0; i < vertexCount; i++ {
+		var n math3d.Vec3
+		if i*3+2 < len(normals) {
+			n = math3d.NewVec3(normals[i*3], normals[i*3+1], normals[i*3+2])
+		}
+
+		// Gram-Schmidt orthogonalize
+		t := tan[i].Sub(n.Scale(n.Dot(tan[i]))).Normalize()
+		if t.LengthSquared() == 0 {
+			t = perpendicular(n)
+		}
+		w := float32(1)
+		if n.Cross(t).Dot(bitan[i]) < 0 {
+			w = -1
+		}
+		tangents[i*4], tangents[i*4+1], tangents[i*4+2], tangents[i*4+3] = t.X, t.Y, t.Z, w
+	}
+	return tangents
+}
+
+// perpendicular returns a unit vector perpendicular to n (the X axis if n is zero)
+func perpendicular(n math3d.Vec3) math3d.Vec3 {
+	axis := math3d.NewVec3(1, 0, 0)
+	if n.X*n.X > 0.81*n.LengthSquared() {
+		axis = math3d.NewVec3(0, 0, 1)
+	}
+	if p := axis.Sub(n.Scale(n.Dot(axis) / max(n.LengthSquared(), 1e-12))).Normalize(); p.LengthSquared() > 0 {
+		return p
+	}
+	return math3d.NewVec3(1, 0, 0)
+}
+
+// prepareMesh generates the tangents of a mesh unless it already has them and
+// computes its bounds. It needs no lock, so load workers call it ahead of storeMesh.
+func prepareMesh(mesh *Mesh) {
+	if len(mesh.Tangents) != len(mesh.Vertices)/3*4 {
+		mesh.Tangents = ComputeTangents(mesh.Vertices, mesh.Normals, mesh.TexCoords, mesh.Indices)
+	}
+	mesh.Bounds = ComputeBounds(mesh.Vertices)
+}
+
+// storeMesh registers mesh under name, generating tangents unless it already
+// has them and computing its bounds. A mesh without a material keeps the one
+// assigned to the mesh it replaces. Instances standing on the mesh are placed again.
+func (a *Assets) storeMesh(name string, mesh *Mesh) {
+	prepareMesh(mesh)
+	if previous, ok := a.meshes[name]; ok && mesh.Material == "" {
+		mesh.Material = previous.Material
+	}
+	a.meshes[name] = mesh
+	a.hashMesh(name)
+	a.precompressMesh(name)
+	a.rescatter(name)
+	if name == "terrain" {
+		a.ground = nil
+	}
+}
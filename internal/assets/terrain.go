@@ -0,0 +1,217 @@
+package assets
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MaxTerrainSegments bounds the grid resolution clients may request
+const MaxTerrainSegments = 1024
+
+// TerrainParams configures the procedural terrain generator. Heights are
+// fractal noise in [0, 1], optionally ridged and shaped into an island, then
+// scaled by Amplitude and offset by BaseHeight, and finally eroded.
+type TerrainParams struct {
+	Seed        int64   `json:"seed"`
+	Size        float32 `json:"size"`        // Width and depth in world units
+	Segments    int     `json:"segments"`    // Grid cells along each side
+	Amplitude   float32 `json:"amplitude"`   // Height difference between the lowest and highest possible point
+	BaseHeight  float32 `json:"baseHeight"`  // Height of the lowest possible point
+	Frequency   float32 `json:"frequency"`   // Noise features across the terrain in the first octave
+	Octaves     int     `json:"octaves"`     // Number of noise layers summed
+	Persistence float32 `json:"persistence"` // Amplitude multiplier per octave
+	Lacunarity  float32 `json:"lacunarity"`  // Frequency multiplier per octave
+	Ridging     float32 `json:"ridging"`     // 0 gives rolling hills, 1 sharp ridges
+	Falloff     float32 `json:"falloff"`     // 0 keeps the edges, 1 sinks them into an island
+
+	Erosion ErosionParams `json:"erosion"`
+}
+
+// DefaultTerrainParams returns the settings of the built-in terrain
+func DefaultTerrainParams() TerrainParams {
+	return TerrainParams{
+		Seed:        1,
+		Size:        50,
+		Segments:    32,
+		Amplitude:   5,
+		BaseHeight:  -5,
+		Frequency:   3,
+		Octaves:     4,
+		Persistence: 0.5,
+		Lacunarity:  2,
+		Ridging:     0,
+		Falloff:     0,
+		Erosion:     DefaultErosionParams(),
+	}
+}
+
+// Validate reports an error if the parameters cannot produce a terrain
+func (p TerrainParams) Validate() error {
+	for name, value := range map[string]float32{
+		"size": p.Size, "amplitude": p.Amplitude, "baseHeight": p.BaseHeight, "frequency": p.Frequency,
+		"persistence": p.Persistence, "lacunarity": p.Lacunarity, "ridging": p.Ridging, "falloff": p.Falloff,
+	} {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("terrain %s must be finite, got %v", name, value)
+		}
+	}
+	switch {
+	case p.Size <= 0:
+		return fmt.Errorf("terrain size must be positive, got %v", p.Size)
+	case p.Segments < 1 || p.Segments > MaxTerrainSegments:
+		return fmt.Errorf("terrain segments must be between 1 and %d, got %d", MaxTerrainSegments, p.Segments)
+	case p.Octaves < 1 || p.Octaves > 12:
+		return fmt.Errorf("terrain octaves must be between 1 and 12, got %d", p.Octaves)
+	case p.Frequency <= 0 || p.Lacunarity <= 0 || p.Persistence <= 0:
+		return fmt.Errorf("terrain frequency, lacunarity and persistence must be positive")
+	case p.Ridging < 0 || p.Ridging > 1 || p.Falloff < 0 || p.Falloff > 1:
+		return fmt.Errorf("terrain ridging and falloff must be between 0 and 1")
+	}
+	return p.Erosion.Validate()
+}
+
+// Height returns the terrain height at (u, v), both in [0, 1] across the terrain
+func (p TerrainParams) Height(noise *Noise2D, u, v float32) float32 {
+	var sum, total float64
+	amplitude, frequency := 1.0, float64(p.Frequency)
+	for octave := 0; octave < p.Octaves; octave++ {
+		n := noise.At(float64(u)*frequency, float64(v)*frequency)
+		smooth := n*0.5 + 0.5
+		ridge := (1 - math.Abs(n)) * (1 - math.Abs(n))
+		sum += amplitude * (smooth + (ridge-smooth)*float64(p.Ridging))
+		total += amplitude
+		amplitude *= float64(p.Persistence)
+		frequency *= float64(p.Lacunarity)
+	}
+	height := sum / total
+
+	// Sink the edges: 1 in the center, 0 at the middle of each edge and beyond
+	dx, dy := float64(u)*2-1, float64(v)*2-1
+	distance := math.Min(math.Sqrt(dx*dx+dy*dy), 1)
+	island := 1 - distance*distance*(3-2*distance)
+	height *= 1 + (island-1)*float64(p.Falloff)
+
+	return p.BaseHeight + float32(height)*p.Amplitude
+}
+
+// Heights returns the (Segments+1)² grid of terrain heights, row by row
+func (p TerrainParams) Heights() []float32 {
+	noise := NewNoise2D(p.Seed)
+	points := p.Segments + 1
+	heights := make([]float32, points*points)
+	for z := 0; z < points; z++ {
+		for x := 0; x < points; x++ {
+			heights[z*points+x] = p.Height(noise, float32(x)/float32(p.Segments), float32(z)/float32(p.Segments))
+		}
+	}
+	p.Erosion.Erode(heights, points, points, p.Seed)
+	return heights
+}
+
+// Mesh generates the terrain mesh, named "terrain"
+func (p TerrainParams) Mesh() *Mesh {
+	heights := p.Heights()
+	points := p.Segments + 1
+	return heightfieldMesh("terrain", points, points, p.Size, func(x, z int) float32 {
+		return heights[z*points+x]
+	})
+}
+
+// GenerateTerrain regenerates the terrain mesh from params and registers it
+func (a *Assets) GenerateTerrain(params TerrainParams) (*Mesh, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	mesh := params.Mesh()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.storeMesh("terrain", mesh)
+	a.terrain = params
+	return mesh, nil
+}
+
+// TerrainParams returns the settings the current terrain was generated with
+func (a *Assets) TerrainParams() TerrainParams {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.terrain
+}
+
+// Noise2D is seeded two-dimensional gradient (Perlin) noise
+type Noise2D struct {
+	perm [512]uint8
+}
+
+// NewNoise2D creates noise whose pattern is determined by seed
+func NewNoise2D(seed int64) *Noise2D {
+	n := &Noise2D{}
+	for i, p := range rand.New(rand.NewSource(seed)).Perm(256) {
+		n.perm[i] = uint8(p)
+		n.perm[i+256] = uint8(p)
+	}
+	return n
+}
+
+// At returns the noise value at (x, y), roughly in [-1, 1]. It repeats every 256 units.
+func (n *Noise2D) At(x, y float64) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	xi, yi := int(xf)&255, int(yf)&255
+	x, y = x-xf, y-yf
+
+	u, v := fade(x), fade(y)
+	a, b := int(n.perm[xi])+yi, int(n.perm[xi+1])+yi
+
+	return lerp(v,
+		lerp(u, gradient(n.perm[a], x, y), gradient(n.perm[b], x-1, y)),
+		lerp(u, gradient(n.perm[a+1], x, y-1), gradient(n.perm[b+1], x-1, y-1)),
+	)
+}
+
+// Tiled returns the noise value at (x, y) like At, but repeating every period
+// units (at most 256) so that images sampled from it tile seamlessly
+func (n *Noise2D) Tiled(x, y float64, period int) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	x0, y0 := ((int(xf)%period)+period)%period, ((int(yf)%period)+period)%period
+	x1, y1 := (x0+1)%period, (y0+1)%period
+	x, y = x-xf, y-yf
+
+	hash := func(xi, yi int) uint8 { return n.perm[int(n.perm[xi])+yi] }
+	u, v := fade(x), fade(y)
+	return lerp(v,
+		lerp(u, gradient(hash(x0, y0), x, y), gradient(hash(x1, y0), x-1, y)),
+		lerp(u, gradient(hash(x0, y1), x, y-1), gradient(hash(x1, y1), x-1, y-1)),
+	)
+}
+
+// fade is Perlin's quintic smoothstep 6t⁵ - 15t⁴ + 10t³
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// gradient returns the dot product of (x, y) with one of eight gradient directions
+func gradient(hash uint8, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
@@ -0,0 +1,182 @@
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Register the JPEG decoder for DecodeConfig
+	_ "image/png"  // Register the PNG decoder for DecodeConfig
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// textureExtensions lists the image files ScanTextures registers
+var textureExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".dds": true}
+
+// RegisterTextureFile registers a texture with dimensions and format read from
+// the image header of filePath (relative to the assets directory)
+func (a *Assets) RegisterTextureFile(name, filePath string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.registerTextureFile(name, filePath)
+}
+
+// registerTextureFile is RegisterTextureFile with the write lock already held
+func (a *Assets) registerTextureFile(name, filePath string) error {
+	if strings.EqualFold(filepath.Ext(filePath), ".dds") {
+		return a.registerDDSTexture(name, filePath)
+	}
+
+	file, err := a.openFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return fmt.Errorf("failed to read texture '%s': %w", name, err)
+	}
+
+	a.registerTexture(name, filePath, config.Width, config.Height, textureFormat(config.ColorModel))
+
+	// A .ktx2 file with the same base name is a compressed variant of the image
+	if err := a.attachKTX2Variant(a.textures[name]); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// ScanTextures registers every PNG, JPEG and DDS file in the assets directory that is
+// not registered yet, named after the file without its extension
+func (a *Assets) ScanTextures() error {
+	entries, err := fs.ReadDir(a.Files(), ".")
+	if err != nil {
+		return err
+	}
+
+	registered := make(map[string]bool, len(a.textures))
+	for _, texture := range a.textures {
+		registered[filepath.Clean(texture.FilePath)] = true
+	}
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !textureExtensions[ext] || registered[entry.Name()] {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, exists := a.textures[name]; exists {
+			continue
+		}
+		if err := a.RegisterTextureFile(name, entry.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textureFormat maps an image color model to the texture format reported to clients
+func textureFormat(model color.Model) string {
+	switch model {
+	case color.GrayModel, color.Gray16Model:
+		return "luminance"
+	case color.YCbCrModel, color.CMYKModel:
+		return "rgb"
+	default:
+		// RGBA, NRGBA and paletted images may all carry alpha
+		return "rgba"
+	}
+}
+
+// Mipmappable reports whether the texture can have a mipmap chain in WebGL 1,
+// which requires power-of-two dimensions
+func (t *Texture) Mipmappable() bool {
+	return isPowerOfTwo(t.Width) && isPowerOfTwo(t.Height)
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// ColorSpace tells clients whether a texture holds color or data
+type ColorSpace string
+
+// Supported texture color spaces
+const (
+	ColorSpaceSRGB   ColorSpace = "srgb"   // Color images, decoded to linear when sampled
+	ColorSpaceLinear ColorSpace = "linear" // Data such as normal and dudv maps, sampled as stored
+)
+
+// linearTextureHints are name fragments of textures that hold data rather than color
+var linearTextureHints = []string{"normal", "dudv", "height", "rough", "metal", "displace", "mask"}
+
+// defaultColorSpace guesses the color space of a texture from its name
+func defaultColorSpace(name string) ColorSpace {
+	lower := strings.ToLower(name)
+	for _, hint := range linearTextureHints {
+		if strings.Contains(lower, hint) {
+			return ColorSpaceLinear
+		}
+	}
+	return ColorSpaceSRGB
+}
+
+// SetTextureColorSpace overrides the color space guessed for a texture
+func (a *Assets) SetTextureColorSpace(name string, colorSpace ColorSpace) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	texture, exists := a.textures[name]
+	if !exists {
+		return fmt.Errorf("texture '%s' not found", name)
+	}
+	if colorSpace != ColorSpaceSRGB && colorSpace != ColorSpaceLinear {
+		return fmt.Errorf("unknown color space '%s'", colorSpace)
+	}
+	texture.ColorSpace = colorSpace
+	return nil
+}
+
+// ColorSpaceDefines returns GLSL preprocessor lines declaring each texture's color space,
+// e.g. "#define TEXTURE_DUDVMAP_LINEAR 1", sorted by texture name
+func (a *Assets) ColorSpaceDefines() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.colorSpaceDefines()
+}
+
+func (a *Assets) colorSpaceDefines() string {
+	names := make([]string, 0, len(a.textures))
+	for name := range a.textures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		suffix := "SRGB"
+		if a.textures[name].ColorSpace == ColorSpaceLinear {
+			suffix = "LINEAR"
+		}
+		fmt.Fprintf(&b, "#define TEXTURE_%s_%s 1\n", glslIdentifier(name), suffix)
+	}
+	return b.String()
+}
+
+// glslIdentifier upper-cases name and replaces characters not allowed in GLSL identifiers
+func glslIdentifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
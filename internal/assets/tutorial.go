@@ -0,0 +1,351 @@
+package assets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// The original webgl-water-tutorial build script exports meshes from Blender with
+// the blender-mesh crate and writes a HashMap<String, BlenderMesh> to meshes.bytes
+// using bincode 1.x defaults: little-endian fixed-width integers, u64 lengths for
+// strings/vectors/maps and a single tag byte for Option values.
+//
+// BlenderMesh keeps separate index lists for positions, normals and uvs and may
+// contain non-triangle faces, so meshes are combined into a single index buffer
+// and fan-triangulated when they are loaded.
+
+// blenderMesh mirrors the fields of blender-mesh's BlenderMesh in serialization order
+type blenderMesh struct {
+	vertexPositions        []float32
+	vertexPositionIndices  []uint16
+	numVerticesInEachFace  []uint8
+	vertexNormals          []float32
+	vertexNormalIndices    []uint16
+	vertexUVs              []float32
+	vertexUVIndices        []uint16
+	textureName            string
+	armatureName           string
+	vertexGroupIndices     []uint8
+	numGroupsForEachVertex []uint8
+	vertexGroupWeights     []float32
+}
+
+// bincodeReader decodes bincode 1.x values from a byte slice
+type bincodeReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bincodeReader) take(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, fmt.Errorf("unexpected end of data at offset %d", r.pos)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *bincodeReader) length() (int, error) {
+	b, err := r.take(8)
+	if err != nil {
+		return 0, err
+	}
+	n := binary.LittleEndian.Uint64(b)
+	if n > uint64(len(r.data)) {
+		return 0, fmt.Errorf("invalid length %d at offset %d", n, r.pos-8)
+	}
+	return int(n), nil
+}
+
+func (r *bincodeReader) option() (bool, error) {
+	b, err := r.take(1)
+	if err != nil {
+		return false, err
+	}
+	switch b[0] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid option tag %d at offset %d", b[0], r.pos-1)
+	}
+}
+
+func (r *bincodeReader) string() (string, error) {
+	n, err := r.length()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.take(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *bincodeReader) float32s() ([]float32, error) {
+	n, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.take(n * 4)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float32, n)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return values, nil
+}
+
+func (r *bincodeReader) uint16s() ([]uint16, error) {
+	n, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.take(n * 2)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]uint16, n)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return values, nil
+}
+
+func (r *bincodeReader) uint8s() ([]uint8, error) {
+	n, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.take(n)
+	if err != nil {
+		return nil, err
+	}
+	return append([]uint8(nil), b...), nil
+}
+
+// optional decodes an Option<T> by calling read only when the value is present
+func (r *bincodeReader) optional(read func() error) error {
+	present, err := r.option()
+	if err != nil || !present {
+		return err
+	}
+	return read()
+}
+
+// readBlenderMesh decodes a single BlenderMesh. Later blender-mesh releases append
+// a bounding box (two Vector3<f32>) which is skipped when withBoundingBox is set.
+func (r *bincodeReader) readBlenderMesh(withBoundingBox bool) (blenderMesh, error) {
+	var m blenderMesh
+	var err error
+
+	if m.vertexPositions, err = r.float32s(); err != nil {
+		return m, err
+	}
+	if m.vertexPositionIndices, err = r.uint16s(); err != nil {
+		return m, err
+	}
+	if m.numVerticesInEachFace, err = r.uint8s(); err != nil {
+		return m, err
+	}
+	if m.vertexNormals, err = r.float32s(); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.vertexNormalIndices, err = r.uint16s(); return }); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.vertexUVs, err = r.float32s(); return }); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.vertexUVIndices, err = r.uint16s(); return }); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.textureName, err = r.string(); return }); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.armatureName, err = r.string(); return }); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.vertexGroupIndices, err = r.uint8s(); return }); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.numGroupsForEachVertex, err = r.uint8s(); return }); err != nil {
+		return m, err
+	}
+	if err = r.optional(func() (err error) { m.vertexGroupWeights, err = r.float32s(); return }); err != nil {
+		return m, err
+	}
+	if withBoundingBox {
+		if _, err = r.take(6 * 4); err != nil {
+			return m, err
+		}
+	}
+
+	return m, nil
+}
+
+// decodeTutorialMeshes decodes the bincode HashMap<String, BlenderMesh> written by the tutorial build
+func decodeTutorialMeshes(data []byte) (map[string]blenderMesh, error) {
+	var lastErr error
+
+	for _, withBoundingBox := range []bool{false, true} {
+		r := &bincodeReader{data: data}
+		meshes, err := r.readBlenderMeshMap(withBoundingBox)
+		if err == nil && r.pos != len(r.data) {
+			err = fmt.Errorf("%d trailing bytes", len(r.data)-r.pos)
+		}
+		if err == nil {
+			return meshes, nil
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("invalid tutorial meshes.bytes: %w", lastErr)
+}
+
+func (r *bincodeReader) readBlenderMeshMap(withBoundingBox bool) (map[string]blenderMesh, error) {
+	count, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+
+	meshes := make(map[string]blenderMesh, count)
+	for i := 0; i < count; i++ {
+		name, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		mesh, err := r.readBlenderMesh(withBoundingBox)
+		if err != nil {
+			return nil, fmt.Errorf("mesh '%s': %w", name, err)
+		}
+		meshes[name] = mesh
+	}
+
+	return meshes, nil
+}
+
+// toMesh combines the separate position/normal/uv indices into a single index buffer
+// and fan-triangulates every face
+func (b blenderMesh) toMesh(name string) (Mesh, error) {
+	type vertexKey struct {
+		position, normal, uv int
+	}
+
+	var (
+		vertices  []float32
+		normals   []float32
+		texCoords []float32
+		indices   []uint32
+	)
+	combined := make(map[vertexKey]uint32)
+	hasUVs := b.vertexUVs != nil && b.vertexUVIndices != nil
+
+	// vertexIndex returns the combined index of the i-th face corner, creating it if needed
+	vertexIndex := func(i int) (uint32, error) {
+		key := vertexKey{position: int(b.vertexPositionIndices[i]), normal: i, uv: -1}
+		if b.vertexNormalIndices != nil {
+			key.normal = int(b.vertexNormalIndices[i])
+		}
+		if hasUVs {
+			key.uv = int(b.vertexUVIndices[i])
+		}
+		if index, ok := combined[key]; ok {
+			return index, nil
+		}
+
+		if (key.position+1)*3 > len(b.vertexPositions) || (key.normal+1)*3 > len(b.vertexNormals) {
+			return 0, fmt.Errorf("vertex index out of range")
+		}
+
+		vertices = append(vertices, b.vertexPositions[key.position*3:key.position*3+3]...)
+		normals = append(normals, b.vertexNormals[key.normal*3:key.normal*3+3]...)
+		if hasUVs {
+			if (key.uv+1)*2 > len(b.vertexUVs) {
+				return 0, fmt.Errorf("uv index out of range")
+			}
+			texCoords = append(texCoords, b.vertexUVs[key.uv*2:key.uv*2+2]...)
+		} else {
+			texCoords = append(texCoords, 0, 0)
+		}
+
+		index := uint32(len(combined))
+		combined[key] = index
+		return index, nil
+	}
+
+	if b.vertexNormalIndices != nil && len(b.vertexNormalIndices) != len(b.vertexPositionIndices) {
+		return Mesh{}, fmt.Errorf("mesh '%s': normal and position index counts differ", name)
+	}
+	if hasUVs && len(b.vertexUVIndices) != len(b.vertexPositionIndices) {
+		return Mesh{}, fmt.Errorf("mesh '%s': uv and position index counts differ", name)
+	}
+
+	start := 0
+	for _, count := range b.numVerticesInEachFace {
+		end := start + int(count)
+		if end > len(b.vertexPositionIndices) {
+			return Mesh{}, fmt.Errorf("mesh '%s': face exceeds index buffer", name)
+		}
+
+		for corner := start + 1; corner+1 < end; corner++ {
+			for _, i := range []int{start, corner, corner + 1} {
+				index, err := vertexIndex(i)
+				if err != nil {
+					return Mesh{}, fmt.Errorf("mesh '%s': %w", name, err)
+				}
+				indices = append(indices, index)
+			}
+		}
+		start = end
+	}
+
+	return Mesh{
+		Name:          name,
+		Vertices:      vertices,
+		Normals:       normals,
+		TexCoords:     texCoords,
+		Indices:       indices,
+		IndexWidth:    IndexWidthFor(len(vertices) / 3),
+		VertexCount:   len(vertices) / 3,
+		TriangleCount: len(indices) / 3,
+	}, nil
+}
+
+// loadTutorialMeshes loads a meshes.bytes file produced by the original Rust tutorial build
+func loadTutorialMeshes(path string) (MeshData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MeshData{}, err
+	}
+
+	blenderMeshes, err := decodeTutorialMeshes(data)
+	if err != nil {
+		return MeshData{}, err
+	}
+
+	// Sort names so the resulting mesh order is stable
+	names := make([]string, 0, len(blenderMeshes))
+	for name := range blenderMeshes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var meshData MeshData
+	for _, name := range names {
+		mesh, err := blenderMeshes[name].toMesh(name)
+		if err != nil {
+			return MeshData{}, err
+		}
+		meshData.Meshes = append(meshData.Meshes, mesh)
+	}
+
+	return meshData, nil
+}
@@ -0,0 +1,112 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// bincodeWriter encodes the bincode 1.x values bincodeReader decodes
+type bincodeWriter struct {
+	bytes.Buffer
+}
+
+func (w *bincodeWriter) length(n uint64) {
+	binary.Write(&w.Buffer, binary.LittleEndian, n)
+}
+
+func (w *bincodeWriter) string(s string) {
+	w.length(uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (w *bincodeWriter) float32s(values ...float32) {
+	w.length(uint64(len(values)))
+	for _, v := range values {
+		binary.Write(&w.Buffer, binary.LittleEndian, math.Float32bits(v))
+	}
+}
+
+func (w *bincodeWriter) uint16s(values ...uint16) {
+	w.length(uint64(len(values)))
+	binary.Write(&w.Buffer, binary.LittleEndian, values)
+}
+
+func (w *bincodeWriter) uint8s(values ...uint8) {
+	w.length(uint64(len(values)))
+	w.Write(values)
+}
+
+// tutorialQuad encodes a map holding one quad with a shared normal and no UVs
+func tutorialQuad() []byte {
+	var w bincodeWriter
+	w.length(1)
+	w.string("quad")
+	w.float32s(0, 0, 0, 1, 0, 0, 1, 0, 1, 0, 0, 1)
+	w.uint16s(0, 1, 2, 3)
+	w.uint8s(4)
+	w.float32s(0, 1, 0)
+	w.WriteByte(1) // Some(vertex normal indices)
+	w.uint16s(0, 0, 0, 0)
+	for i := 0; i < 7; i++ {
+		w.WriteByte(0) // None for the UVs, names and vertex groups
+	}
+	return w.Bytes()
+}
+
+func TestDecodeTutorialMeshes(t *testing.T) {
+	valid := tutorialQuad()
+
+	oversized := bytes.Clone(valid)
+	binary.LittleEndian.PutUint64(oversized, 1<<40)
+
+	badTag := bytes.Clone(valid)
+	badTag[len(badTag)-1] = 7
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"empty", nil, true},
+		{"truncated", valid[:len(valid)-5], true},
+		{"oversized length", oversized, true},
+		{"bad option tag", badTag, true},
+		{"trailing bytes", append(bytes.Clone(valid), 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meshes, err := decodeTutorialMeshes(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			mesh, err := meshes["quad"].toMesh("quad")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mesh.VertexCount != 4 || mesh.TriangleCount != 2 {
+				t.Errorf("got %d vertices and %d triangles, want 4 and 2", mesh.VertexCount, mesh.TriangleCount)
+			}
+		})
+	}
+}
+
+func TestTutorialMeshOutOfRangeIndex(t *testing.T) {
+	meshes, err := decodeTutorialMeshes(tutorialQuad())
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad := meshes["quad"]
+	quad.vertexPositionIndices = []uint16{0, 1, 2, 9}
+	if _, err := quad.toMesh("quad"); err == nil {
+		t.Error("expected an error for a position index past the vertices")
+	}
+}
@@ -0,0 +1,181 @@
+package assets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Kinds of assets reported by Watch
+const (
+	AssetKindMesh    = "mesh"
+	AssetKindTexture = "texture"
+	AssetKindScene   = "scene"
+	AssetKindShader  = "shader"
+
+	// AssetKindInstances reports changed scatter settings; it is not reported by Watch
+	AssetKindInstances = "instances"
+	// AssetKindMaterial reports a changed material or material assignment; it is not reported by Watch
+	AssetKindMaterial = "material"
+	// AssetKindAudio reports a registered, changed or removed sound; it is not reported by Watch
+	AssetKindAudio = "audio"
+	// AssetKindCollection reports a newly activated collection, after which every asset may differ
+	AssetKindCollection = "collection"
+)
+
+// watchSettleDelay is how long a file must stay unchanged before it is reloaded.
+// Editors often save in several writes, and a half-written image fails to decode.
+const watchSettleDelay = 150 * time.Millisecond
+
+// shaderExtensions lists the shader sources Watch reports changes for
+var shaderExtensions = map[string]bool{".glsl": true, ".vert": true, ".frag": true}
+
+// AssetChange describes an asset that was reloaded because its file changed
+type AssetChange struct {
+	Kind string `json:"kind"` // One of the AssetKind constants
+	Name string `json:"name"`
+}
+
+// Watch reloads meshes, textures and scenes whose files change in the assets
+// directory until ctx is done. Shaders are read from disk on every request, so
+// changes in shaderDir (if not empty) are only reported. onChange is called
+// after every reload, with a non-nil error if the new file could not be loaded;
+// the previous version of the asset is kept in that case.
+func (a *Assets) Watch(ctx context.Context, shaderDir string, onChange func(AssetChange, error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create asset watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(a.basePath); err != nil {
+		return fmt.Errorf("failed to watch '%s': %w", a.basePath, err)
+	}
+	if shaderDir != "" {
+		if err := watcher.Add(shaderDir); err != nil {
+			return fmt.Errorf("failed to watch '%s': %w", shaderDir, err)
+		}
+	}
+
+	pending := make(map[string]bool)
+	settle := time.NewTimer(watchSettleDelay)
+	settle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
+				pending[event.Name] = true
+				settle.Reset(watchSettleDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			onChange(AssetChange{}, err)
+		case <-settle.C:
+			for path := range pending {
+				if change, ok, err := a.reloadFile(path, shaderDir); ok {
+					onChange(change, err)
+				}
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// reloadFile reloads the asset stored in path. It reports false for files that
+// are not assets.
+func (a *Assets) reloadFile(path, shaderDir string) (AssetChange, bool, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if shaderDir != "" && filepath.Clean(filepath.Dir(path)) == filepath.Clean(shaderDir) {
+		if !shaderExtensions[ext] {
+			return AssetChange{}, false, nil
+		}
+		var err error
+		if filepath.Clean(shaderDir) == filepath.Clean(a.shaderDir) {
+			a.mu.Lock()
+			err = a.hashShader(filepath.Base(path))
+			if err == nil {
+				err = a.precompressShader(filepath.Base(path))
+			}
+			a.mu.Unlock()
+		}
+		return AssetChange{Kind: AssetKindShader, Name: name}, true, err
+	}
+
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return AssetChange{}, false, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case textureExtensions[ext]:
+		return a.reloadTexture(filepath.Base(path), name)
+	case ext == ".ktx2":
+		// A new or updated compressed variant replaces the variant of its image's texture
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		for _, texture := range a.textures {
+			if strings.TrimSuffix(texture.FilePath, filepath.Ext(texture.FilePath)) == base {
+				return a.reloadTexture(texture.FilePath, texture.Name)
+			}
+		}
+		return AssetChange{}, false, nil
+	case ext == ".stl":
+		_, err := a.LoadSTL(name, path)
+		return AssetChange{Kind: AssetKindMesh, Name: name}, true, err
+	case ext == ".gltf", ext == ".glb":
+		_, err := a.LoadGLTF(name, path)
+		return AssetChange{Kind: AssetKindScene, Name: name}, true, err
+	case ext == ".wgscene":
+		_, err := a.LoadScene(name, path)
+		return AssetChange{Kind: AssetKindScene, Name: name}, true, err
+	default:
+		return AssetChange{}, false, nil
+	}
+}
+
+// reloadTexture re-registers the texture stored in filePath and rebuilds its mipmaps.
+// A texture already registered for the file keeps its name and color space.
+func (a *Assets) reloadTexture(filePath, name string) (AssetChange, bool, error) {
+	var colorSpace ColorSpace
+	for _, texture := range a.textures {
+		if filepath.Clean(texture.FilePath) == filepath.Clean(filePath) {
+			name, colorSpace = texture.Name, texture.ColorSpace
+			break
+		}
+	}
+
+	change := AssetChange{Kind: AssetKindTexture, Name: name}
+	previous := a.textures[name]
+	if err := a.registerTextureFile(name, filePath); err != nil {
+		if previous != nil {
+			a.textures[name] = previous
+		} else {
+			delete(a.textures, name)
+		}
+		return change, true, err
+	}
+	if colorSpace != "" {
+		a.textures[name].ColorSpace = colorSpace
+	}
+	a.dropResized(name)
+	if err := a.hashTexture(name); err != nil {
+		return change, true, err
+	}
+	return change, true, a.generateMipmaps(name, a.textures[name])
+}
@@ -0,0 +1,190 @@
+package assets
+
+import (
+	"fmt"
+	"math"
+)
+
+// WaterTopology selects how the water mesh distributes its vertices
+type WaterTopology string
+
+// Supported water mesh topologies
+const (
+	// WaterTopologyGrid is a uniform square grid centered on the origin
+	WaterTopologyGrid WaterTopology = "grid"
+	// WaterTopologyRadial is a disc of concentric rings whose spacing grows with
+	// the distance from the center. Clients keep its center under the camera.
+	WaterTopologyRadial WaterTopology = "radial"
+	// WaterTopologyProjected is a uniform grid in screen space. Vertices hold
+	// normalized device coordinates in X and Z; the vertex shader casts a ray
+	// through each and places it where the ray meets the water, so vertices are
+	// dense near the camera and sparse towards the horizon.
+	WaterTopologyProjected WaterTopology = "projected"
+)
+
+// Valid reports whether t is a supported topology
+func (t WaterTopology) Valid() bool {
+	switch t {
+	case WaterTopologyGrid, WaterTopologyRadial, WaterTopologyProjected:
+		return true
+	}
+	return false
+}
+
+// Water mesh resolution limits
+const (
+	MaxWaterSegments = 1024
+	MaxWaterRings    = 512
+	MaxWaterSectors  = 1024
+)
+
+// WaterMeshParams configures the water mesh, named "water_plane". Size applies
+// to the grid and radial topologies, Segments to the grid and projected ones,
+// and Rings, Sectors and Falloff to the radial one.
+type WaterMeshParams struct {
+	Topology WaterTopology `json:"topology"`
+	Size     float32       `json:"size"`     // Width of the grid or diameter of the disc in world units
+	Segments int           `json:"segments"` // Cells along each side of the grid
+	Rings    int           `json:"rings"`    // Rings around the center of the disc
+	Sectors  int           `json:"sectors"`  // Vertices on every ring
+	Falloff  float32       `json:"falloff"`  // 1 spaces rings evenly; larger values pack them towards the center
+}
+
+// DefaultWaterMeshParams returns the settings of the built-in water plane
+func DefaultWaterMeshParams() WaterMeshParams {
+	return WaterMeshParams{
+		Topology: WaterTopologyGrid,
+		Size:     20,
+		Segments: 64,
+		Rings:    64,
+		Sectors:  128,
+		Falloff:  2,
+	}
+}
+
+// Validate reports an error if the parameters cannot produce a water mesh
+func (p WaterMeshParams) Validate() error {
+	if !p.Topology.Valid() {
+		return fmt.Errorf("unknown water topology '%s'", p.Topology)
+	}
+	if math.IsNaN(float64(p.Size)) || math.IsInf(float64(p.Size), 0) ||
+		math.IsNaN(float64(p.Falloff)) || math.IsInf(float64(p.Falloff), 0) {
+		return fmt.Errorf("water mesh size and falloff must be finite")
+	}
+
+	switch p.Topology {
+	case WaterTopologyGrid, WaterTopologyProjected:
+		if p.Segments < 1 || p.Segments > MaxWaterSegments {
+			return fmt.Errorf("water segments must be between 1 and %d, got %d", MaxWaterSegments, p.Segments)
+		}
+	case WaterTopologyRadial:
+		switch {
+		case p.Rings < 1 || p.Rings > MaxWaterRings:
+			return fmt.Errorf("water rings must be between 1 and %d, got %d", MaxWaterRings, p.Rings)
+		case p.Sectors < 3 || p.Sectors > MaxWaterSectors:
+			return fmt.Errorf("water sectors must be between 3 and %d, got %d", MaxWaterSectors, p.Sectors)
+		case p.Falloff < 1:
+			return fmt.Errorf("water falloff must be at least 1, got %v", p.Falloff)
+		}
+	}
+	if p.Topology != WaterTopologyProjected && p.Size <= 0 {
+		return fmt.Errorf("water mesh size must be positive, got %v", p.Size)
+	}
+	return nil
+}
+
+// Mesh generates the water mesh
+func (p WaterMeshParams) Mesh() *Mesh {
+	switch p.Topology {
+	case WaterTopologyRadial:
+		return radialWaterMesh(p.Size/2, p.Rings, p.Sectors, p.Falloff)
+	case WaterTopologyProjected:
+		// The shader works in NDC, where the screen spans -1 to 1
+		return gridWaterMesh(2, p.Segments)
+	default:
+		return gridWaterMesh(p.Size, p.Segments)
+	}
+}
+
+// gridWaterMesh builds a flat grid of segments×segments cells, size wide
+func gridWaterMesh(size float32, segments int) *Mesh {
+	points := segments + 1
+	return heightfieldMesh("water_plane", points, points, size, func(x, z int) float32 {
+		return 0
+	})
+}
+
+// radialWaterMesh builds a flat disc: a center vertex surrounded by rings of
+// sectors vertices. Ring i lies at radius·(i/rings)^falloff.
+func radialWaterMesh(radius float32, rings, sectors int, falloff float32) *Mesh {
+	vertexCount := 1 + rings*sectors
+	mesh := &Mesh{
+		Name:      "water_plane",
+		Vertices:  make([]float32, 0, vertexCount*3),
+		Normals:   make([]float32, 0, vertexCount*3),
+		TexCoords: make([]float32, 0, vertexCount*2),
+	}
+	addVertex := func(x, z float32) {
+		mesh.Vertices = append(mesh.Vertices, x, 0, z)
+		mesh.Normals = append(mesh.Normals, 0, 1, 0)
+		mesh.TexCoords = append(mesh.TexCoords, x/(2*radius)+0.5, z/(2*radius)+0.5)
+	}
+
+	addVertex(0, 0)
+	for ring := 1; ring <= rings; ring++ {
+		r := radius * float32(math.Pow(float64(ring)/float64(rings), float64(falloff)))
+		for sector := 0; sector < sectors; sector++ {
+			angle := 2 * math.Pi * float64(sector) / float64(sectors)
+			addVertex(r*float32(math.Cos(angle)), r*float32(math.Sin(angle)))
+		}
+	}
+
+	// Vertex of a sector on a ring; ring 0 is the center
+	vertex := func(ring, sector int) uint32 {
+		if ring == 0 {
+			return 0
+		}
+		return uint32(1 + (ring-1)*sectors + sector%sectors)
+	}
+
+	// Counter-clockwise seen from above, so the faces point up like the grid's
+	for sector := 0; sector < sectors; sector++ {
+		mesh.Indices = append(mesh.Indices, 0, vertex(1, sector+1), vertex(1, sector))
+	}
+	for ring := 1; ring < rings; ring++ {
+		for sector := 0; sector < sectors; sector++ {
+			inner, innerNext := vertex(ring, sector), vertex(ring, sector+1)
+			outer, outerNext := vertex(ring+1, sector), vertex(ring+1, sector+1)
+			mesh.Indices = append(mesh.Indices,
+				inner, innerNext, outer,
+				innerNext, outerNext, outer,
+			)
+		}
+	}
+
+	mesh.VertexCount = vertexCount
+	mesh.IndexWidth = IndexWidthFor(vertexCount)
+	mesh.TriangleCount = len(mesh.Indices) / 3
+	return mesh
+}
+
+// WaterMeshParams returns the settings of the current water mesh
+func (a *Assets) WaterMeshParams() WaterMeshParams {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.water
+}
+
+// GenerateWaterMesh validates params and replaces the water mesh
+func (a *Assets) GenerateWaterMesh(params WaterMeshParams) (*Mesh, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	mesh := params.Mesh()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.water = params
+	a.storeMesh(mesh.Name, mesh)
+	return mesh, nil
+}
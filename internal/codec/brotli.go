@@ -0,0 +1,35 @@
+package codec
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// NameBrotli is the name and HTTP content coding of the brotli codec
+const NameBrotli = "br"
+
+// Brotli returns a brotli codec compressing at level (brotli.BestSpeed to
+// brotli.BestCompression, or brotli.DefaultCompression)
+func Brotli(level int) Codec {
+	return brotliCodec{level: level}
+}
+
+type brotliCodec struct {
+	level int
+}
+
+func (brotliCodec) Name() string      { return NameBrotli }
+func (brotliCodec) Extension() string { return ".br" }
+
+// Magic returns nil, as brotli streams have no signature. Detect cannot
+// recognize them, so they need the codec named, such as by Content-Encoding.
+func (brotliCodec) Magic() []byte { return nil }
+
+func (c brotliCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return brotli.NewWriterLevel(w, c.level), nil
+}
+
+func (brotliCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(brotli.NewReader(r)), nil
+}
@@ -0,0 +1,209 @@
+// Package codec abstracts the compression applied to HTTP responses, backup
+// archives and checkpoint files behind a common interface.
+//
+// gzip, zstd, brotli and none are built in. Other codecs can be added with
+// Register and are then available everywhere a codec is selected by name.
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Names of the built-in codecs
+const (
+	NameNone = "none"
+	NameGzip = "gzip"
+)
+
+// Codec compresses and decompresses byte streams
+type Codec interface {
+	// Name identifies the codec in configuration and is its HTTP content coding
+	Name() string
+	// Extension is appended to file names holding encoded data (".gz"), "" for none
+	Extension() string
+	// Magic returns the bytes encoded data starts with, or nil if it cannot be recognized
+	Magic() []byte
+	// NewWriter returns a writer compressing into w. Close flushes it but does not close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader decompressing r
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{
+		NameNone:   None,
+		NameGzip:   Gzip(gzip.DefaultCompression),
+		NameZstd:   Zstd(zstd.SpeedDefault),
+		NameBrotli: Brotli(brotli.DefaultCompression),
+	}
+)
+
+// Register makes c available by its name, replacing any codec with the same name
+func Register(c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.Name()] = c
+}
+
+// Lookup returns the codec registered as name. The HTTP content coding
+// "identity" is accepted for none.
+func Lookup(name string) (Codec, error) {
+	if name == "identity" {
+		return None, nil
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if c, ok := registry[name]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown codec '%s' (available: %s)", name, strings.Join(names(), ", "))
+}
+
+// Names returns the names of all registered codecs in sorted order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return names()
+}
+
+func names() []string {
+	result := make([]string, 0, len(registry))
+	for name := range registry {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Detect returns the codec whose magic bytes r starts with, or fallback if none
+// matches. Nothing is consumed from r.
+func Detect(r *bufio.Reader, fallback Codec) (Codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, name := range names() {
+		c := registry[name]
+		magic := c.Magic()
+		if len(magic) == 0 {
+			continue
+		}
+		prefix, err := r.Peek(len(magic))
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if bytes.Equal(prefix, magic) {
+			return c, nil
+		}
+	}
+	return fallback, nil
+}
+
+// Negotiate picks the codec to encode a response with from the Accept-Encoding
+// header. offered is in order of preference and breaks ties between equal
+// q-values. None is returned if no offered codec is acceptable.
+func Negotiate(acceptEncoding string, offered []Codec) Codec {
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, q := parseCoding(part)
+		switch coding {
+		case "":
+		case "*":
+			wildcard = q
+		default:
+			weights[coding] = q
+		}
+	}
+
+	best, bestQ := Codec(None), 0.0
+	for _, c := range offered {
+		if c.Name() == NameNone {
+			continue
+		}
+		q, ok := weights[c.Name()]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+	return best
+}
+
+// parseCoding splits an Accept-Encoding element such as "gzip;q=0.8" into its
+// lowercase coding and q-value
+func parseCoding(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	coding := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, param := range params[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.ToLower(strings.TrimSpace(key)) != "q" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return "", 0
+		}
+		q = parsed
+	}
+	return coding, q
+}
+
+// None passes data through unchanged
+var None Codec = noneCodec{}
+
+type noneCodec struct{}
+
+func (noneCodec) Name() string      { return NameNone }
+func (noneCodec) Extension() string { return "" }
+func (noneCodec) Magic() []byte     { return nil }
+
+func (noneCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (noneCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Gzip returns a gzip codec compressing at level (gzip.BestSpeed to
+// gzip.BestCompression, or gzip.DefaultCompression)
+func Gzip(level int) Codec {
+	return gzipCodec{level: level}
+}
+
+type gzipCodec struct {
+	level int
+}
+
+func (gzipCodec) Name() string      { return NameGzip }
+func (gzipCodec) Extension() string { return ".gz" }
+func (gzipCodec) Magic() []byte     { return []byte{0x1f, 0x8b} }
+
+func (c gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
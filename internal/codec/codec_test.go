@@ -0,0 +1,164 @@
+package codec_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/ku3ppi/webgl-water/internal/assets"
+	"github.com/ku3ppi/webgl-water/internal/codec"
+)
+
+// waterGridPayloads returns water grid meshes at the default and a dense
+// resolution, encoded as JSON and in the binary mesh format
+func waterGridPayloads(tb testing.TB) []struct {
+	name string
+	data []byte
+} {
+	var payloads []struct {
+		name string
+		data []byte
+	}
+	for _, segments := range []struct {
+		name  string
+		count int
+	}{{"default", assets.DefaultWaterMeshParams().Segments}, {"dense", 256}} {
+		params := assets.DefaultWaterMeshParams()
+		params.Segments = segments.count
+		mesh := params.Mesh()
+
+		jsonData, err := json.Marshal(mesh)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		binaryData, err := assets.MarshalMeshBinary(mesh)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		payloads = append(payloads,
+			struct {
+				name string
+				data []byte
+			}{segments.name + "-json", jsonData},
+			struct {
+				name string
+				data []byte
+			}{segments.name + "-binary", binaryData},
+		)
+	}
+	return payloads
+}
+
+// encode compresses data with c
+func encode(tb testing.TB, c codec.Codec, data []byte) []byte {
+	var encoded bytes.Buffer
+	w, err := c.NewWriter(&encoded)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		tb.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	payloads := waterGridPayloads(t)
+	for _, name := range codec.Names() {
+		c, err := codec.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, payload := range payloads {
+			t.Run(name+"/"+payload.name, func(t *testing.T) {
+				encoded := encode(t, c, payload.data)
+
+				r, err := c.NewReader(bytes.NewReader(encoded))
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := r.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(decoded, payload.data) {
+					t.Fatalf("decoded %d bytes, want the %d encoded", len(decoded), len(payload.data))
+				}
+
+				// Codecs with magic bytes are recognized without being named
+				if c.Magic() != nil {
+					detected, err := codec.Detect(bufio.NewReader(bytes.NewReader(encoded)), codec.None)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if detected.Name() != name {
+						t.Errorf("detected %s, want %s", detected.Name(), name)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offered := []codec.Codec{}
+	for _, name := range []string{codec.NameBrotli, codec.NameZstd, codec.NameGzip} {
+		c, err := codec.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offered = append(offered, c)
+	}
+
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"gzip, deflate, br, zstd", codec.NameBrotli},
+		{"gzip, zstd", codec.NameZstd},
+		{"br;q=0.5, zstd;q=0.8", codec.NameZstd},
+		{"gzip", codec.NameGzip},
+		{"*", codec.NameBrotli},
+		{"br;q=0, *;q=0.1", codec.NameZstd},
+		{"deflate", codec.NameNone},
+		{"", codec.NameNone},
+	}
+	for _, tt := range tests {
+		if got := codec.Negotiate(tt.acceptEncoding, offered).Name(); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkEncode compresses the water grid payloads with every registered
+// codec. Besides the encode time it reports the compressed size relative to
+// the input as "ratio".
+func BenchmarkEncode(b *testing.B) {
+	payloads := waterGridPayloads(b)
+	for _, name := range codec.Names() {
+		c, err := codec.Lookup(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, payload := range payloads {
+			b.Run(name+"/"+payload.name, func(b *testing.B) {
+				size := len(encode(b, c, payload.data))
+				b.SetBytes(int64(len(payload.data)))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					encode(b, c, payload.data)
+				}
+				b.ReportMetric(float64(size)/float64(len(payload.data)), "ratio")
+			})
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// NameZstd is the name and HTTP content coding of the zstd codec
+const NameZstd = "zstd"
+
+// Zstd returns a zstd codec compressing at level (zstd.SpeedFastest to
+// zstd.SpeedBestCompression, or zstd.SpeedDefault)
+func Zstd(level zstd.EncoderLevel) Codec {
+	return zstdCodec{level: level}
+}
+
+type zstdCodec struct {
+	level zstd.EncoderLevel
+}
+
+func (zstdCodec) Name() string      { return NameZstd }
+func (zstdCodec) Extension() string { return ".zst" }
+func (zstdCodec) Magic() []byte     { return []byte{0x28, 0xb5, 0x2f, 0xfd} }
+
+// Every writer and reader serves a single stream, so they do without the
+// goroutines zstd would otherwise start per CPU
+func (c zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(c.level), zstd.WithEncoderConcurrency(1))
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
@@ -0,0 +1,35 @@
+package math3d
+
+import (
+	"math"
+)
+
+// Degrees converts an angle from radians to degrees
+func Degrees(radians float32) float32 {
+	return radians * (180 / math.Pi)
+}
+
+// Radians converts an angle from degrees to radians
+func Radians(degrees float32) float32 {
+	return degrees * (math.Pi / 180)
+}
+
+// WrapAngle wraps an angle in radians into the range [-π, π]
+func WrapAngle(angle float32) float32 {
+	wrapped := math.Mod(float64(angle)+math.Pi, 2*math.Pi)
+	if wrapped < 0 {
+		wrapped += 2 * math.Pi
+	}
+	return float32(wrapped - math.Pi)
+}
+
+// ShortestAngleDelta returns the signed difference to - from in radians, taking the short way around the circle.
+// The result is in [-π, π], so from + delta reaches to without crossing the wrap point the long way.
+func ShortestAngleDelta(from, to float32) float32 {
+	return WrapAngle(to - from)
+}
+
+// LerpAngle interpolates between two angles in radians along the shortest arc
+func LerpAngle(from, to, t float32) float32 {
+	return from + ShortestAngleDelta(from, to)*t
+}
@@ -0,0 +1,53 @@
+package math3d
+
+// Epsilon is the default tolerance for approximate float comparisons
+const Epsilon float32 = 1e-6
+
+// ApproxEqualFloat reports whether a and b differ by at most epsilon
+func ApproxEqualFloat(a, b, epsilon float32) bool {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= epsilon
+}
+
+// ApproxEqual reports whether every component differs by at most epsilon
+func (v Vec2) ApproxEqual(other Vec2, epsilon float32) bool {
+	return ApproxEqualFloat(v.X, other.X, epsilon) &&
+		ApproxEqualFloat(v.Y, other.Y, epsilon)
+}
+
+// ApproxEqual reports whether every component differs by at most epsilon
+func (v Vec3) ApproxEqual(other Vec3, epsilon float32) bool {
+	return ApproxEqualFloat(v.X, other.X, epsilon) &&
+		ApproxEqualFloat(v.Y, other.Y, epsilon) &&
+		ApproxEqualFloat(v.Z, other.Z, epsilon)
+}
+
+// ApproxEqual reports whether every component differs by at most epsilon
+func (v Vec4) ApproxEqual(other Vec4, epsilon float32) bool {
+	return ApproxEqualFloat(v.X, other.X, epsilon) &&
+		ApproxEqualFloat(v.Y, other.Y, epsilon) &&
+		ApproxEqualFloat(v.Z, other.Z, epsilon) &&
+		ApproxEqualFloat(v.W, other.W, epsilon)
+}
+
+// ApproxEqual reports whether every component differs by at most epsilon.
+// Note that q and -q represent the same rotation but do not compare equal here.
+func (q Quat) ApproxEqual(other Quat, epsilon float32) bool {
+	return ApproxEqualFloat(q.X, other.X, epsilon) &&
+		ApproxEqualFloat(q.Y, other.Y, epsilon) &&
+		ApproxEqualFloat(q.Z, other.Z, epsilon) &&
+		ApproxEqualFloat(q.W, other.W, epsilon)
+}
+
+// ApproxEqual reports whether every element differs by at most epsilon
+func (m Mat4) ApproxEqual(other Mat4, epsilon float32) bool {
+	for i := range m {
+		if !ApproxEqualFloat(m[i], other[i], epsilon) {
+			return false
+		}
+	}
+	return true
+}
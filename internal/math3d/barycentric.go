@@ -0,0 +1,58 @@
+package math3d
+
+// Barycentric returns the barycentric coordinates (u, v, w) of point p with respect to
+// triangle (a, b, c), so that p = u*a + v*b + w*c when p lies in the triangle's plane.
+// It returns false for degenerate triangles.
+func Barycentric(p, a, b, c Vec3) (float32, float32, float32, bool) {
+	v0 := b.Sub(a)
+	v1 := c.Sub(a)
+	v2 := p.Sub(a)
+
+	d00 := v0.Dot(v0)
+	d01 := v0.Dot(v1)
+	d11 := v1.Dot(v1)
+	d20 := v2.Dot(v0)
+	d21 := v2.Dot(v1)
+
+	denom := d00*d11 - d01*d01
+	if denom == 0 {
+		return 0, 0, 0, false
+	}
+
+	v := (d11*d20 - d01*d21) / denom
+	w := (d00*d21 - d01*d20) / denom
+	return 1 - v - w, v, w, true
+}
+
+// Barycentric2D returns the barycentric coordinates (u, v, w) of point p with respect to
+// the 2D triangle (a, b, c). It returns false for degenerate triangles.
+func Barycentric2D(p, a, b, c Vec2) (float32, float32, float32, bool) {
+	denom := (b.Y-c.Y)*(a.X-c.X) + (c.X-b.X)*(a.Y-c.Y)
+	if denom == 0 {
+		return 0, 0, 0, false
+	}
+
+	u := ((b.Y-c.Y)*(p.X-c.X) + (c.X-b.X)*(p.Y-c.Y)) / denom
+	v := ((c.Y-a.Y)*(p.X-c.X) + (a.X-c.X)*(p.Y-c.Y)) / denom
+	return u, v, 1 - u - v, true
+}
+
+// InsideTriangle reports whether barycentric coordinates describe a point inside (or on the edge of) the triangle
+func InsideTriangle(u, v, w float32) bool {
+	return u >= 0 && v >= 0 && w >= 0
+}
+
+// InterpolateFloat blends three scalar attributes with barycentric weights
+func InterpolateFloat(u, v, w, a, b, c float32) float32 {
+	return u*a + v*b + w*c
+}
+
+// InterpolateVec2 blends three Vec2 attributes (e.g. texture coordinates) with barycentric weights
+func InterpolateVec2(u, v, w float32, a, b, c Vec2) Vec2 {
+	return a.Scale(u).Add(b.Scale(v)).Add(c.Scale(w))
+}
+
+// InterpolateVec3 blends three Vec3 attributes (e.g. positions or normals) with barycentric weights
+func InterpolateVec3(u, v, w float32, a, b, c Vec3) Vec3 {
+	return a.Scale(u).Add(b.Scale(v)).Add(c.Scale(w))
+}
@@ -0,0 +1,217 @@
+package math3d
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Color represents an RGBA color with float32 components in [0, 1]
+type Color struct {
+	R, G, B, A float32
+}
+
+// ColorBinarySize is the binary size in bytes of a Color
+const ColorBinarySize = 4 * 4
+
+// NewColor creates a new color from components
+func NewColor(r, g, b, a float32) Color {
+	return Color{R: r, G: g, B: b, A: a}
+}
+
+// NewColorRGB creates a new opaque color
+func NewColorRGB(r, g, b float32) Color {
+	return Color{R: r, G: g, B: b, A: 1}
+}
+
+// ColorFromHSV creates an opaque color from hue (degrees), saturation and value in [0, 1]
+func ColorFromHSV(h, s, v float32) Color {
+	h = float32(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+
+	c := v * s
+	x := c * (1 - float32(math.Abs(math.Mod(float64(h/60), 2)-1)))
+	m := v - c
+
+	var r, g, b float32
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return Color{R: r + m, G: g + m, B: b + m, A: 1}
+}
+
+// ParseHexColor parses a color in #RGB, #RGBA, #RRGGBB or #RRGGBBAA form (the # is optional)
+func ParseHexColor(s string) (Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+
+	// Expand the short forms to two digits per channel
+	if len(hex) == 3 || len(hex) == 4 {
+		var expanded strings.Builder
+		for _, digit := range hex {
+			expanded.WriteRune(digit)
+			expanded.WriteRune(digit)
+		}
+		hex = expanded.String()
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return Color{}, fmt.Errorf("invalid hex color '%s'", s)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid hex color '%s'", s)
+	}
+
+	return Color{
+		R: float32((value>>24)&0xff) / 255,
+		G: float32((value>>16)&0xff) / 255,
+		B: float32((value>>8)&0xff) / 255,
+		A: float32(value&0xff) / 255,
+	}, nil
+}
+
+// Hex returns the color as a #RRGGBBAA string
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x%02x", toByte(c.R), toByte(c.G), toByte(c.B), toByte(c.A))
+}
+
+// HSV returns hue (degrees), saturation and value of the color, ignoring alpha
+func (c Color) HSV() (float32, float32, float32) {
+	max := float32(math.Max(float64(c.R), math.Max(float64(c.G), float64(c.B))))
+	min := float32(math.Min(float64(c.R), math.Min(float64(c.G), float64(c.B))))
+	delta := max - min
+
+	var h float32
+	switch {
+	case delta == 0:
+		h = 0
+	case max == c.R:
+		h = 60 * float32(math.Mod(float64((c.G-c.B)/delta), 6))
+	case max == c.G:
+		h = 60 * ((c.B-c.R)/delta + 2)
+	default:
+		h = 60 * ((c.R-c.G)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+
+	var s float32
+	if max != 0 {
+		s = delta / max
+	}
+
+	return h, s, max
+}
+
+// ToLinear converts the color from sRGB to linear space (alpha is unchanged)
+func (c Color) ToLinear() Color {
+	return Color{R: srgbToLinear(c.R), G: srgbToLinear(c.G), B: srgbToLinear(c.B), A: c.A}
+}
+
+// ToSRGB converts the color from linear to sRGB space (alpha is unchanged)
+func (c Color) ToSRGB() Color {
+	return Color{R: linearToSRGB(c.R), G: linearToSRGB(c.G), B: linearToSRGB(c.B), A: c.A}
+}
+
+// Lerp linearly interpolates between two colors
+func (c Color) Lerp(other Color, t float32) Color {
+	return Color{
+		R: c.R + (other.R-c.R)*t,
+		G: c.G + (other.G-c.G)*t,
+		B: c.B + (other.B-c.B)*t,
+		A: c.A + (other.A-c.A)*t,
+	}
+}
+
+// ToVec3 returns the RGB components as a Vec3
+func (c Color) ToVec3() Vec3 {
+	return Vec3{X: c.R, Y: c.G, Z: c.B}
+}
+
+// ToVec4 returns the RGBA components as a Vec4
+func (c Color) ToVec4() Vec4 {
+	return Vec4{X: c.R, Y: c.G, Z: c.B, W: c.A}
+}
+
+// MarshalJSON encodes the color as [r, g, b, a]
+func (c Color) MarshalJSON() ([]byte, error) {
+	return marshalFloats(c.R, c.G, c.B, c.A)
+}
+
+// UnmarshalJSON decodes the color from [r, g, b, a] or a hex string
+func (c *Color) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		hex, err := strconv.Unquote(string(data))
+		if err != nil {
+			return fmt.Errorf("math3d: invalid Color: %w", err)
+		}
+		parsed, err := ParseHexColor(hex)
+		if err != nil {
+			return err
+		}
+		*c = parsed
+		return nil
+	}
+	return unmarshalFloats(data, "Color", &c.R, &c.G, &c.B, &c.A)
+}
+
+// MarshalBinary encodes the color as little-endian float32 components
+func (c Color) MarshalBinary() ([]byte, error) {
+	return c.AppendBinary(make([]byte, 0, ColorBinarySize)), nil
+}
+
+// AppendBinary appends the binary encoding of the color to buf
+func (c Color) AppendBinary(buf []byte) []byte {
+	return appendFloats(buf, c.R, c.G, c.B, c.A)
+}
+
+// UnmarshalBinary decodes the color from little-endian float32 components
+func (c *Color) UnmarshalBinary(data []byte) error {
+	return readFloats(data, "Color", &c.R, &c.G, &c.B, &c.A)
+}
+
+// srgbToLinear converts a single sRGB channel to linear space
+func srgbToLinear(v float32) float32 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return float32(math.Pow((float64(v)+0.055)/1.055, 2.4))
+}
+
+// linearToSRGB converts a single linear channel to sRGB space
+func linearToSRGB(v float32) float32 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return float32(1.055*math.Pow(float64(v), 1/2.4) - 0.055)
+}
+
+// toByte converts a [0, 1] channel to a rounded byte value
+func toByte(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return uint8(v*255 + 0.5)
+}
@@ -0,0 +1,134 @@
+package math3d
+
+// DualQuat represents a rigid transform (rotation followed by translation) as a dual quaternion.
+// Real holds the rotation and Dual encodes the translation.
+type DualQuat struct {
+	Real Quat
+	Dual Quat
+}
+
+// DualQuatIdentity returns the identity dual quaternion (no rotation or translation)
+func DualQuatIdentity() DualQuat {
+	return DualQuat{Real: QuatIdentity(), Dual: Quat{}}
+}
+
+// DualQuatFromRotationTranslation creates a dual quaternion that rotates by q and then translates by t
+func DualQuatFromRotationTranslation(q Quat, t Vec3) DualQuat {
+	real := q.Normalize()
+	translation := Quat{X: t.X, Y: t.Y, Z: t.Z, W: 0}
+
+	return DualQuat{
+		Real: real,
+		Dual: translation.Multiply(real).Scale(0.5),
+	}
+}
+
+// DualQuatFromTranslation creates a dual quaternion representing a pure translation
+func DualQuatFromTranslation(t Vec3) DualQuat {
+	return DualQuatFromRotationTranslation(QuatIdentity(), t)
+}
+
+// DualQuatFromMat4 creates a dual quaternion from a rigid transform matrix.
+// Any scale or shear in the matrix is discarded.
+func DualQuatFromMat4(m Mat4) DualQuat {
+	return DualQuatFromRotationTranslation(QuatFromMat4(m), m.GetTranslation())
+}
+
+// Add adds two dual quaternions component-wise
+func (dq DualQuat) Add(other DualQuat) DualQuat {
+	return DualQuat{Real: dq.Real.Add(other.Real), Dual: dq.Dual.Add(other.Dual)}
+}
+
+// Scale multiplies both parts of the dual quaternion by a scalar
+func (dq DualQuat) Scale(s float32) DualQuat {
+	return DualQuat{Real: dq.Real.Scale(s), Dual: dq.Dual.Scale(s)}
+}
+
+// Multiply composes two transforms; the result applies other first, then dq
+func (dq DualQuat) Multiply(other DualQuat) DualQuat {
+	return DualQuat{
+		Real: dq.Real.Multiply(other.Real),
+		Dual: dq.Real.Multiply(other.Dual).Add(dq.Dual.Multiply(other.Real)),
+	}
+}
+
+// Conjugate returns the quaternion conjugate of both parts, which inverts a unit dual quaternion
+func (dq DualQuat) Conjugate() DualQuat {
+	return DualQuat{Real: dq.Real.Conjugate(), Dual: dq.Dual.Conjugate()}
+}
+
+// Normalize returns a unit dual quaternion with the dual part made orthogonal to the real part
+func (dq DualQuat) Normalize() DualQuat {
+	length := dq.Real.Length()
+	if length == 0 {
+		return DualQuatIdentity()
+	}
+
+	real := dq.Real.Scale(1.0 / length)
+	dual := dq.Dual.Scale(1.0 / length)
+	dual = dual.Sub(real.Scale(real.Dot(dual)))
+
+	return DualQuat{Real: real, Dual: dual}
+}
+
+// Rotation returns the rotation part of the transform
+func (dq DualQuat) Rotation() Quat {
+	return dq.Real.Normalize()
+}
+
+// Translation returns the translation part of the transform
+func (dq DualQuat) Translation() Vec3 {
+	t := dq.Dual.Scale(2).Multiply(dq.Real.Conjugate())
+	lengthSq := dq.Real.LengthSquared()
+	if lengthSq == 0 {
+		return Vec3{}
+	}
+	return Vec3{X: t.X, Y: t.Y, Z: t.Z}.Scale(1.0 / lengthSq)
+}
+
+// TransformPoint applies the rigid transform to a point
+func (dq DualQuat) TransformPoint(p Vec3) Vec3 {
+	return dq.Rotation().RotateVec3(p).Add(dq.Translation())
+}
+
+// TransformVector applies only the rotation part of the transform to a direction
+func (dq DualQuat) TransformVector(v Vec3) Vec3 {
+	return dq.Rotation().RotateVec3(v)
+}
+
+// ToMat4 converts the dual quaternion to a 4x4 rigid transform matrix
+func (dq DualQuat) ToMat4() Mat4 {
+	m := dq.Rotation().ToMat4()
+	m.SetTranslation(dq.Translation())
+	return m
+}
+
+// Lerp performs dual quaternion linear blending between two transforms, taking the shortest path.
+// This is the interpolation used for dual quaternion skinning.
+func (dq DualQuat) Lerp(other DualQuat, t float32) DualQuat {
+	if dq.Real.Dot(other.Real) < 0 {
+		other = other.Scale(-1)
+	}
+	return dq.Scale(1 - t).Add(other.Scale(t)).Normalize()
+}
+
+// BlendDualQuats blends several transforms by weight (dual quaternion skinning).
+// Weights do not need to sum to one; the result is normalized.
+func BlendDualQuats(transforms []DualQuat, weights []float32) DualQuat {
+	if len(transforms) == 0 || len(transforms) != len(weights) {
+		return DualQuatIdentity()
+	}
+
+	pivot := transforms[0].Real
+	var result DualQuat
+	for i, dq := range transforms {
+		weight := weights[i]
+		// Keep every blend input in the same hemisphere as the first transform
+		if pivot.Dot(dq.Real) < 0 {
+			weight = -weight
+		}
+		result = result.Add(dq.Scale(weight))
+	}
+
+	return result.Normalize()
+}
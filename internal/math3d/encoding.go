@@ -0,0 +1,190 @@
+package math3d
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Binary sizes in bytes of the math3d types (little-endian float32 components)
+const (
+	Vec2BinarySize = 2 * 4
+	Vec3BinarySize = 3 * 4
+	Vec4BinarySize = 4 * 4
+	QuatBinarySize = 4 * 4
+	Mat4BinarySize = 16 * 4
+)
+
+// marshalFloats encodes components as a JSON array
+func marshalFloats(values ...float32) ([]byte, error) {
+	return json.Marshal(values)
+}
+
+// unmarshalFloats decodes a JSON array into the given components
+func unmarshalFloats(data []byte, typeName string, dst ...*float32) error {
+	var values []float32
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("math3d: invalid %s: %w", typeName, err)
+	}
+	if len(values) != len(dst) {
+		return fmt.Errorf("math3d: %s requires %d components, got %d", typeName, len(dst), len(values))
+	}
+	for i, value := range values {
+		*dst[i] = value
+	}
+	return nil
+}
+
+// appendFloats appends components to buf as little-endian float32 values
+func appendFloats(buf []byte, values ...float32) []byte {
+	for _, value := range values {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(value))
+	}
+	return buf
+}
+
+// readFloats decodes little-endian float32 values from data into the given components
+func readFloats(data []byte, typeName string, dst ...*float32) error {
+	if len(data) != len(dst)*4 {
+		return fmt.Errorf("math3d: %s requires %d bytes, got %d", typeName, len(dst)*4, len(data))
+	}
+	for i := range dst {
+		*dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the vector as [x, y]
+func (v Vec2) MarshalJSON() ([]byte, error) {
+	return marshalFloats(v.X, v.Y)
+}
+
+// UnmarshalJSON decodes the vector from [x, y]
+func (v *Vec2) UnmarshalJSON(data []byte) error {
+	return unmarshalFloats(data, "Vec2", &v.X, &v.Y)
+}
+
+// MarshalBinary encodes the vector as little-endian float32 components
+func (v Vec2) MarshalBinary() ([]byte, error) {
+	return v.AppendBinary(make([]byte, 0, Vec2BinarySize)), nil
+}
+
+// AppendBinary appends the binary encoding of the vector to buf
+func (v Vec2) AppendBinary(buf []byte) []byte {
+	return appendFloats(buf, v.X, v.Y)
+}
+
+// UnmarshalBinary decodes the vector from little-endian float32 components
+func (v *Vec2) UnmarshalBinary(data []byte) error {
+	return readFloats(data, "Vec2", &v.X, &v.Y)
+}
+
+// MarshalJSON encodes the vector as [x, y, z]
+func (v Vec3) MarshalJSON() ([]byte, error) {
+	return marshalFloats(v.X, v.Y, v.Z)
+}
+
+// UnmarshalJSON decodes the vector from [x, y, z]
+func (v *Vec3) UnmarshalJSON(data []byte) error {
+	return unmarshalFloats(data, "Vec3", &v.X, &v.Y, &v.Z)
+}
+
+// MarshalBinary encodes the vector as little-endian float32 components
+func (v Vec3) MarshalBinary() ([]byte, error) {
+	return v.AppendBinary(make([]byte, 0, Vec3BinarySize)), nil
+}
+
+// AppendBinary appends the binary encoding of the vector to buf
+func (v Vec3) AppendBinary(buf []byte) []byte {
+	return appendFloats(buf, v.X, v.Y, v.Z)
+}
+
+// UnmarshalBinary decodes the vector from little-endian float32 components
+func (v *Vec3) UnmarshalBinary(data []byte) error {
+	return readFloats(data, "Vec3", &v.X, &v.Y, &v.Z)
+}
+
+// MarshalJSON encodes the vector as [x, y, z, w]
+func (v Vec4) MarshalJSON() ([]byte, error) {
+	return marshalFloats(v.X, v.Y, v.Z, v.W)
+}
+
+// UnmarshalJSON decodes the vector from [x, y, z, w]
+func (v *Vec4) UnmarshalJSON(data []byte) error {
+	return unmarshalFloats(data, "Vec4", &v.X, &v.Y, &v.Z, &v.W)
+}
+
+// MarshalBinary encodes the vector as little-endian float32 components
+func (v Vec4) MarshalBinary() ([]byte, error) {
+	return v.AppendBinary(make([]byte, 0, Vec4BinarySize)), nil
+}
+
+// AppendBinary appends the binary encoding of the vector to buf
+func (v Vec4) AppendBinary(buf []byte) []byte {
+	return appendFloats(buf, v.X, v.Y, v.Z, v.W)
+}
+
+// UnmarshalBinary decodes the vector from little-endian float32 components
+func (v *Vec4) UnmarshalBinary(data []byte) error {
+	return readFloats(data, "Vec4", &v.X, &v.Y, &v.Z, &v.W)
+}
+
+// MarshalJSON encodes the quaternion as [x, y, z, w]
+func (q Quat) MarshalJSON() ([]byte, error) {
+	return marshalFloats(q.X, q.Y, q.Z, q.W)
+}
+
+// UnmarshalJSON decodes the quaternion from [x, y, z, w]
+func (q *Quat) UnmarshalJSON(data []byte) error {
+	return unmarshalFloats(data, "Quat", &q.X, &q.Y, &q.Z, &q.W)
+}
+
+// MarshalBinary encodes the quaternion as little-endian float32 components
+func (q Quat) MarshalBinary() ([]byte, error) {
+	return q.AppendBinary(make([]byte, 0, QuatBinarySize)), nil
+}
+
+// AppendBinary appends the binary encoding of the quaternion to buf
+func (q Quat) AppendBinary(buf []byte) []byte {
+	return appendFloats(buf, q.X, q.Y, q.Z, q.W)
+}
+
+// UnmarshalBinary decodes the quaternion from little-endian float32 components
+func (q *Quat) UnmarshalBinary(data []byte) error {
+	return readFloats(data, "Quat", &q.X, &q.Y, &q.Z, &q.W)
+}
+
+// MarshalJSON encodes the matrix as a flat array of 16 values in column-major order
+func (m Mat4) MarshalJSON() ([]byte, error) {
+	return marshalFloats(m[:]...)
+}
+
+// UnmarshalJSON decodes the matrix from a flat array of 16 values in column-major order
+func (m *Mat4) UnmarshalJSON(data []byte) error {
+	return unmarshalFloats(data, "Mat4", m.components()...)
+}
+
+// MarshalBinary encodes the matrix as 16 little-endian float32 values in column-major order
+func (m Mat4) MarshalBinary() ([]byte, error) {
+	return m.AppendBinary(make([]byte, 0, Mat4BinarySize)), nil
+}
+
+// AppendBinary appends the binary encoding of the matrix to buf
+func (m Mat4) AppendBinary(buf []byte) []byte {
+	return appendFloats(buf, m[:]...)
+}
+
+// UnmarshalBinary decodes the matrix from 16 little-endian float32 values in column-major order
+func (m *Mat4) UnmarshalBinary(data []byte) error {
+	return readFloats(data, "Mat4", m.components()...)
+}
+
+// components returns pointers to every element of the matrix
+func (m *Mat4) components() []*float32 {
+	dst := make([]*float32, len(m))
+	for i := range m {
+		dst[i] = &m[i]
+	}
+	return dst
+}
@@ -0,0 +1,40 @@
+package math3d
+
+import (
+	"math"
+)
+
+// IsFiniteFloat reports whether f is neither NaN nor infinite
+func IsFiniteFloat(f float32) bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
+
+// IsFinite reports whether every component is neither NaN nor infinite
+func (v Vec2) IsFinite() bool {
+	return IsFiniteFloat(v.X) && IsFiniteFloat(v.Y)
+}
+
+// IsFinite reports whether every component is neither NaN nor infinite
+func (v Vec3) IsFinite() bool {
+	return IsFiniteFloat(v.X) && IsFiniteFloat(v.Y) && IsFiniteFloat(v.Z)
+}
+
+// IsFinite reports whether every component is neither NaN nor infinite
+func (v Vec4) IsFinite() bool {
+	return IsFiniteFloat(v.X) && IsFiniteFloat(v.Y) && IsFiniteFloat(v.Z) && IsFiniteFloat(v.W)
+}
+
+// IsFinite reports whether every component is neither NaN nor infinite
+func (q Quat) IsFinite() bool {
+	return IsFiniteFloat(q.X) && IsFiniteFloat(q.Y) && IsFiniteFloat(q.Z) && IsFiniteFloat(q.W)
+}
+
+// IsFinite reports whether every element is neither NaN nor infinite
+func (m Mat4) IsFinite() bool {
+	for _, v := range m {
+		if !IsFiniteFloat(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package math3d
+
+import (
+	"math"
+)
+
+// The *Into variants below write their result through a destination pointer
+// instead of returning a value. They take their operands by pointer as well,
+// so none of them copies a Mat4 or allocates; they are meant for per-frame
+// update paths that transform many entities. The destination may alias any
+// operand.
+
+// MultiplyInto stores m * other in dst
+func (m *Mat4) MultiplyInto(other *Mat4, dst *Mat4) {
+	var result Mat4
+
+	for col := 0; col < 4; col++ {
+		o0, o1, o2, o3 := other[col*4], other[col*4+1], other[col*4+2], other[col*4+3]
+		result[col*4] = m[0]*o0 + m[4]*o1 + m[8]*o2 + m[12]*o3
+		result[col*4+1] = m[1]*o0 + m[5]*o1 + m[9]*o2 + m[13]*o3
+		result[col*4+2] = m[2]*o0 + m[6]*o1 + m[10]*o2 + m[14]*o3
+		result[col*4+3] = m[3]*o0 + m[7]*o1 + m[11]*o2 + m[15]*o3
+	}
+
+	*dst = result
+}
+
+// TransposeInto stores the transpose of m in dst
+func (m *Mat4) TransposeInto(dst *Mat4) {
+	result := Mat4{
+		m[0], m[4], m[8], m[12],
+		m[1], m[5], m[9], m[13],
+		m[2], m[6], m[10], m[14],
+		m[3], m[7], m[11], m[15],
+	}
+	*dst = result
+}
+
+// MultiplyVec4Into stores m * v in dst
+func (m *Mat4) MultiplyVec4Into(v *Vec4, dst *Vec4) {
+	x, y, z, w := v.X, v.Y, v.Z, v.W
+	dst.X = m[0]*x + m[4]*y + m[8]*z + m[12]*w
+	dst.Y = m[1]*x + m[5]*y + m[9]*z + m[13]*w
+	dst.Z = m[2]*x + m[6]*y + m[10]*z + m[14]*w
+	dst.W = m[3]*x + m[7]*y + m[11]*z + m[15]*w
+}
+
+// MultiplyVec3PointInto stores m * v (W=1, divided by the resulting W) in dst
+func (m *Mat4) MultiplyVec3PointInto(v *Vec3, dst *Vec3) {
+	x, y, z := v.X, v.Y, v.Z
+	rx := m[0]*x + m[4]*y + m[8]*z + m[12]
+	ry := m[1]*x + m[5]*y + m[9]*z + m[13]
+	rz := m[2]*x + m[6]*y + m[10]*z + m[14]
+	rw := m[3]*x + m[7]*y + m[11]*z + m[15]
+	if rw != 0 {
+		rx, ry, rz = rx/rw, ry/rw, rz/rw
+	}
+	dst.X, dst.Y, dst.Z = rx, ry, rz
+}
+
+// MultiplyVec3VectorInto stores m * v (W=0) in dst
+func (m *Mat4) MultiplyVec3VectorInto(v *Vec3, dst *Vec3) {
+	x, y, z := v.X, v.Y, v.Z
+	dst.X = m[0]*x + m[4]*y + m[8]*z
+	dst.Y = m[1]*x + m[5]*y + m[9]*z
+	dst.Z = m[2]*x + m[6]*y + m[10]*z
+}
+
+// LookAtInto stores the view matrix built by LookAt in dst
+func LookAtInto(eye, target, up *Vec3, dst *Mat4) {
+	var forward, right, realUp Vec3
+	target.SubInto(eye, &forward)
+	forward.NormalizeInto(&forward)
+	forward.CrossInto(up, &right)
+	right.NormalizeInto(&right)
+	right.CrossInto(&forward, &realUp)
+
+	// Negate forward for right-handed coordinate system
+	forward.ScaleInto(-1, &forward)
+
+	*dst = Mat4{
+		right.X, realUp.X, forward.X, 0,
+		right.Y, realUp.Y, forward.Y, 0,
+		right.Z, realUp.Z, forward.Z, 0,
+		-right.Dot(*eye), -realUp.Dot(*eye), -forward.Dot(*eye), 1,
+	}
+}
+
+// AddInto stores v + other in dst
+func (v *Vec3) AddInto(other *Vec3, dst *Vec3) {
+	dst.X, dst.Y, dst.Z = v.X+other.X, v.Y+other.Y, v.Z+other.Z
+}
+
+// SubInto stores v - other in dst
+func (v *Vec3) SubInto(other *Vec3, dst *Vec3) {
+	dst.X, dst.Y, dst.Z = v.X-other.X, v.Y-other.Y, v.Z-other.Z
+}
+
+// ScaleInto stores v * s in dst
+func (v *Vec3) ScaleInto(s float32, dst *Vec3) {
+	dst.X, dst.Y, dst.Z = v.X*s, v.Y*s, v.Z*s
+}
+
+// MulInto stores the component-wise product of v and other in dst
+func (v *Vec3) MulInto(other *Vec3, dst *Vec3) {
+	dst.X, dst.Y, dst.Z = v.X*other.X, v.Y*other.Y, v.Z*other.Z
+}
+
+// CrossInto stores v x other in dst
+func (v *Vec3) CrossInto(other *Vec3, dst *Vec3) {
+	x := v.Y*other.Z - v.Z*other.Y
+	y := v.Z*other.X - v.X*other.Z
+	z := v.X*other.Y - v.Y*other.X
+	dst.X, dst.Y, dst.Z = x, y, z
+}
+
+// NormalizeInto stores v scaled to unit length in dst (the zero vector stays zero)
+func (v *Vec3) NormalizeInto(dst *Vec3) {
+	length := float32(math.Sqrt(float64(v.X*v.X + v.Y*v.Y + v.Z*v.Z)))
+	if length == 0 {
+		*dst = Vec3{}
+		return
+	}
+	dst.X, dst.Y, dst.Z = v.X/length, v.Y/length, v.Z/length
+}
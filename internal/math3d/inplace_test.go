@@ -0,0 +1,190 @@
+package math3d
+
+import "testing"
+
+// Each value-returning operation is paired with its *Into variant. Results go
+// to package variables so the compiler cannot drop the work.
+
+var (
+	benchMat4 Mat4
+	benchVec4 Vec4
+	benchVec3 Vec3
+)
+
+var (
+	benchA   = Perspective(1, 16.0/9.0, 0.1, 100)
+	benchB   = LookAt(NewVec3(3, 4, 5), NewVec3(0, 0, 0), NewVec3(0, 1, 0))
+	benchV   = NewVec3(1, 2, 3)
+	benchW   = NewVec3(-4, 0.5, 2)
+	benchV4  = Vec4{X: 1, Y: 2, Z: 3, W: 1}
+	benchEye = NewVec3(3, 4, 5)
+	benchUp  = NewVec3(0, 1, 0)
+)
+
+func BenchmarkMultiply(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchMat4 = benchA.Multiply(benchB)
+	}
+}
+
+func BenchmarkMultiplyInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchA.MultiplyInto(&benchB, &benchMat4)
+	}
+}
+
+func BenchmarkTranspose(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchMat4 = benchA.Transpose()
+	}
+}
+
+func BenchmarkTransposeInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchA.TransposeInto(&benchMat4)
+	}
+}
+
+func BenchmarkMultiplyVec4(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec4 = benchA.MultiplyVec4(benchV4)
+	}
+}
+
+func BenchmarkMultiplyVec4Into(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchA.MultiplyVec4Into(&benchV4, &benchVec4)
+	}
+}
+
+func BenchmarkMultiplyVec3Point(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchA.MultiplyVec3Point(benchV)
+	}
+}
+
+func BenchmarkMultiplyVec3PointInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchA.MultiplyVec3PointInto(&benchV, &benchVec3)
+	}
+}
+
+func BenchmarkMultiplyVec3Vector(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchA.MultiplyVec3Vector(benchV)
+	}
+}
+
+func BenchmarkMultiplyVec3VectorInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchA.MultiplyVec3VectorInto(&benchV, &benchVec3)
+	}
+}
+
+func BenchmarkLookAt(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchMat4 = LookAt(benchEye, benchV, benchUp)
+	}
+}
+
+func BenchmarkLookAtInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		LookAtInto(&benchEye, &benchV, &benchUp, &benchMat4)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchV.Add(benchW)
+	}
+}
+
+func BenchmarkAddInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchV.AddInto(&benchW, &benchVec3)
+	}
+}
+
+func BenchmarkSub(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchV.Sub(benchW)
+	}
+}
+
+func BenchmarkSubInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchV.SubInto(&benchW, &benchVec3)
+	}
+}
+
+func BenchmarkScale(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchV.Scale(2.5)
+	}
+}
+
+func BenchmarkScaleInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchV.ScaleInto(2.5, &benchVec3)
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchV.Mul(benchW)
+	}
+}
+
+func BenchmarkMulInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchV.MulInto(&benchW, &benchVec3)
+	}
+}
+
+func BenchmarkCross(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchV.Cross(benchW)
+	}
+}
+
+func BenchmarkCrossInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchV.CrossInto(&benchW, &benchVec3)
+	}
+}
+
+func BenchmarkNormalize(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchVec3 = benchV.Normalize()
+	}
+}
+
+func BenchmarkNormalizeInto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchV.NormalizeInto(&benchVec3)
+	}
+}
@@ -205,6 +205,45 @@ func Perspective(fovY, aspect, near, far float32) Mat4 {
 	}
 }
 
+// PerspectiveReversedZ creates a perspective projection matrix with reversed depth.
+// Near maps to depth 1 and far maps to depth 0 in a [0, 1] clip range, which
+// spreads floating point precision evenly across large scenes.
+func PerspectiveReversedZ(fovY, aspect, near, far float32) Mat4 {
+	f := float32(1.0 / math.Tan(float64(fovY)*0.5))
+
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, near / (far - near), -1,
+		0, 0, (far * near) / (far - near), 0,
+	}
+}
+
+// PerspectiveInfinite creates a perspective projection matrix with the far plane at infinity
+func PerspectiveInfinite(fovY, aspect, near float32) Mat4 {
+	f := float32(1.0 / math.Tan(float64(fovY)*0.5))
+
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, -1, -1,
+		0, 0, -2 * near, 0,
+	}
+}
+
+// PerspectiveInfiniteReversedZ creates a reversed-Z projection matrix with the far plane at infinity.
+// Near maps to depth 1 and depth approaches 0 as distance grows.
+func PerspectiveInfiniteReversedZ(fovY, aspect, near float32) Mat4 {
+	f := float32(1.0 / math.Tan(float64(fovY)*0.5))
+
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, 0, -1,
+		0, 0, near, 0,
+	}
+}
+
 // Ortho creates an orthographic projection matrix
 func Ortho(left, right, bottom, top, near, far float32) Mat4 {
 	return Mat4{
@@ -217,6 +256,89 @@ func Ortho(left, right, bottom, top, near, far float32) Mat4 {
 	}
 }
 
+// Ortho2D creates an orthographic projection for pixel coordinates with the origin
+// in the top-left corner and Y pointing down (HUD and overlay rendering)
+func Ortho2D(width, height float32) Mat4 {
+	return Ortho(0, width, height, 0, -1, 1)
+}
+
+// Viewport creates a matrix mapping normalized device coordinates to window
+// coordinates, with depth mapped from [-1, 1] to [0, 1]
+func Viewport(x, y, width, height float32) Mat4 {
+	return Mat4{
+		width / 2, 0, 0, 0,
+		0, height / 2, 0, 0,
+		0, 0, 0.5, 0,
+		x + width/2, y + height/2, 0.5, 1,
+	}
+}
+
+// Unproject maps a window coordinate (with depth in [0, 1]) back to world space
+// using the inverse of viewport * projection * view
+func Unproject(window Vec3, viewProjection, viewport Mat4) (Vec3, bool) {
+	inv, ok := viewport.Multiply(viewProjection).Inverse()
+	if !ok {
+		return Vec3{}, false
+	}
+	return inv.MultiplyVec3(window), true
+}
+
+// PlaneFromPointNormal returns the plane (a, b, c, d) with ax + by + cz + d = 0
+// passing through point with the given normal
+func PlaneFromPointNormal(point, normal Vec3) Vec4 {
+	n := normal.Normalize()
+	return Vec4{X: n.X, Y: n.Y, Z: n.Z, W: -n.Dot(point)}
+}
+
+// TransformPlane transforms a plane by this matrix (e.g. a view matrix to move a world-space plane into view space)
+func (m Mat4) TransformPlane(plane Vec4) Vec4 {
+	inv, ok := m.Inverse()
+	if !ok {
+		return plane
+	}
+	return inv.Transpose().MultiplyVec4(plane)
+}
+
+// ApplyObliqueClipPlane replaces the near plane of a perspective projection with an
+// arbitrary view-space clip plane (Lengyel's oblique near-plane clipping).
+// Geometry on the negative side of the plane is clipped, which is used to cut away
+// everything below (or above) the water surface in reflection and refraction passes.
+func (m Mat4) ApplyObliqueClipPlane(plane Vec4) Mat4 {
+	// Corner of the view frustum opposite the plane, in view space
+	q := Vec4{
+		X: (sign(plane.X) + m[8]) / m[0],
+		Y: (sign(plane.Y) + m[9]) / m[5],
+		Z: -1,
+		W: (1 + m[10]) / m[14],
+	}
+
+	dot := plane.Dot(q)
+	if dot == 0 {
+		return m
+	}
+	c := plane.Scale(2 / dot)
+
+	// Replace the third row with the scaled plane minus the fourth row
+	result := m
+	result[2] = c.X
+	result[6] = c.Y
+	result[10] = c.Z + 1
+	result[14] = c.W
+	return result
+}
+
+// sign returns -1, 0 or 1 matching the sign of v
+func sign(v float32) float32 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
 // Inverse calculates the inverse of this matrix (using Gaussian elimination)
 func (m Mat4) Inverse() (Mat4, bool) {
 	// Create augmented matrix [A|I]
@@ -0,0 +1,67 @@
+package math3d
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Rand produces random vectors from a seedable source.
+// The same seed always yields the same sequence, which keeps scattering and spawning reproducible.
+// A Rand is not safe for concurrent use.
+type Rand struct {
+	source *rand.Rand
+}
+
+// NewRand creates a random vector generator with the given seed
+func NewRand(seed int64) *Rand {
+	return &Rand{source: rand.New(rand.NewSource(seed))}
+}
+
+// Float32 returns a random value in [0, 1)
+func (r *Rand) Float32() float32 {
+	return r.source.Float32()
+}
+
+// Range returns a random value in [lo, hi)
+func (r *Rand) Range(lo, hi float32) float32 {
+	return lo + (hi-lo)*r.source.Float32()
+}
+
+// RandomUnitVec3 returns a uniformly distributed direction on the unit sphere
+func (r *Rand) RandomUnitVec3() Vec3 {
+	z := r.Range(-1, 1)
+	theta := r.Range(0, 2*math.Pi)
+	radius := float32(math.Sqrt(float64(1 - z*z)))
+
+	return Vec3{
+		X: radius * float32(math.Cos(float64(theta))),
+		Y: radius * float32(math.Sin(float64(theta))),
+		Z: z,
+	}
+}
+
+// RandomInSphere returns a uniformly distributed point inside the unit sphere
+func (r *Rand) RandomInSphere() Vec3 {
+	radius := float32(math.Cbrt(float64(r.source.Float32())))
+	return r.RandomUnitVec3().Scale(radius)
+}
+
+// RandomOnHemisphere returns a uniformly distributed direction in the hemisphere around normal
+func (r *Rand) RandomOnHemisphere(normal Vec3) Vec3 {
+	dir := r.RandomUnitVec3()
+	if dir.Dot(normal) < 0 {
+		return dir.Scale(-1)
+	}
+	return dir
+}
+
+// RandomVec2InDisk returns a uniformly distributed point inside the unit disk
+func (r *Rand) RandomVec2InDisk() Vec2 {
+	radius := float32(math.Sqrt(float64(r.source.Float32())))
+	theta := r.Range(0, 2*math.Pi)
+
+	return Vec2{
+		X: radius * float32(math.Cos(float64(theta))),
+		Y: radius * float32(math.Sin(float64(theta))),
+	}
+}
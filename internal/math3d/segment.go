@@ -0,0 +1,81 @@
+package math3d
+
+// segmentEpsilon guards against division by zero for degenerate segments
+const segmentEpsilon = 1e-12
+
+// clamp01 limits a value to [0, 1]
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// ClosestPointOnSegment returns the point on segment ab closest to p
+func ClosestPointOnSegment(p, a, b Vec3) Vec3 {
+	ab := b.Sub(a)
+	lengthSq := ab.LengthSquared()
+	if lengthSq <= segmentEpsilon {
+		return a
+	}
+
+	t := clamp01(p.Sub(a).Dot(ab) / lengthSq)
+	return a.Add(ab.Scale(t))
+}
+
+// PointToSegmentDistance returns the distance from p to segment ab
+func PointToSegmentDistance(p, a, b Vec3) float32 {
+	return p.Distance(ClosestPointOnSegment(p, a, b))
+}
+
+// ClosestPointsBetweenSegments returns the closest points between segments p1q1 and p2q2
+// (Ericson, Real-Time Collision Detection, 5.1.9)
+func ClosestPointsBetweenSegments(p1, q1, p2, q2 Vec3) (Vec3, Vec3) {
+	d1 := q1.Sub(p1)
+	d2 := q2.Sub(p2)
+	r := p1.Sub(p2)
+	a := d1.LengthSquared()
+	e := d2.LengthSquared()
+	f := d2.Dot(r)
+
+	var s, t float32
+	switch {
+	case a <= segmentEpsilon && e <= segmentEpsilon:
+		// Both segments degenerate into points
+		return p1, p2
+	case a <= segmentEpsilon:
+		// First segment degenerates into a point
+		t = clamp01(f / e)
+	default:
+		c := d1.Dot(r)
+		if e <= segmentEpsilon {
+			// Second segment degenerates into a point
+			s = clamp01(-c / a)
+		} else {
+			b := d1.Dot(d2)
+			denom := a*e - b*b
+			if denom != 0 {
+				s = clamp01((b*f - c*e) / denom)
+			}
+			t = (b*s + f) / e
+			if t < 0 {
+				t = 0
+				s = clamp01(-c / a)
+			} else if t > 1 {
+				t = 1
+				s = clamp01((b - c) / a)
+			}
+		}
+	}
+
+	return p1.Add(d1.Scale(s)), p2.Add(d2.Scale(t))
+}
+
+// SegmentSegmentDistance returns the shortest distance between segments p1q1 and p2q2
+func SegmentSegmentDistance(p1, q1, p2, q2 Vec3) float32 {
+	c1, c2 := ClosestPointsBetweenSegments(p1, q1, p2, q2)
+	return c1.Distance(c2)
+}
@@ -0,0 +1,46 @@
+package math3d
+
+import (
+	"math"
+)
+
+// Spherical coordinates here use the orbit camera convention: yaw rotates around
+// the Y axis starting from +Z, and pitch is the elevation above the XZ plane.
+
+// SphericalToCartesian converts a radius, yaw and pitch (radians) to a Cartesian offset
+func SphericalToCartesian(radius, yaw, pitch float32) Vec3 {
+	cosPitch := float32(math.Cos(float64(pitch)))
+	return Vec3{
+		X: radius * cosPitch * float32(math.Sin(float64(yaw))),
+		Y: radius * float32(math.Sin(float64(pitch))),
+		Z: radius * cosPitch * float32(math.Cos(float64(yaw))),
+	}
+}
+
+// CartesianToSpherical converts a Cartesian offset to radius, yaw and pitch (radians).
+// The zero vector maps to all zeros.
+func CartesianToSpherical(v Vec3) (radius, yaw, pitch float32) {
+	radius = v.Length()
+	if radius == 0 {
+		return 0, 0, 0
+	}
+	yaw = float32(math.Atan2(float64(v.X), float64(v.Z)))
+	pitch = float32(math.Asin(float64(clampUnit(v.Y / radius))))
+	return radius, yaw, pitch
+}
+
+// OrbitAround returns the position at the given yaw, pitch and distance from target
+func OrbitAround(target Vec3, yaw, pitch, distance float32) Vec3 {
+	return target.Add(SphericalToCartesian(distance, yaw, pitch))
+}
+
+// clampUnit limits a value to [-1, 1] so rounding error cannot push it outside asin's domain
+func clampUnit(v float32) float32 {
+	if v < -1 {
+		return -1
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,14 @@
+package math3d
+
+// CatmullRom interpolates between p1 and p2 on the uniform Catmull-Rom spline
+// through p0, p1, p2 and p3. The curve passes through p1 at t = 0 and p2 at
+// t = 1, and consecutive segments join with matching tangents.
+func CatmullRom(p0, p1, p2, p3 Vec3, t float32) Vec3 {
+	t2 := t * t
+	t3 := t2 * t
+	return p1.Scale(2).
+		Add(p2.Sub(p0).Scale(t)).
+		Add(p0.Scale(2).Sub(p1.Scale(5)).Add(p2.Scale(4)).Sub(p3).Scale(t2)).
+		Add(p1.Scale(3).Sub(p0).Sub(p2.Scale(3)).Add(p3).Scale(t3)).
+		Scale(0.5)
+}
@@ -0,0 +1,75 @@
+package math3d
+
+// Transform represents a position, rotation and scale with an optional parent.
+// The local matrix is applied as scale, then rotation, then translation.
+type Transform struct {
+	Position Vec3       `json:"position"`
+	Rotation Quat       `json:"rotation"`
+	Scale    Vec3       `json:"scale"`
+	Parent   *Transform `json:"-"`
+}
+
+// NewTransform creates an identity transform with no parent
+func NewTransform() *Transform {
+	return &Transform{
+		Position: Vec3Zero,
+		Rotation: QuatIdentity(),
+		Scale:    Vec3One,
+	}
+}
+
+// SetParent attaches the transform to a parent.
+// It returns false and leaves the transform unchanged if the parent would create a cycle.
+func (t *Transform) SetParent(parent *Transform) bool {
+	for p := parent; p != nil; p = p.Parent {
+		if p == t {
+			return false
+		}
+	}
+	t.Parent = parent
+	return true
+}
+
+// LocalMatrix returns the matrix for this transform relative to its parent
+func (t *Transform) LocalMatrix() Mat4 {
+	return TranslationVec3(t.Position).
+		Multiply(t.Rotation.ToMat4()).
+		Multiply(ScaleVec3(t.Scale))
+}
+
+// LocalToWorld returns the matrix mapping local coordinates to world coordinates
+func (t *Transform) LocalToWorld() Mat4 {
+	local := t.LocalMatrix()
+	if t.Parent == nil {
+		return local
+	}
+	return t.Parent.LocalToWorld().Multiply(local)
+}
+
+// WorldToLocal returns the matrix mapping world coordinates to local coordinates
+func (t *Transform) WorldToLocal() (Mat4, bool) {
+	return t.LocalToWorld().Inverse()
+}
+
+// WorldPosition returns the position of the transform in world space
+func (t *Transform) WorldPosition() Vec3 {
+	return t.LocalToWorld().GetTranslation()
+}
+
+// WorldRotation returns the combined rotation of the transform and all its parents
+func (t *Transform) WorldRotation() Quat {
+	if t.Parent == nil {
+		return t.Rotation
+	}
+	return t.Parent.WorldRotation().Multiply(t.Rotation)
+}
+
+// TransformPoint maps a point from local to world space
+func (t *Transform) TransformPoint(p Vec3) Vec3 {
+	return t.LocalToWorld().MultiplyVec3Point(p)
+}
+
+// TransformVector maps a direction from local to world space (ignoring translation)
+func (t *Transform) TransformVector(v Vec3) Vec3 {
+	return t.LocalToWorld().MultiplyVec3Vector(v)
+}
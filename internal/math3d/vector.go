@@ -67,6 +67,36 @@ func (v Vec2) Normalize() Vec2 {
 	return Vec2{X: v.X / length, Y: v.Y / length}
 }
 
+// Mul returns the component-wise (Hadamard) product
+func (v Vec2) Mul(other Vec2) Vec2 {
+	return Vec2{X: v.X * other.X, Y: v.Y * other.Y}
+}
+
+// Min returns the component-wise minimum
+func (v Vec2) Min(other Vec2) Vec2 {
+	return Vec2{X: min(v.X, other.X), Y: min(v.Y, other.Y)}
+}
+
+// Max returns the component-wise maximum
+func (v Vec2) Max(other Vec2) Vec2 {
+	return Vec2{X: max(v.X, other.X), Y: max(v.Y, other.Y)}
+}
+
+// Clamp limits each component to the range given by the matching components of lo and hi
+func (v Vec2) Clamp(lo, hi Vec2) Vec2 {
+	return v.Max(lo).Min(hi)
+}
+
+// Abs returns the component-wise absolute value
+func (v Vec2) Abs() Vec2 {
+	return Vec2{X: float32(math.Abs(float64(v.X))), Y: float32(math.Abs(float64(v.Y)))}
+}
+
+// Floor returns the component-wise floor
+func (v Vec2) Floor() Vec2 {
+	return Vec2{X: float32(math.Floor(float64(v.X))), Y: float32(math.Floor(float64(v.Y)))}
+}
+
 // Vec3 methods
 func (v Vec3) Add(other Vec3) Vec3 {
 	return Vec3{X: v.X + other.X, Y: v.Y + other.Y, Z: v.Z + other.Z}
@@ -112,6 +142,36 @@ func (v Vec3) Distance(other Vec3) float32 {
 	return v.Sub(other).Length()
 }
 
+// Mul returns the component-wise (Hadamard) product
+func (v Vec3) Mul(other Vec3) Vec3 {
+	return Vec3{X: v.X * other.X, Y: v.Y * other.Y, Z: v.Z * other.Z}
+}
+
+// Min returns the component-wise minimum
+func (v Vec3) Min(other Vec3) Vec3 {
+	return Vec3{X: min(v.X, other.X), Y: min(v.Y, other.Y), Z: min(v.Z, other.Z)}
+}
+
+// Max returns the component-wise maximum
+func (v Vec3) Max(other Vec3) Vec3 {
+	return Vec3{X: max(v.X, other.X), Y: max(v.Y, other.Y), Z: max(v.Z, other.Z)}
+}
+
+// Clamp limits each component to the range given by the matching components of lo and hi
+func (v Vec3) Clamp(lo, hi Vec3) Vec3 {
+	return v.Max(lo).Min(hi)
+}
+
+// Abs returns the component-wise absolute value
+func (v Vec3) Abs() Vec3 {
+	return Vec3{X: float32(math.Abs(float64(v.X))), Y: float32(math.Abs(float64(v.Y))), Z: float32(math.Abs(float64(v.Z)))}
+}
+
+// Floor returns the component-wise floor
+func (v Vec3) Floor() Vec3 {
+	return Vec3{X: float32(math.Floor(float64(v.X))), Y: float32(math.Floor(float64(v.Y))), Z: float32(math.Floor(float64(v.Z)))}
+}
+
 // Vec4 methods
 func (v Vec4) Add(other Vec4) Vec4 {
 	return Vec4{X: v.X + other.X, Y: v.Y + other.Y, Z: v.Z + other.Z, W: v.W + other.W}
@@ -145,6 +205,36 @@ func (v Vec4) Normalize() Vec4 {
 	return Vec4{X: v.X / length, Y: v.Y / length, Z: v.Z / length, W: v.W / length}
 }
 
+// Mul returns the component-wise (Hadamard) product
+func (v Vec4) Mul(other Vec4) Vec4 {
+	return Vec4{X: v.X * other.X, Y: v.Y * other.Y, Z: v.Z * other.Z, W: v.W * other.W}
+}
+
+// Min returns the component-wise minimum
+func (v Vec4) Min(other Vec4) Vec4 {
+	return Vec4{X: min(v.X, other.X), Y: min(v.Y, other.Y), Z: min(v.Z, other.Z), W: min(v.W, other.W)}
+}
+
+// Max returns the component-wise maximum
+func (v Vec4) Max(other Vec4) Vec4 {
+	return Vec4{X: max(v.X, other.X), Y: max(v.Y, other.Y), Z: max(v.Z, other.Z), W: max(v.W, other.W)}
+}
+
+// Clamp limits each component to the range given by the matching components of lo and hi
+func (v Vec4) Clamp(lo, hi Vec4) Vec4 {
+	return v.Max(lo).Min(hi)
+}
+
+// Abs returns the component-wise absolute value
+func (v Vec4) Abs() Vec4 {
+	return Vec4{X: float32(math.Abs(float64(v.X))), Y: float32(math.Abs(float64(v.Y))), Z: float32(math.Abs(float64(v.Z))), W: float32(math.Abs(float64(v.W)))}
+}
+
+// Floor returns the component-wise floor
+func (v Vec4) Floor() Vec4 {
+	return Vec4{X: float32(math.Floor(float64(v.X))), Y: float32(math.Floor(float64(v.Y))), Z: float32(math.Floor(float64(v.Z))), W: float32(math.Floor(float64(v.W)))}
+}
+
 // ToVec3 converts Vec4 to Vec3 by dropping the W component
 func (v Vec4) ToVec3() Vec3 {
 	return Vec3{X: v.X, Y: v.Y, Z: v.Z}
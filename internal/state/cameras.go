@@ -0,0 +1,235 @@
+package state
+
+import (
+	"fmt"
+)
+
+// State holds several named cameras, one of which is active: it is the one
+// state updates show and mouse, keyboard, touch and gamepad input move. The
+// reflection debug camera is not stored but derived from the main camera, so
+// while it is active, input moves the main camera and the view follows it
+// through the water.
+
+// Built-in camera names
+const (
+	CameraMain            = "main"             // The viewer's camera, restored from checkpoints
+	CameraOverview        = "overview"         // Looks down on the whole scene
+	CameraReflectionDebug = "reflection-debug" // The main camera mirrored in the water, as the reflection pass sees the scene
+)
+
+// MaxCameras is the number of cameras State can hold, built-in ones included
+const MaxCameras = 16
+
+// NewOverviewCamera creates the camera looking down on the whole scene
+func NewOverviewCamera() *Camera {
+	c := NewCamera()
+	c.distance = 80
+	c.pitch = 1.2
+	c.updatePosition()
+	return c
+}
+
+// SetActiveCameraMessage makes the named camera the active one
+type SetActiveCameraMessage struct {
+	Name string
+}
+
+func (*SetActiveCameraMessage) message() {}
+
+// SetCameraPoseMessage places the named camera, adding it if there is no
+// camera by that name
+type SetCameraPoseMessage struct {
+	Name string
+	Pose CameraPreset
+}
+
+func (*SetCameraPoseMessage) message() {}
+
+// RemoveCameraMessage removes a camera added with SetCameraPoseMessage
+type RemoveCameraMessage struct {
+	Name string
+}
+
+func (*RemoveCameraMessage) message() {}
+
+// CameraMessage applies Message to the named camera rather than the active
+// one. Only messages that move a camera by a delta can be sent to a named
+// camera: zoom, touch gestures, camera mode changes and preset recalls.
+type CameraMessage struct {
+	Camera  string
+	Message Message
+}
+
+func (*CameraMessage) message() {}
+
+// GetActiveCamera returns the name of the active camera
+func (s *State) GetActiveCamera() string {
+	return s.Snapshot().ActiveCamera
+}
+
+// GetCameras returns the poses of every camera by name
+func (s *State) GetCameras() map[string]CameraPreset {
+	saved := s.Snapshot().Cameras
+	cameras := make(map[string]CameraPreset, len(saved))
+	for name, pose := range saved {
+		cameras[name] = pose
+	}
+	return cameras
+}
+
+// controlledCamera returns the stored camera that moves when the named camera
+// is controlled. The reflection debug camera follows the main camera.
+func (s *State) controlledCamera(name string) (*Camera, error) {
+	if name == CameraReflectionDebug {
+		name = CameraMain
+	}
+	camera, ok := s.cameras[name]
+	if !ok {
+		return nil, fmt.Errorf("camera '%s' not found", name)
+	}
+	return camera, nil
+}
+
+// view returns the camera the named camera sees through. The lock must be held.
+func (s *State) view(name string) *Camera {
+	if name == CameraReflectionDebug {
+		return s.cameras[CameraMain].mirrored()
+	}
+	return s.cameras[name]
+}
+
+// setActiveCamera makes the named camera the active one. The write lock must be held.
+func (s *State) setActiveCamera(name string) error {
+	camera, err := s.controlledCamera(name)
+	if err != nil {
+		return err
+	}
+	if camera != s.camera {
+		// Input held on the old camera must not carry over to the new one
+		s.flight = nil
+		clear(s.keys)
+		s.gamepad = gamepad{}
+	}
+	s.camera = camera
+	s.active = name
+	return nil
+}
+
+// setCameraPose places the named camera, adding it if needed. The write lock must be held.
+func (s *State) setCameraPose(name string, pose CameraPreset) error {
+	if name == CameraReflectionDebug {
+		return fmt.Errorf("camera '%s' follows the main camera and cannot be placed", name)
+	}
+	camera, ok := s.cameras[name]
+	if !ok {
+		if len(s.cameras) >= MaxCameras {
+			return fmt.Errorf("at most %d cameras are supported", MaxCameras)
+		}
+		camera = NewCamera()
+		s.cameras[name] = camera
+	}
+	camera.setPose(pose)
+	return nil
+}
+
+// removeCamera removes a camera that is not built in. The write lock must be held.
+func (s *State) removeCamera(name string) error {
+	switch name {
+	case CameraMain, CameraOverview, CameraReflectionDebug:
+		return fmt.Errorf("built-in camera '%s' cannot be removed", name)
+	}
+	if _, ok := s.cameras[name]; !ok {
+		return fmt.Errorf("camera '%s' not found", name)
+	}
+	if s.active == name {
+		s.setActiveCamera(CameraMain)
+	}
+	delete(s.cameras, name)
+	return nil
+}
+
+// updateCamera applies a message to the named camera. The write lock must be held.
+func (s *State) updateCamera(m *CameraMessage) error {
+	camera, err := s.controlledCamera(m.Camera)
+	if err != nil {
+		return err
+	}
+	// Point the active camera at the named one while applying the message
+	active := s.camera
+	s.camera = camera
+	defer func() { s.camera = active }()
+
+	switch inner := m.Message.(type) {
+	case *ZoomMessage:
+		camera.Zoom(inner.Delta)
+	case *TouchOrbitMessage, *PinchZoomMessage, *TwoFingerRotateMessage:
+		s.applyTouch(inner)
+	case *SetCameraModeMessage:
+		s.setCameraMode(inner.Mode)
+	case *RecallCameraPresetMessage:
+		return s.recallCameraPreset(inner.Name)
+	}
+	return nil
+}
+
+// validateCameraName reports an error if name cannot be placed as a camera.
+// The main camera is saved apart from the others and the reflection debug
+// camera follows it, so neither can be placed by name.
+func validateCameraName(name string) error {
+	if name == "" || name == CameraMain || name == CameraReflectionDebug {
+		return fmt.Errorf("invalid camera name '%s'", name)
+	}
+	return nil
+}
+
+// validateCameraMessage reports an error if the wrapped message cannot be
+// sent to a named camera or is invalid itself
+func validateCameraMessage(m *CameraMessage) error {
+	switch m.Message.(type) {
+	case *ZoomMessage, *TouchOrbitMessage, *PinchZoomMessage, *TwoFingerRotateMessage,
+		*SetCameraModeMessage, *RecallCameraPresetMessage:
+		return ValidateMessage(m.Message)
+	}
+	return fmt.Errorf("%T cannot be sent to a named camera", m.Message)
+}
+
+// cameraPoses returns the pose of every camera, the reflection debug camera
+// included. The lock must be held.
+func (s *State) cameraPoses() map[string]CameraPreset {
+	poses := make(map[string]CameraPreset, len(s.cameras)+1)
+	for name, camera := range s.cameras {
+		poses[name] = camera.pose()
+	}
+	poses[CameraReflectionDebug] = s.view(CameraReflectionDebug).pose()
+	return poses
+}
+
+// pose returns where the camera looks and from how far
+func (c *Camera) pose() CameraPreset {
+	return CameraPreset{
+		Target:   c.target,
+		Distance: c.distance,
+		Yaw:      c.yaw,
+		Pitch:    c.pitch,
+	}
+}
+
+// setPose places the camera orbiting pose.Target, within its distance and
+// pitch limits
+func (c *Camera) setPose(pose CameraPreset) {
+	c.mode = CameraOrbit
+	c.target = pose.Target
+	c.yaw = pose.Yaw
+	c.distance = max(min(pose.Distance, c.maxDistance), c.minDistance)
+	c.pitch = max(min(pose.Pitch, c.maxPitch), c.minPitch)
+	c.updatePosition()
+}
+
+// mirrored returns a copy of the camera reflected in the water surface
+func (c *Camera) mirrored() *Camera {
+	m := *c
+	m.position.Y = 2*WaterLevel - c.position.Y
+	m.target.Y = 2*WaterLevel - c.target.Y
+	m.pitch = -c.pitch
+	return &m
+}
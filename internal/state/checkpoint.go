@@ -0,0 +1,424 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ku3ppi/webgl-water/internal/codec"
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// checkpointMagic identifies a state checkpoint file
+var checkpointMagic = [4]byte{'W', 'G', 'C', 'P'}
+
+// checkpointVersion is the current checkpoint layout version
+const checkpointVersion uint16 = 2
+
+// maxCheckpointString is the longest string a checkpoint can hold, in bytes
+const maxCheckpointString = 1 << 12
+
+// checkpointHeader precedes the checkpoint payload
+type checkpointHeader struct {
+	Magic   [4]byte
+	Version uint16
+}
+
+// checkpointV1 is the fixed-size little-endian checkpoint payload
+type checkpointV1 struct {
+	Clock           float32
+	CameraTarget    math3d.Vec3
+	CameraDistance  float32
+	CameraYaw       float32
+	CameraPitch     float32
+	Reflectivity    float32
+	FresnelStrength float32
+	WaveSpeed       float32
+	UseReflection   bool
+	UseRefraction   bool
+	Scenery         bool
+}
+
+// isFinite reports whether every float in the payload is neither NaN nor infinite
+func (p *checkpointV1) isFinite() bool {
+	for _, v := range []float32{
+		p.Clock, p.CameraDistance, p.CameraYaw, p.CameraPitch,
+		p.Reflectivity, p.FresnelStrength, p.WaveSpeed,
+	} {
+		if !math3d.IsFiniteFloat(v) {
+			return false
+		}
+	}
+	return p.CameraTarget.IsFinite()
+}
+
+// checkpointV2 extends the v1 payload with the render parameters. The
+// variable-length fields follow it, written by writeCheckpointV2.
+type checkpointV2 struct {
+	checkpointV1
+	Exposure float32
+	Gamma    float32
+}
+
+// checkpoint is a decoded checkpoint of any version
+type checkpoint struct {
+	checkpointV2
+	ToneMapping ToneMapping
+	Layers      []SurfaceLayer
+	Cameras     map[string]CameraPreset // Poses of the cameras other than main, whose pose is in checkpointV1
+	Active      string                  // Name of the active camera
+}
+
+// checkpointLayer is the fixed-size part of a surface layer in a checkpoint;
+// its name, texture and blend mode follow it
+type checkpointLayer struct {
+	Tint    math3d.Vec3
+	Opacity float32
+	Scale   float32
+	Drift   float32
+}
+
+// validate reports an error if the checkpoint cannot be restored
+func (c *checkpoint) validate() error {
+	if !c.isFinite() || !math3d.IsFiniteFloat(c.Exposure) {
+		return fmt.Errorf("checkpoint contains NaN or infinite values")
+	}
+	if err := validateGamma(c.Gamma); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	if !c.ToneMapping.Valid() {
+		return fmt.Errorf("checkpoint has unknown tone mapping operator '%s'", c.ToneMapping)
+	}
+	names := make(map[string]bool, len(c.Layers))
+	for _, layer := range c.Layers {
+		if err := layer.Validate(); err != nil {
+			return fmt.Errorf("checkpoint: %w", err)
+		}
+		if names[layer.Name] {
+			return fmt.Errorf("checkpoint has layer '%s' twice", layer.Name)
+		}
+		names[layer.Name] = true
+	}
+	if len(c.Cameras) >= MaxCameras {
+		return fmt.Errorf("checkpoint has %d cameras, at most %d are supported", len(c.Cameras)+1, MaxCameras)
+	}
+	for name, pose := range c.Cameras {
+		if err := validateCameraName(name); err != nil {
+			return fmt.Errorf("checkpoint: %w", err)
+		}
+		if err := pose.Validate(); err != nil {
+			return fmt.Errorf("checkpoint camera '%s': %w", name, err)
+		}
+	}
+	if _, ok := c.Cameras[c.Active]; !ok && c.Active != CameraMain && c.Active != CameraReflectionDebug {
+		return fmt.Errorf("checkpoint has unknown active camera '%s'", c.Active)
+	}
+	return nil
+}
+
+// checkpointWriter writes little-endian checkpoint fields, keeping the first error
+type checkpointWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (cw *checkpointWriter) write(v interface{}) {
+	if cw.err == nil {
+		cw.err = binary.Write(cw.w, binary.LittleEndian, v)
+	}
+}
+
+// string writes s prefixed with its length
+func (cw *checkpointWriter) string(s string) {
+	if len(s) > maxCheckpointString {
+		cw.err = fmt.Errorf("checkpoint string of %d bytes is too long", len(s))
+		return
+	}
+	cw.write(uint16(len(s)))
+	cw.write([]byte(s))
+}
+
+// checkpointReader reads fields written by checkpointWriter, keeping the first error
+type checkpointReader struct {
+	r   io.Reader
+	err error
+}
+
+func (cr *checkpointReader) read(v interface{}) {
+	if cr.err == nil {
+		cr.err = binary.Read(cr.r, binary.LittleEndian, v)
+	}
+}
+
+// string reads a string written by checkpointWriter.string
+func (cr *checkpointReader) string() string {
+	var length uint16
+	cr.read(&length)
+	if cr.err == nil && length > maxCheckpointString {
+		cr.err = fmt.Errorf("checkpoint string of %d bytes is too long", length)
+	}
+	if cr.err != nil {
+		return ""
+	}
+	data := make([]byte, length)
+	cr.read(data)
+	return string(data)
+}
+
+// WriteCheckpoint writes a compact binary checkpoint of the simulation state to w
+func (s *State) WriteCheckpoint(w io.Writer) error {
+	s.mu.RLock()
+	main := s.cameras[CameraMain]
+	payload := checkpoint{
+		checkpointV2: checkpointV2{
+			checkpointV1: checkpointV1{
+				Clock:           s.clock,
+				CameraTarget:    main.target,
+				CameraDistance:  main.distance,
+				CameraYaw:       main.yaw,
+				CameraPitch:     main.pitch,
+				Reflectivity:    s.water.Reflectivity,
+				FresnelStrength: s.water.FresnelStrength,
+				WaveSpeed:       s.water.WaveSpeed,
+				UseReflection:   s.water.UseReflection,
+				UseRefraction:   s.water.UseRefraction,
+				Scenery:         s.scenery,
+			},
+			Exposure: s.render.Exposure,
+			Gamma:    s.render.Gamma,
+		},
+		ToneMapping: s.render.ToneMapping,
+		Layers:      slices.Clone(s.layers),
+		Cameras:     make(map[string]CameraPreset, len(s.cameras)-1),
+		Active:      s.active,
+	}
+	for name, camera := range s.cameras {
+		if name != CameraMain {
+			payload.Cameras[name] = camera.pose()
+		}
+	}
+	s.mu.RUnlock()
+
+	header := checkpointHeader{Magic: checkpointMagic, Version: checkpointVersion}
+	if err := binary.Write(w, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("failed to write checkpoint header: %w", err)
+	}
+	if err := writeCheckpointV2(w, &payload); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// writeCheckpointV2 writes the fixed v2 payload followed by its variable-length fields
+func writeCheckpointV2(w io.Writer, c *checkpoint) error {
+	cw := &checkpointWriter{w: w}
+	cw.write(&c.checkpointV2)
+	cw.string(string(c.ToneMapping))
+	cw.write(uint8(len(c.Layers)))
+	for _, layer := range c.Layers {
+		cw.write(&checkpointLayer{Tint: layer.Tint, Opacity: layer.Opacity, Scale: layer.Scale, Drift: layer.Drift})
+		cw.string(layer.Name)
+		cw.string(layer.Texture)
+		cw.string(string(layer.Blend))
+	}
+	// Sorted so the same state always writes the same checkpoint
+	names := make([]string, 0, len(c.Cameras))
+	for name := range c.Cameras {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	cw.write(uint8(len(names)))
+	for _, name := range names {
+		pose := c.Cameras[name]
+		cw.string(name)
+		cw.write(&pose)
+	}
+	cw.string(c.Active)
+	return cw.err
+}
+
+// readCheckpointV2 reads a payload written by writeCheckpointV2
+func readCheckpointV2(r io.Reader, c *checkpoint) error {
+	cr := &checkpointReader{r: r}
+	cr.read(&c.checkpointV2)
+	c.ToneMapping = ToneMapping(cr.string())
+	var layers uint8
+	cr.read(&layers)
+	if cr.err == nil && int(layers) > MaxSurfaceLayers {
+		return fmt.Errorf("checkpoint has %d surface layers, at most %d are supported", layers, MaxSurfaceLayers)
+	}
+	for i := 0; i < int(layers) && cr.err == nil; i++ {
+		var fixed checkpointLayer
+		cr.read(&fixed)
+		c.Layers = append(c.Layers, SurfaceLayer{
+			Name:    cr.string(),
+			Texture: cr.string(),
+			Tint:    fixed.Tint,
+			Opacity: fixed.Opacity,
+			Scale:   fixed.Scale,
+			Drift:   fixed.Drift,
+			Blend:   LayerBlend(cr.string()),
+		})
+	}
+	var cameras uint8
+	cr.read(&cameras)
+	if cr.err == nil && int(cameras) >= MaxCameras {
+		return fmt.Errorf("checkpoint has %d cameras, at most %d are supported", int(cameras)+1, MaxCameras)
+	}
+	c.Cameras = make(map[string]CameraPreset, cameras)
+	for i := 0; i < int(cameras) && cr.err == nil; i++ {
+		name := cr.string()
+		var pose CameraPreset
+		cr.read(&pose)
+		c.Cameras[name] = pose
+	}
+	c.Active = cr.string()
+	return cr.err
+}
+
+// ReadCheckpoint restores the simulation state from a checkpoint produced by
+// WriteCheckpoint. Version 1 checkpoints restore the render parameters to
+// their defaults, remove the surface layers and leave cameras other than main
+// as they are.
+func (s *State) ReadCheckpoint(r io.Reader) error {
+	var header checkpointHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("failed to read checkpoint header: %w", err)
+	}
+	if header.Magic != checkpointMagic {
+		return fmt.Errorf("not a checkpoint file")
+	}
+
+	var payload checkpoint
+	switch header.Version {
+	case 1:
+		if err := binary.Read(r, binary.LittleEndian, &payload.checkpointV1); err != nil {
+			return fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+		render := NewRender()
+		payload.Exposure, payload.Gamma, payload.ToneMapping = render.Exposure, render.Gamma, render.ToneMapping
+		payload.Active = CameraMain
+	case 2:
+		if err := readCheckpointV2(r, &payload); err != nil {
+			return fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported checkpoint version %d", header.Version)
+	}
+	if err := payload.validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clock = payload.Clock
+	main := s.cameras[CameraMain]
+	main.target = payload.CameraTarget
+	main.distance = payload.CameraDistance
+	main.yaw = payload.CameraYaw
+	main.pitch = payload.CameraPitch
+	main.mode = CameraOrbit
+	main.updatePosition()
+	s.flight = nil
+	s.water.Reflectivity = payload.Reflectivity
+	s.water.FresnelStrength = payload.FresnelStrength
+	s.water.WaveSpeed = payload.WaveSpeed
+	s.water.UseReflection = payload.UseReflection
+	s.water.UseRefraction = payload.UseRefraction
+	s.scenery = payload.Scenery
+	s.render.Exposure = payload.Exposure
+	s.render.Gamma = payload.Gamma
+	s.render.ToneMapping = payload.ToneMapping
+	s.layers = payload.Layers
+	if payload.Cameras != nil {
+		s.restoreCameras(payload.Cameras, payload.Active)
+	}
+	s.bumpVersion()
+	s.publish()
+
+	return nil
+}
+
+// restoreCameras replaces the cameras other than main with the checkpointed
+// ones and activates the named camera. The write lock must be held.
+func (s *State) restoreCameras(poses map[string]CameraPreset, active string) {
+	cameras := map[string]*Camera{CameraMain: s.cameras[CameraMain], CameraOverview: NewOverviewCamera()}
+	for name, pose := range poses {
+		camera, ok := cameras[name]
+		if !ok {
+			camera = NewCamera()
+			cameras[name] = camera
+		}
+		camera.setPose(pose)
+	}
+	s.cameras = cameras
+	// validate checked that the active camera exists
+	s.setActiveCamera(active)
+}
+
+// SaveCheckpoint atomically writes a checkpoint compressed with c to path.
+// The checkpoint is written to a temporary file first so a crash never leaves a truncated file behind.
+func (s *State) SaveCheckpoint(path string, c codec.Codec) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder, err := c.NewWriter(writer)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compress checkpoint: %w", err)
+	}
+	if err := s.WriteCheckpoint(encoder); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compress checkpoint: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadCheckpoint restores the simulation state from the checkpoint file at path.
+// The codec is recognized from the file's first bytes; fallback is used for
+// codecs that cannot be recognized, such as brotli.
+func (s *State) LoadCheckpoint(path string, fallback codec.Codec) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	c := codec.None
+	if prefix, _ := reader.Peek(len(checkpointMagic)); !bytes.Equal(prefix, checkpointMagic[:]) {
+		if c, err = codec.Detect(reader, fallback); err != nil {
+			return fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+	}
+
+	decoder, err := c.NewReader(reader)
+	if err != nil {
+		return fmt.Errorf("failed to decompress checkpoint: %w", err)
+	}
+	defer decoder.Close()
+
+	return s.ReadCheckpoint(decoder)
+}
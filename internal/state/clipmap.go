@@ -0,0 +1,95 @@
+package state
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// Geometry clipmaps render a large ocean as nested square grids centered on the
+// camera. Every level has the same number of cells, and each level's cells are
+// twice as wide as the previous one, so detail falls off with distance while the
+// vertex count stays constant. Clients draw each level as one grid mesh scaled by
+// Spacing and moved to Origin, skipping the fragments inside Hole, which is
+// covered by the next finer level.
+//
+// Origins are snapped to twice the level's spacing so the grid only moves in
+// whole coarse cells and vertices never swim. The water is flat, so the finer
+// level's border needs no morphing to meet the coarser one without cracks.
+
+// ClipmapConfig sets up the clipmap layout
+type ClipmapConfig struct {
+	Levels      int     // Number of nested grids
+	Cells       int     // Grid cells along each side of every level, a multiple of 4 and at least 8
+	BaseSpacing float32 // Cell width of the finest level in world units
+}
+
+// DefaultClipmapConfig returns a layout reaching about 10 km from the camera
+func DefaultClipmapConfig() ClipmapConfig {
+	return ClipmapConfig{Levels: 8, Cells: 64, BaseSpacing: 1}
+}
+
+// Validate reports an error if the configuration cannot produce a layout
+func (c ClipmapConfig) Validate() error {
+	switch {
+	case c.Levels < 1 || c.Levels > 16:
+		return fmt.Errorf("clipmap levels must be between 1 and 16, got %d", c.Levels)
+	case c.Cells < 8 || c.Cells%4 != 0:
+		// Smaller grids could not contain the finer level after snapping
+		return fmt.Errorf("clipmap cells must be a multiple of 4 and at least 8, got %d", c.Cells)
+	case !math3d.IsFiniteFloat(c.BaseSpacing) || c.BaseSpacing <= 0:
+		return fmt.Errorf("clipmap base spacing must be positive, got %v", c.BaseSpacing)
+	}
+	return nil
+}
+
+// ClipmapLevel is the placement of one clipmap grid
+type ClipmapLevel struct {
+	Level   int          `json:"level"`
+	Spacing float32      `json:"spacing"`        // Cell width in world units
+	Origin  math3d.Vec2  `json:"origin"`         // Grid center on the XZ plane
+	Hole    *math3d.Vec4 `json:"hole,omitempty"` // minX, minZ, maxX, maxZ covered by the finer level
+}
+
+// Clipmap is the clipmap layout for the current camera position
+type Clipmap struct {
+	Cells  int            `json:"cells"`
+	Levels []ClipmapLevel `json:"levels"` // Finest first
+}
+
+// Layout places the clipmap levels around a camera at eye. Levels whose whole
+// grid is smaller than the camera's height above the water are dropped, since
+// their detail would be finer than a pixel; the finest remaining level has no hole.
+func (c ClipmapConfig) Layout(eye math3d.Vec3) Clipmap {
+	clipmap := Clipmap{Cells: c.Cells}
+	height := float32(math.Abs(float64(eye.Y)))
+
+	var finer *ClipmapLevel // The previous level placed, whose area is cut out of the next one
+	for level := 0; level < c.Levels; level++ {
+		spacing := c.BaseSpacing * float32(int(1)<<level)
+		extent := spacing * float32(c.Cells)
+		if extent < height && level < c.Levels-1 {
+			continue
+		}
+
+		snap := 2 * spacing
+		current := ClipmapLevel{
+			Level:   level,
+			Spacing: spacing,
+			Origin: math3d.NewVec2(
+				float32(math.Floor(float64(eye.X/snap)))*snap,
+				float32(math.Floor(float64(eye.Z/snap)))*snap,
+			),
+		}
+		if finer != nil {
+			half := finer.Spacing * float32(c.Cells) / 2
+			hole := math3d.NewVec4(finer.Origin.X-half, finer.Origin.Y-half, finer.Origin.X+half, finer.Origin.Y+half)
+			current.Hole = &hole
+		}
+
+		clipmap.Levels = append(clipmap.Levels, current)
+		finer = &current
+	}
+	return clipmap
+}
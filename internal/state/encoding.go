@@ -0,0 +1,123 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Messages are encoded as their type name and their fields as JSON, so
+// sessions can be recorded to files and replayed later.
+
+// messageTypes creates an empty message of each type by its encoded name
+var messageTypes = map[string]func() Message{
+	"mouse_down":           func() Message { return &MouseDownMessage{} },
+	"mouse_up":             func() Message { return &MouseUpMessage{} },
+	"mouse_move":           func() Message { return &MouseMoveMessage{} },
+	"zoom":                 func() Message { return &ZoomMessage{} },
+	"key_down":             func() Message { return &KeyDownMessage{} },
+	"key_up":               func() Message { return &KeyUpMessage{} },
+	"gamepad":              func() Message { return &GamepadMessage{} },
+	"touch_orbit":          func() Message { return &TouchOrbitMessage{} },
+	"pinch_zoom":           func() Message { return &PinchZoomMessage{} },
+	"two_finger_rotate":    func() Message { return &TwoFingerRotateMessage{} },
+	"set_camera_mode":      func() Message { return &SetCameraModeMessage{} },
+	"set_active_camera":    func() Message { return &SetActiveCameraMessage{} },
+	"set_camera_pose":      func() Message { return &SetCameraPoseMessage{} },
+	"remove_camera":        func() Message { return &RemoveCameraMessage{} },
+	"camera":               func() Message { return &CameraMessage{} },
+	"flythrough_start":     func() Message { return &StartFlyThroughMessage{} },
+	"flythrough_stop":      func() Message { return &StopFlyThroughMessage{} },
+	"save_camera_preset":   func() Message { return &SaveCameraPresetMessage{} },
+	"recall_camera_preset": func() Message { return &RecallCameraPresetMessage{} },
+	"delete_camera_preset": func() Message { return &DeleteCameraPresetMessage{} },
+	"set_reflectivity":     func() Message { return &SetReflectivityMessage{} },
+	"set_fresnel":          func() Message { return &SetFresnelMessage{} },
+	"set_wave_speed":       func() Message { return &SetWaveSpeedMessage{} },
+	"use_reflection":       func() Message { return &UseReflectionMessage{} },
+	"use_refraction":       func() Message { return &UseRefractionMessage{} },
+	"show_scenery":         func() Message { return &ShowSceneryMessage{} },
+	"set_exposure":         func() Message { return &SetExposureMessage{} },
+	"set_gamma":            func() Message { return &SetGammaMessage{} },
+	"set_tone_mapping":     func() Message { return &SetToneMappingMessage{} },
+	"set_surface_layer":    func() Message { return &SetSurfaceLayerMessage{} },
+	"remove_surface_layer": func() Message { return &RemoveSurfaceLayerMessage{} },
+	"xr_enter":             func() Message { return &EnterXRMessage{} },
+	"xr_exit":              func() Message { return &ExitXRMessage{} },
+	"xr_pose":              func() Message { return &XRPoseMessage{} },
+	"undo":                 func() Message { return &UndoMessage{} },
+	"redo":                 func() Message { return &RedoMessage{} },
+	"advance_clock":        func() Message { return &AdvanceClockMessage{} },
+}
+
+// messageNames is the encoded name of each message type
+var messageNames = func() map[reflect.Type]string {
+	names := make(map[reflect.Type]string, len(messageTypes))
+	for name, create := range messageTypes {
+		names[reflect.TypeOf(create())] = name
+	}
+	return names
+}()
+
+// EncodedMessage is a message with its type spelled out, ready for JSON
+type EncodedMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// EncodeMessage encodes msg with its type name
+func EncodeMessage(msg Message) (EncodedMessage, error) {
+	name, ok := messageNames[reflect.TypeOf(msg)]
+	if !ok {
+		return EncodedMessage{}, fmt.Errorf("cannot encode message of type %T", msg)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return EncodedMessage{}, fmt.Errorf("failed to encode %s message: %w", name, err)
+	}
+	return EncodedMessage{Type: name, Data: data}, nil
+}
+
+// Decode returns the message e encodes. It is not validated.
+func (e EncodedMessage) Decode() (Message, error) {
+	create, ok := messageTypes[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type '%s'", e.Type)
+	}
+	msg := create()
+	if len(e.Data) > 0 {
+		if err := json.Unmarshal(e.Data, msg); err != nil {
+			return nil, fmt.Errorf("failed to decode %s message: %w", e.Type, err)
+		}
+	}
+	return msg, nil
+}
+
+// encodedCameraMessage is a CameraMessage with its wrapped message encoded
+type encodedCameraMessage struct {
+	Camera  string
+	Message EncodedMessage
+}
+
+// MarshalJSON encodes the wrapped message with its type name
+func (m *CameraMessage) MarshalJSON() ([]byte, error) {
+	inner, err := EncodeMessage(m.Message)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encodedCameraMessage{Camera: m.Camera, Message: inner})
+}
+
+// UnmarshalJSON decodes a message encoded by MarshalJSON
+func (m *CameraMessage) UnmarshalJSON(data []byte) error {
+	var encoded encodedCameraMessage
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	inner, err := encoded.Message.Decode()
+	if err != nil {
+		return err
+	}
+	m.Camera, m.Message = encoded.Camera, inner
+	return nil
+}
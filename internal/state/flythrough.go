@@ -0,0 +1,168 @@
+package state
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// A fly-through moves the camera along a path of keyframes on every clock
+// tick, following Catmull-Rom splines through the keyframe positions and
+// targets. It overrides mouse and keyboard input until it ends, is stopped,
+// or the camera mode or a preset is chosen.
+
+// MaxFlyThroughKeyframes is the number of keyframes a fly-through path can have
+const MaxFlyThroughKeyframes = 256
+
+// Keyframe is where the camera is and what it looks at at one point of a fly-through
+type Keyframe struct {
+	Position math3d.Vec3 `json:"position"`
+	Target   math3d.Vec3 `json:"target"`
+	Time     float32     `json:"time"` // Milliseconds from the start of the path
+}
+
+// FlyThroughPath is a camera path through keyframes in time order
+type FlyThroughPath struct {
+	Keyframes []Keyframe `json:"keyframes"`
+	Loop      bool       `json:"loop"` // Start over after the last keyframe instead of stopping
+}
+
+// Validate reports an error if the path cannot be flown
+func (p FlyThroughPath) Validate() error {
+	if len(p.Keyframes) < 2 {
+		return fmt.Errorf("a fly-through needs at least 2 keyframes, got %d", len(p.Keyframes))
+	}
+	if len(p.Keyframes) > MaxFlyThroughKeyframes {
+		return fmt.Errorf("a fly-through can have at most %d keyframes, got %d", MaxFlyThroughKeyframes, len(p.Keyframes))
+	}
+	for i, k := range p.Keyframes {
+		if !k.Position.IsFinite() || !k.Target.IsFinite() || !math3d.IsFiniteFloat(k.Time) {
+			return fmt.Errorf("keyframe %d values must be finite", i)
+		}
+		if k.Position == k.Target {
+			return fmt.Errorf("keyframe %d looks at its own position", i)
+		}
+		if i == 0 && k.Time < 0 {
+			return fmt.Errorf("keyframe 0 time must not be negative, got %v", k.Time)
+		}
+		if i > 0 && k.Time <= p.Keyframes[i-1].Time {
+			return fmt.Errorf("keyframe %d time must be after keyframe %d", i, i-1)
+		}
+	}
+	return nil
+}
+
+// Duration returns the time of the last keyframe in milliseconds
+func (p FlyThroughPath) Duration() float32 {
+	return p.Keyframes[len(p.Keyframes)-1].Time
+}
+
+// Sample returns the camera position and target at time milliseconds into the
+// path. Before the first keyframe and after the last the camera holds still.
+func (p FlyThroughPath) Sample(time float32) (math3d.Vec3, math3d.Vec3) {
+	keys := p.Keyframes
+	last := len(keys) - 1
+	if time <= keys[0].Time {
+		return keys[0].Position, keys[0].Target
+	}
+	if time >= keys[last].Time {
+		return keys[last].Position, keys[last].Target
+	}
+
+	// keys[i] is the last keyframe at or before time
+	i := sort.Search(len(keys), func(i int) bool { return keys[i].Time > time }) - 1
+	k0, k1, k2, k3 := keys[max(i-1, 0)], keys[i], keys[i+1], keys[min(i+2, last)]
+	t := (time - k1.Time) / (k2.Time - k1.Time)
+	return math3d.CatmullRom(k0.Position, k1.Position, k2.Position, k3.Position, t),
+		math3d.CatmullRom(k0.Target, k1.Target, k2.Target, k3.Target, t)
+}
+
+// FlyThroughStatus is the progress of the current fly-through
+type FlyThroughStatus struct {
+	Active   bool    `json:"active"`
+	Loop     bool    `json:"loop"`
+	Elapsed  float32 `json:"elapsed"`  // Milliseconds into the path
+	Duration float32 `json:"duration"` // Milliseconds
+	Progress float32 `json:"progress"` // 0 at the start of the path, 1 at its end
+}
+
+// flight is a fly-through in progress
+type flight struct {
+	path    FlyThroughPath
+	elapsed float32 // Milliseconds into the path
+}
+
+// status returns the progress of f; a nil flight is inactive
+func (f *flight) status() FlyThroughStatus {
+	if f == nil {
+		return FlyThroughStatus{}
+	}
+	duration := f.path.Duration()
+	return FlyThroughStatus{
+		Active:   true,
+		Loop:     f.path.Loop,
+		Elapsed:  f.elapsed,
+		Duration: duration,
+		Progress: f.elapsed / duration,
+	}
+}
+
+// StartFlyThroughMessage starts flying the camera along a path, replacing any
+// fly-through in progress
+type StartFlyThroughMessage struct {
+	Path FlyThroughPath
+}
+
+func (*StartFlyThroughMessage) message() {}
+
+// StopFlyThroughMessage stops the fly-through, leaving the camera where it is
+type StopFlyThroughMessage struct{}
+
+func (*StopFlyThroughMessage) message() {}
+
+// GetFlyThrough returns the progress of the current fly-through
+func (s *State) GetFlyThrough() FlyThroughStatus {
+	return s.Snapshot().FlyThrough
+}
+
+// startFlyThrough starts flying along path from its first keyframe. The write
+// lock must be held.
+func (s *State) startFlyThrough(path FlyThroughPath) {
+	path.Keyframes = append([]Keyframe(nil), path.Keyframes...)
+	s.flight = &flight{path: path}
+	s.camera.lookFrom(path.Sample(0))
+}
+
+// advanceFlyThrough moves the camera deltaTime milliseconds further along the
+// fly-through and ends it after the last keyframe unless it loops. The write
+// lock must be held.
+func (s *State) advanceFlyThrough(deltaTime float32) {
+	f := s.flight
+	f.elapsed += deltaTime
+	if duration := f.path.Duration(); f.elapsed >= duration {
+		if !f.path.Loop {
+			s.camera.lookFrom(f.path.Sample(duration))
+			s.flight = nil
+			return
+		}
+		f.elapsed = float32(math.Mod(float64(f.elapsed), float64(duration)))
+	}
+	s.camera.lookFrom(f.path.Sample(f.elapsed))
+}
+
+// lookFrom places the orbit camera at position, looking at target. The pitch
+// is kept within the camera's limits, as looking straight up or down leaves
+// the view without a horizon to orient it.
+func (c *Camera) lookFrom(position, target math3d.Vec3) {
+	distance, yaw, pitch := math3d.CartesianToSpherical(position.Sub(target))
+	if distance == 0 {
+		return
+	}
+	c.mode = CameraOrbit
+	c.target = target
+	c.distance, c.yaw = distance, yaw
+	c.pitch = max(min(pitch, c.maxPitch), c.minPitch)
+	c.updatePosition()
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// Gamepad sticks set camera velocities rather than moving it by a delta: the
+// last reported axes orbit and zoom the camera on every clock tick. Like held
+// keys, the axes reset to rest once GamepadTimeout passes without a report,
+// so a client that goes away mid-push cannot leave the camera drifting.
+
+// Gamepad settings
+const (
+	GamepadOrbitSpeed = 2.0  // Radians per second at full deflection
+	GamepadZoomSpeed  = 30.0 // Units per second at full deflection
+	GamepadDeadZone   = 0.15 // Deflection ignored around the rest position, as worn sticks drift
+
+	// GamepadTimeout is how long in clock milliseconds the axes last after
+	// their last report. Clients resend them well within it while deflected.
+	GamepadTimeout = 1000
+)
+
+// GamepadAxes are analog stick deflections between -1 and 1
+type GamepadAxes struct {
+	OrbitX float32 `json:"orbitX"` // Positive orbits right
+	OrbitY float32 `json:"orbitY"` // Positive orbits up, looking down on the target
+	Zoom   float32 `json:"zoom"`   // Positive moves away from the target
+}
+
+// Validate reports an error if an axis is not between -1 and 1
+func (a GamepadAxes) Validate() error {
+	for _, axis := range []struct {
+		name  string
+		value float32
+	}{{"orbitX", a.OrbitX}, {"orbitY", a.OrbitY}, {"zoom", a.Zoom}} {
+		if !math3d.IsFiniteFloat(axis.value) || axis.value < -1 || axis.value > 1 {
+			return fmt.Errorf("gamepad axis %s must be between -1 and 1, got %v", axis.name, axis.value)
+		}
+	}
+	return nil
+}
+
+// atRest reports whether every axis is within the dead zone
+func (a GamepadAxes) atRest() bool {
+	return deadZone(a.OrbitX) == 0 && deadZone(a.OrbitY) == 0 && deadZone(a.Zoom) == 0
+}
+
+// deadZone returns 0 within GamepadDeadZone of rest and rescales the rest of
+// the range so full deflection still reaches 1
+func deadZone(value float32) float32 {
+	switch {
+	case value > GamepadDeadZone:
+		return (value - GamepadDeadZone) / (1 - GamepadDeadZone)
+	case value < -GamepadDeadZone:
+		return (value + GamepadDeadZone) / (1 - GamepadDeadZone)
+	}
+	return 0
+}
+
+// GamepadMessage reports the current stick deflections
+type GamepadMessage struct {
+	Axes GamepadAxes
+}
+
+func (*GamepadMessage) message() {}
+
+// gamepad holds the last reported axes
+type gamepad struct {
+	axes     GamepadAxes
+	reported float32 // Clock time of the last report
+}
+
+// applyGamepad moves the camera by the stick velocities over deltaTime
+// milliseconds and resets axes that timed out. The write lock must be held.
+func (s *State) applyGamepad(deltaTime float32) {
+	if s.clock-s.gamepad.reported > GamepadTimeout {
+		s.gamepad.axes = GamepadAxes{}
+	}
+	axes := s.gamepad.axes
+	if axes.atRest() {
+		return
+	}
+
+	seconds := deltaTime / 1000
+	yaw, pitch := deadZone(axes.OrbitX), deadZone(axes.OrbitY)
+	if s.camera.mode == CameraWalk {
+		// Turning the eye is the opposite of orbiting it around the target
+		yaw, pitch = -yaw, -pitch
+	}
+	s.camera.OrbitLeftRight(yaw * GamepadOrbitSpeed * seconds)
+	s.camera.OrbitUpDown(pitch * GamepadOrbitSpeed * seconds)
+	s.camera.Zoom(deadZone(axes.Zoom) * GamepadZoomSpeed * seconds)
+}
@@ -0,0 +1,119 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Undo and redo cover the tunable scene settings: water, render parameters,
+// scenery and surface layers. Camera movement and held input are not
+// recorded, as they stream in continuously and would flood the history.
+// Every settings message that changes something records the settings from
+// before it; a new change after undoing discards what could be redone.
+
+// MaxUndoHistory is the number of changes that can be undone
+const MaxUndoHistory = 100
+
+// UndoMessage reverts the last settings change
+type UndoMessage struct{}
+
+func (*UndoMessage) message() {}
+
+// RedoMessage reapplies the last undone settings change
+type RedoMessage struct{}
+
+func (*RedoMessage) message() {}
+
+// History is how many changes can be undone and redone
+type History struct {
+	Undo int `json:"undo"`
+	Redo int `json:"redo"`
+}
+
+// settings is the part of the state undo and redo restore
+type settings struct {
+	water   Water
+	render  Render
+	scenery bool
+	layers  []SurfaceLayer
+}
+
+// equal reports whether a and b hold the same settings
+func (a settings) equal(b settings) bool {
+	return a.water == b.water && a.render == b.render &&
+		a.scenery == b.scenery && slices.Equal(a.layers, b.layers)
+}
+
+// GetHistory returns how many changes can be undone and redone
+func (s *State) GetHistory() History {
+	return s.Snapshot().History
+}
+
+// undoable reports whether msg changes the settings undo restores
+func undoable(msg Message) bool {
+	switch msg.(type) {
+	case *SetReflectivityMessage, *SetFresnelMessage, *SetWaveSpeedMessage,
+		*UseReflectionMessage, *UseRefractionMessage, *ShowSceneryMessage,
+		*SetExposureMessage, *SetGammaMessage, *SetToneMappingMessage,
+		*SetSurfaceLayerMessage, *RemoveSurfaceLayerMessage:
+		return true
+	}
+	return false
+}
+
+// settings returns a copy of the current settings. The lock must be held.
+func (s *State) settings() settings {
+	return settings{
+		water:   *s.water,
+		render:  *s.render,
+		scenery: s.scenery,
+		layers:  slices.Clone(s.layers),
+	}
+}
+
+// restore replaces the current settings. The write lock must be held.
+func (s *State) restore(saved settings) {
+	*s.water = saved.water
+	*s.render = saved.render
+	s.scenery = saved.scenery
+	s.layers = slices.Clone(saved.layers)
+}
+
+// record pushes the settings from before a change onto the undo history unless
+// the change left them as they were, and forgets undone changes. The write
+// lock must be held.
+func (s *State) record(before settings) {
+	if before.equal(s.settings()) {
+		return
+	}
+	if len(s.undo) == MaxUndoHistory {
+		s.undo = slices.Delete(s.undo, 0, 1)
+	}
+	s.undo = append(s.undo, before)
+	s.redo = nil
+}
+
+// undoChange restores the settings from before the last change. The write
+// lock must be held.
+func (s *State) undoChange() error {
+	if len(s.undo) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	last := len(s.undo) - 1
+	s.redo = append(s.redo, s.settings())
+	s.restore(s.undo[last])
+	s.undo = s.undo[:last]
+	return nil
+}
+
+// redoChange reapplies the last undone change. The write lock must be held.
+func (s *State) redoChange() error {
+	if len(s.redo) == 0 {
+		return fmt.Errorf("nothing to redo")
+	}
+	last := len(s.redo) - 1
+	s.undo = append(s.undo, s.settings())
+	s.restore(s.redo[last])
+	s.redo = s.redo[:last]
+	return nil
+}
@@ -0,0 +1,134 @@
+package state
+
+import (
+	"math"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// Held keys move the camera on every clock tick: W/A/S/D pan the camera target
+// over the ground plane, Q/E orbit around it and the up/down arrows pitch. In
+// walk mode W/A/S/D walk and the other keys turn and tilt the view instead.
+// A key counts as held until KeyUp, or until KeyHoldTimeout passes without a
+// KeyDown repeat, so a client that goes away mid-press cannot leave the camera
+// drifting.
+
+// Key identifies a keyboard key by its KeyboardEvent.code, which does not
+// depend on the keyboard layout
+type Key string
+
+// Keys that control the camera
+const (
+	KeyW         Key = "KeyW"      // Pan forward
+	KeyA         Key = "KeyA"      // Pan left
+	KeyS         Key = "KeyS"      // Pan back
+	KeyD         Key = "KeyD"      // Pan right
+	KeyQ         Key = "KeyQ"      // Orbit left
+	KeyE         Key = "KeyE"      // Orbit right
+	KeyArrowUp   Key = "ArrowUp"   // Pitch up, looking down on the target
+	KeyArrowDown Key = "ArrowDown" // Pitch down
+)
+
+// Valid reports whether k is a key that controls the camera
+func (k Key) Valid() bool {
+	switch k {
+	case KeyW, KeyA, KeyS, KeyD, KeyQ, KeyE, KeyArrowUp, KeyArrowDown:
+		return true
+	}
+	return false
+}
+
+// Camera speeds while a key is held
+const (
+	KeyPanSpeed   = 10.0 // Units per second
+	KeyOrbitSpeed = 1.5  // Radians per second
+	KeyPitchSpeed = 1.0  // Radians per second
+
+	// KeyHoldTimeout is how long in clock milliseconds a key stays held after its
+	// last KeyDown. Browsers repeat KeyDown well within it while a key is held.
+	KeyHoldTimeout = 1000
+)
+
+// maxTargetRange bounds how far the camera target can be panned from the origin
+const maxTargetRange = 100.0
+
+// KeyDownMessage represents a key press, or a repeat while the key is held
+type KeyDownMessage struct {
+	Key Key
+}
+
+func (*KeyDownMessage) message() {}
+
+// KeyUpMessage represents a key release
+type KeyUpMessage struct {
+	Key Key
+}
+
+func (*KeyUpMessage) message() {}
+
+// applyHeldKeys moves the camera by the keys held during deltaTime milliseconds
+// and releases keys that timed out. It reports whether the camera moved. The
+// write lock must be held.
+func (s *State) applyHeldKeys(deltaTime float32) bool {
+	var right, forward, yaw, pitch float32
+	for key, pressed := range s.keys {
+		if s.clock-pressed > KeyHoldTimeout {
+			delete(s.keys, key)
+			continue
+		}
+		switch key {
+		case KeyW:
+			forward++
+		case KeyS:
+			forward--
+		case KeyD:
+			right++
+		case KeyA:
+			right--
+		case KeyE:
+			yaw++
+		case KeyQ:
+			yaw--
+		case KeyArrowUp:
+			pitch++
+		case KeyArrowDown:
+			pitch--
+		}
+	}
+	if right == 0 && forward == 0 && yaw == 0 && pitch == 0 {
+		return false
+	}
+
+	seconds := deltaTime / 1000
+	if s.camera.mode == CameraWalk {
+		// Turning the eye is the opposite of orbiting it around the target
+		s.camera.Walk(right*KeyWalkSpeed*seconds, forward*KeyWalkSpeed*seconds, s.groundAt)
+		yaw, pitch = -yaw, -pitch
+	} else {
+		s.camera.Pan(right*KeyPanSpeed*seconds, forward*KeyPanSpeed*seconds)
+	}
+	s.camera.OrbitLeftRight(yaw * KeyOrbitSpeed * seconds)
+	s.camera.OrbitUpDown(pitch * KeyPitchSpeed * seconds)
+	return true
+}
+
+// Pan moves the camera target over the ground plane, right and forward as seen
+// from the camera
+func (c *Camera) Pan(right, forward float32) {
+	c.target = c.target.Add(horizontalOffset(c.yaw, right, forward))
+
+	horizontal := math3d.NewVec3(c.target.X, 0, c.target.Z)
+	if length := horizontal.Length(); length > maxTargetRange {
+		horizontal = horizontal.Scale(maxTargetRange / length)
+		c.target.X, c.target.Z = horizontal.X, horizontal.Z
+	}
+	c.updatePosition()
+}
+
+// horizontalOffset returns the offset right and forward over the ground plane
+// as seen from a camera at yaw
+func horizontalOffset(yaw, right, forward float32) math3d.Vec3 {
+	sin, cos := float32(math.Sin(float64(yaw))), float32(math.Cos(float64(yaw)))
+	// The camera looks along -(sin, 0, cos); its right is (cos, 0, -sin)
+	return math3d.NewVec3(right*cos-forward*sin, 0, -right*sin-forward*cos)
+}
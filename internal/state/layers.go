@@ -0,0 +1,124 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// MaxSurfaceLayers is the number of surface layers the water shader can composite
+const MaxSurfaceLayers = 4
+
+// LayerBlend selects how a surface layer is composited over the water
+type LayerBlend string
+
+// Supported layer blend modes
+const (
+	LayerBlendAlpha    LayerBlend = "alpha"    // Covers the water, e.g. foam or floating debris
+	LayerBlendMultiply LayerBlend = "multiply" // Darkens and tints the water, e.g. a muddy harbor
+	LayerBlendAdd      LayerBlend = "add"      // Brightens the water, e.g. an iridescent oil sheen
+)
+
+// Valid reports whether b is a supported blend mode
+func (b LayerBlend) Valid() bool {
+	switch b {
+	case LayerBlendAlpha, LayerBlendMultiply, LayerBlendAdd:
+		return true
+	}
+	return false
+}
+
+// SurfaceLayer is a textured layer composited over the water material,
+// such as an oil slick, a foam tint or floating debris
+type SurfaceLayer struct {
+	Name    string      `json:"name"`
+	Texture string      `json:"texture"` // Name of a registered texture
+	Tint    math3d.Vec3 `json:"tint"`    // Multiplied with the texture color
+	Opacity float32     `json:"opacity"` // 0 hides the layer, 1 applies it fully
+	Scale   float32     `json:"scale"`   // Texture repeats across the water plane
+	Drift   float32     `json:"drift"`   // Texture scroll speed relative to the wave speed
+	Blend   LayerBlend  `json:"blend"`
+}
+
+// NewSurfaceLayer creates an opaque, untinted alpha-blended layer
+func NewSurfaceLayer(name, texture string) SurfaceLayer {
+	return SurfaceLayer{
+		Name:    name,
+		Texture: texture,
+		Tint:    math3d.NewVec3(1, 1, 1),
+		Opacity: 1,
+		Scale:   1,
+		Drift:   1,
+		Blend:   LayerBlendAlpha,
+	}
+}
+
+// Validate reports an error if the layer cannot be rendered
+func (l SurfaceLayer) Validate() error {
+	if l.Name == "" {
+		return fmt.Errorf("layer name must not be empty")
+	}
+	if l.Texture == "" {
+		return fmt.Errorf("layer '%s' needs a texture", l.Name)
+	}
+	if !l.Blend.Valid() {
+		return fmt.Errorf("layer '%s' has unknown blend mode '%s'", l.Name, l.Blend)
+	}
+	if !l.Tint.IsFinite() || !math3d.IsFiniteFloat(l.Opacity) ||
+		!math3d.IsFiniteFloat(l.Scale) || !math3d.IsFiniteFloat(l.Drift) {
+		return fmt.Errorf("layer '%s' values must be finite", l.Name)
+	}
+	if l.Opacity < 0 || l.Opacity > 1 {
+		return fmt.Errorf("layer '%s' opacity must be between 0 and 1, got %v", l.Name, l.Opacity)
+	}
+	if l.Scale <= 0 {
+		return fmt.Errorf("layer '%s' scale must be positive, got %v", l.Name, l.Scale)
+	}
+	return nil
+}
+
+// GetSurfaceLayers returns a copy of the surface layers in compositing order
+func (s *State) GetSurfaceLayers() []SurfaceLayer {
+	return append([]SurfaceLayer(nil), s.Snapshot().Layers...)
+}
+
+// setSurfaceLayer replaces the layer with the same name or appends a new one on top.
+// The write lock must be held.
+func (s *State) setSurfaceLayer(layer SurfaceLayer) error {
+	for i := range s.layers {
+		if s.layers[i].Name == layer.Name {
+			s.layers[i] = layer
+			return nil
+		}
+	}
+	if len(s.layers) >= MaxSurfaceLayers {
+		return fmt.Errorf("at most %d surface layers are supported", MaxSurfaceLayers)
+	}
+	s.layers = append(s.layers, layer)
+	return nil
+}
+
+// removeSurfaceLayer deletes a layer by name. The write lock must be held.
+func (s *State) removeSurfaceLayer(name string) error {
+	for i := range s.layers {
+		if s.layers[i].Name == name {
+			s.layers = append(s.layers[:i], s.layers[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("layer '%s' not found", name)
+}
+
+// SetSurfaceLayerMessage adds a surface layer or replaces the one with the same name
+type SetSurfaceLayerMessage struct {
+	Layer SurfaceLayer
+}
+
+func (*SetSurfaceLayerMessage) message() {}
+
+// RemoveSurfaceLayerMessage removes a surface layer
+type RemoveSurfaceLayerMessage struct {
+	Name string
+}
+
+func (*RemoveSurfaceLayerMessage) message() {}
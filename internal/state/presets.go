@@ -0,0 +1,174 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// MaxCameraPresets is the number of camera presets that can be saved
+const MaxCameraPresets = 64
+
+// CameraPreset is an orbit camera viewpoint, as saved in presets and used to
+// place named cameras
+type CameraPreset struct {
+	Target   math3d.Vec3 `json:"target"`
+	Distance float32     `json:"distance"`
+	Yaw      float32     `json:"yaw"`   // Radians
+	Pitch    float32     `json:"pitch"` // Radians
+}
+
+// Validate reports an error if the preset carries a NaN or infinite value
+func (p CameraPreset) Validate() error {
+	if !p.Target.IsFinite() || !math3d.IsFiniteFloat(p.Distance) ||
+		!math3d.IsFiniteFloat(p.Yaw) || !math3d.IsFiniteFloat(p.Pitch) {
+		return fmt.Errorf("camera preset values must be finite")
+	}
+	return nil
+}
+
+// SaveCameraPresetMessage saves the current camera under a name, replacing
+// any preset with the same name
+type SaveCameraPresetMessage struct {
+	Name string
+}
+
+func (*SaveCameraPresetMessage) message() {}
+
+// RecallCameraPresetMessage moves the camera to a saved preset
+type RecallCameraPresetMessage struct {
+	Name string
+}
+
+func (*RecallCameraPresetMessage) message() {}
+
+// DeleteCameraPresetMessage removes a saved preset
+type DeleteCameraPresetMessage struct {
+	Name string
+}
+
+func (*DeleteCameraPresetMessage) message() {}
+
+// GetCameraPresets returns a copy of the saved camera presets by name
+func (s *State) GetCameraPresets() map[string]CameraPreset {
+	saved := s.Snapshot().Presets
+	presets := make(map[string]CameraPreset, len(saved))
+	for name, preset := range saved {
+		presets[name] = preset
+	}
+	return presets
+}
+
+// SetCameraPresets replaces every saved camera preset
+func (s *State) SetCameraPresets(presets map[string]CameraPreset) error {
+	if len(presets) > MaxCameraPresets {
+		return fmt.Errorf("at most %d camera presets are supported", MaxCameraPresets)
+	}
+	copied := make(map[string]CameraPreset, len(presets))
+	for name, preset := range presets {
+		if err := validatePresetName(name); err != nil {
+			return err
+		}
+		if err := preset.Validate(); err != nil {
+			return fmt.Errorf("camera preset '%s': %w", name, err)
+		}
+		copied[name] = preset
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.presets = copied
+	s.bumpVersion()
+	s.publish()
+	return nil
+}
+
+// saveCameraPreset saves the current camera under name. The presets are
+// copied rather than modified, as snapshots share them. The write lock must
+// be held.
+func (s *State) saveCameraPreset(name string) error {
+	if _, ok := s.presets[name]; !ok && len(s.presets) >= MaxCameraPresets {
+		return fmt.Errorf("at most %d camera presets are supported", MaxCameraPresets)
+	}
+	presets := make(map[string]CameraPreset, len(s.presets)+1)
+	for n, preset := range s.presets {
+		presets[n] = preset
+	}
+	presets[name] = s.camera.pose()
+	s.presets = presets
+	return nil
+}
+
+// recallCameraPreset moves the camera to the named preset, orbiting its
+// target. The write lock must be held.
+func (s *State) recallCameraPreset(name string) error {
+	preset, ok := s.presets[name]
+	if !ok {
+		return fmt.Errorf("camera preset '%s' not found", name)
+	}
+	s.camera.setPose(preset)
+	return nil
+}
+
+// deleteCameraPreset removes the named preset. The write lock must be held.
+func (s *State) deleteCameraPreset(name string) error {
+	if _, ok := s.presets[name]; !ok {
+		return fmt.Errorf("camera preset '%s' not found", name)
+	}
+	presets := make(map[string]CameraPreset, len(s.presets))
+	for n, preset := range s.presets {
+		if n != name {
+			presets[n] = preset
+		}
+	}
+	s.presets = presets
+	return nil
+}
+
+// validatePresetName reports an error if name cannot name a camera preset
+func validatePresetName(name string) error {
+	if name == "" {
+		return fmt.Errorf("camera preset name must not be empty")
+	}
+	return nil
+}
+
+// SaveCameraPresets atomically writes the camera presets to path as JSON
+func (s *State) SaveCameraPresets(path string) error {
+	data, err := json.MarshalIndent(s.Snapshot().Presets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode camera presets: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create camera presets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write camera presets: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write camera presets: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadCameraPresets replaces the camera presets with those saved at path
+func (s *State) LoadCameraPresets(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var presets map[string]CameraPreset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return fmt.Errorf("failed to parse camera presets: %w", err)
+	}
+	return s.SetCameraPresets(presets)
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// Snapshot is the whole state at one instant. State builds a new one under its
+// write lock after every change, clock ticks included, so readers never see
+// half of an update. Snapshots are shared between readers and must not be
+// modified, including their slices.
+type Snapshot struct {
+	Clock      float32    // Milliseconds
+	Version    uint64     // See State.Version
+	Camera     CameraView // Of the active camera
+	Water      Water
+	Render     Render
+	Scenery    bool
+	Layers     []SurfaceLayer // In compositing order
+	XR         XR
+	Presets    map[string]CameraPreset // Camera presets by name
+	FlyThrough FlyThroughStatus
+	History    History
+
+	ActiveCamera string
+	Cameras      map[string]CameraPreset // Poses of every camera by name
+}
+
+// CameraView is where a camera is and what it looks at
+type CameraView struct {
+	Mode       CameraMode
+	Position   math3d.Vec3
+	ViewMatrix math3d.Mat4
+}
+
+// Snapshot returns the state as of the last change. It does not lock.
+func (s *State) Snapshot() *Snapshot {
+	return s.snapshot.Load()
+}
+
+// publish replaces the snapshot with one of the current state. The write lock must be held.
+func (s *State) publish() {
+	xr := *s.xr
+	xr.Views = append([]XRView(nil), s.xr.Views...)
+	xr.Controllers = append([]XRController(nil), s.xr.Controllers...)
+	xr.grabs = nil
+
+	view := s.view(s.active)
+	s.snapshot.Store(&Snapshot{
+		Clock:   s.clock,
+		Version: s.version,
+		Camera: CameraView{
+			Mode:       view.mode,
+			Position:   view.GetPosition(),
+			ViewMatrix: view.GetViewMatrix(),
+		},
+		Water:      *s.water,
+		Render:     *s.render,
+		Scenery:    s.scenery,
+		Layers:     append([]SurfaceLayer(nil), s.layers...),
+		XR:         xr,
+		Presets:    s.presets,
+		FlyThrough: s.flight.status(),
+		History:    History{Undo: len(s.undo), Redo: len(s.redo)},
+
+		ActiveCamera: s.active,
+		Cameras:      s.cameraPoses(),
+	})
+}
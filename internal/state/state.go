@@ -1,8 +1,10 @@
 package state
 
 import (
-	"math"
+	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ku3ppi/webgl-water/internal/math3d"
@@ -12,30 +14,54 @@ import (
 type State struct {
 	mu       sync.RWMutex
 	clock    float32
-	camera   *Camera
+	camera   *Camera            // The camera input moves, one of cameras
+	cameras  map[string]*Camera // By name; see cameras.go
+	active   string             // Name of the camera state updates show
 	mouse    *Mouse
+	keys     map[Key]float32 // Held keys and the clock time of their last KeyDown
+	gamepad  gamepad         // Last reported stick axes
 	water    *Water
+	render   *Render
+	layers   []SurfaceLayer          // Composited over the water in order
+	xr       *XR                     // Immersive session; replaces the camera while active
+	ground   GroundFunc              // Terrain height queries for walk mode; nil stands on the water
+	presets  map[string]CameraPreset // Replaced rather than modified, as snapshots share it
+	flight   *flight                 // Fly-through in progress, if any
+	undo     []settings              // Settings from before each undoable change, oldest first
+	redo     []settings              // Settings undone, most recently undone last
+	onUpdate func(Message)           // Called with every applied message but clock advances
 	scenery  bool
 	lastTime time.Time
+	version  uint64        // Incremented on every change other than the clock advancing
+	changed  chan struct{} // Closed and replaced whenever version changes
+
+	snapshot atomic.Pointer[Snapshot] // Published under the write lock after every change
 }
 
 // NewState creates a new application state
 func NewState() *State {
-	return &State{
+	main := NewCamera()
+	s := &State{
 		clock:    0.0,
-		camera:   NewCamera(),
+		camera:   main,
+		cameras:  map[string]*Camera{CameraMain: main, CameraOverview: NewOverviewCamera()},
+		active:   CameraMain,
 		mouse:    NewMouse(),
+		keys:     make(map[Key]float32),
 		water:    NewWater(),
+		render:   NewRender(),
+		xr:       &XR{},
 		scenery:  true,
 		lastTime: time.Now(),
+		changed:  make(chan struct{}),
 	}
+	s.publish()
+	return s
 }
 
 // GetClock returns the current clock time in milliseconds
 func (s *State) GetClock() float32 {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.clock
+	return s.Snapshot().Clock
 }
 
 // GetCamera returns a copy of the camera state
@@ -47,26 +73,98 @@ func (s *State) GetCamera() Camera {
 
 // GetWater returns a copy of the water state
 func (s *State) GetWater() Water {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return *s.water
+	return s.Snapshot().Water
+}
+
+// GetRender returns a copy of the render parameters
+func (s *State) GetRender() Render {
+	return s.Snapshot().Render
 }
 
 // GetScenery returns whether scenery should be shown
 func (s *State) GetScenery() bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.scenery
+	return s.Snapshot().Scenery
+}
+
+// Version returns a counter that changes whenever anything but the clock changes
+func (s *State) Version() uint64 {
+	return s.Snapshot().Version
 }
 
-// Update processes a state message
-func (s *State) Update(msg Message) {
+// WaitForChange blocks until the version differs from since or ctx is done.
+// It returns the current version and whether it differs from since.
+func (s *State) WaitForChange(ctx context.Context, since uint64) (uint64, bool) {
+	for {
+		s.mu.RLock()
+		version, changed := s.version, s.changed
+		s.mu.RUnlock()
+
+		if version != since {
+			return version, true
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return version, false
+		}
+	}
+}
+
+// SetUpdateHook sets a function called with every message Update applies,
+// other than clock advances, such as a session recorder. It is called under
+// the write lock in the order messages are applied, so it must not call back
+// into State. A nil hook removes it.
+func (s *State) SetUpdateHook(hook func(Message)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onUpdate = hook
+}
+
+// bumpVersion records a change and wakes WaitForChange callers. The write lock must be held.
+func (s *State) bumpVersion() {
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Update processes a state message.
+// Messages carrying NaN or infinite values are rejected so they never reach shared state.
+func (s *State) Update(msg Message) error {
+	if err := ValidateMessage(msg); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	var before settings
+	if undoable(msg) {
+		before = s.settings()
+	}
+
 	switch m := msg.(type) {
 	case *AdvanceClockMessage:
 		s.clock += m.DeltaTime
+		position := s.camera.position
+		s.applyHeldKeys(m.DeltaTime)
+		s.applyGamepad(m.DeltaTime)
+		if s.camera.mode == CameraWalk {
+			// The terrain may have changed under the eye since the last tick
+			s.camera.placeOnGround(s.groundAt)
+		}
+		flying := s.flight != nil
+		if flying {
+			s.advanceFlyThrough(m.DeltaTime)
+		}
+		if s.camera.position != position || (flying && s.flight == nil) {
+			s.bumpVersion()
+		}
+	case *KeyDownMessage:
+		s.keys[m.Key] = s.clock
+	case *KeyUpMessage:
+		delete(s.keys, m.Key)
+	case *GamepadMessage:
+		s.gamepad = gamepad{axes: m.Axes, reported: s.clock}
 	case *MouseDownMessage:
 		s.mouse.SetPressed(true)
 		s.mouse.SetPos(m.X, m.Y)
@@ -74,7 +172,7 @@ func (s *State) Update(msg Message) {
 		s.mouse.SetPressed(false)
 	case *MouseMoveMessage:
 		if !s.mouse.GetPressed() {
-			return
+			return nil
 		}
 		oldX, oldY := s.mouse.GetPos()
 		xDelta := float32(oldX - m.X)
@@ -85,6 +183,44 @@ func (s *State) Update(msg Message) {
 		s.mouse.SetPos(m.X, m.Y)
 	case *ZoomMessage:
 		s.camera.Zoom(m.Delta)
+	case *TouchOrbitMessage, *PinchZoomMessage, *TwoFingerRotateMessage:
+		s.applyTouch(m)
+	case *SetCameraModeMessage:
+		s.flight = nil
+		s.setCameraMode(m.Mode)
+	case *SetActiveCameraMessage:
+		if err := s.setActiveCamera(m.Name); err != nil {
+			return err
+		}
+	case *SetCameraPoseMessage:
+		if err := s.setCameraPose(m.Name, m.Pose); err != nil {
+			return err
+		}
+	case *RemoveCameraMessage:
+		if err := s.removeCamera(m.Name); err != nil {
+			return err
+		}
+	case *CameraMessage:
+		if err := s.updateCamera(m); err != nil {
+			return err
+		}
+	case *StartFlyThroughMessage:
+		s.startFlyThrough(m.Path)
+	case *StopFlyThroughMessage:
+		s.flight = nil
+	case *SaveCameraPresetMessage:
+		if err := s.saveCameraPreset(m.Name); err != nil {
+			return err
+		}
+	case *RecallCameraPresetMessage:
+		if err := s.recallCameraPreset(m.Name); err != nil {
+			return err
+		}
+		s.flight = nil
+	case *DeleteCameraPresetMessage:
+		if err := s.deleteCameraPreset(m.Name); err != nil {
+			return err
+		}
 	case *SetReflectivityMessage:
 		s.water.Reflectivity = m.Value
 	case *SetFresnelMessage:
@@ -97,11 +233,142 @@ func (s *State) Update(msg Message) {
 		s.water.UseRefraction = m.Value
 	case *ShowSceneryMessage:
 		s.scenery = m.Value
+	case *SetExposureMessage:
+		s.render.Exposure = m.Value
+	case *SetGammaMessage:
+		s.render.Gamma = m.Value
+	case *SetToneMappingMessage:
+		s.render.ToneMapping = m.Value
+	case *SetSurfaceLayerMessage:
+		if err := s.setSurfaceLayer(m.Layer); err != nil {
+			return err
+		}
+	case *RemoveSurfaceLayerMessage:
+		if err := s.removeSurfaceLayer(m.Name); err != nil {
+			return err
+		}
+	case *EnterXRMessage:
+		s.enterXR()
+	case *ExitXRMessage:
+		s.xr = &XR{}
+	case *XRPoseMessage:
+		if err := s.updateXRPoses(m); err != nil {
+			return err
+		}
+	case *UndoMessage:
+		if err := s.undoChange(); err != nil {
+			return err
+		}
+	case *RedoMessage:
+		if err := s.redoChange(); err != nil {
+			return err
+		}
+	}
+
+	if undoable(msg) {
+		s.record(before)
+	}
+	if _, ok := msg.(*AdvanceClockMessage); !ok && s.onUpdate != nil {
+		s.onUpdate(msg)
+	}
+	if _, ok := msg.(*AdvanceClockMessage); !ok {
+		s.bumpVersion()
+	}
+	s.publish()
+	return nil
+}
+
+// ValidateMessage reports an error if msg carries a NaN or infinite value, a
+// pinch scale or gamma that is not positive, an unknown tone mapping operator,
+// an invalid surface layer, invalid XR poses, a key that does not control the
+// camera, gamepad axes out of range, an unknown camera mode, an empty camera
+// preset name, a fly-through path that cannot be flown, an invalid camera pose
+// or a message that cannot target a named camera
+func ValidateMessage(msg Message) error {
+	var name string
+	var value float32
+
+	switch m := msg.(type) {
+	case *AdvanceClockMessage:
+		name, value = "delta time", m.DeltaTime
+	case *ZoomMessage:
+		name, value = "zoom delta", m.Delta
+	case *PinchZoomMessage:
+		return validatePinchScale(m.Scale)
+	case *TwoFingerRotateMessage:
+		name, value = "rotation angle", m.Angle
+	case *SetReflectivityMessage:
+		name, value = "reflectivity", m.Value
+	case *SetFresnelMessage:
+		name, value = "fresnel strength", m.Value
+	case *SetWaveSpeedMessage:
+		name, value = "wave speed", m.Value
+	case *SetExposureMessage:
+		name, value = "exposure", m.Value
+	case *SetGammaMessage:
+		return validateGamma(m.Value)
+	case *SetToneMappingMessage:
+		if !m.Value.Valid() {
+			return fmt.Errorf("unknown tone mapping operator '%s'", m.Value)
+		}
+		return nil
+	case *SetSurfaceLayerMessage:
+		return m.Layer.Validate()
+	case *XRPoseMessage:
+		return m.Validate()
+	case *KeyDownMessage:
+		return validateKey(m.Key)
+	case *KeyUpMessage:
+		return validateKey(m.Key)
+	case *GamepadMessage:
+		return m.Axes.Validate()
+	case *SetCameraModeMessage:
+		return validateCameraMode(m.Mode)
+	case *SaveCameraPresetMessage:
+		return validatePresetName(m.Name)
+	case *RecallCameraPresetMessage:
+		return validatePresetName(m.Name)
+	case *DeleteCameraPresetMessage:
+		return validatePresetName(m.Name)
+	case *StartFlyThroughMessage:
+		return m.Path.Validate()
+	case *SetCameraPoseMessage:
+		if err := validateCameraName(m.Name); err != nil {
+			return err
+		}
+		return m.Pose.Validate()
+	case *CameraMessage:
+		return validateCameraMessage(m)
+	default:
+		return nil
+	}
+
+	if !math3d.IsFiniteFloat(value) {
+		return fmt.Errorf("%s must be finite, got %v", name, value)
 	}
+	return nil
+}
+
+// validateGamma reports an error if gamma is not a finite positive number, as
+// the shader divides by it
+func validateGamma(gamma float32) error {
+	if !math3d.IsFiniteFloat(gamma) || gamma <= 0 {
+		return fmt.Errorf("gamma must be positive, got %v", gamma)
+	}
+	return nil
+}
+
+// validateKey reports an error if key does not control the camera
+func validateKey(key Key) error {
+	if !key.Valid() {
+		return fmt.Errorf("unknown key '%s'", key)
+	}
+	return nil
 }
 
 // Camera represents the camera state
 type Camera struct {
+	mode        CameraMode
 	position    math3d.Vec3
 	target      math3d.Vec3
 	up          math3d.Vec3
@@ -116,7 +383,8 @@ type Camera struct {
 
 // NewCamera creates a new camera with default settings
 func NewCamera() *Camera {
-	return &Camera{
+	c := &Camera{
+		mode:        CameraOrbit,
 		position:    math3d.NewVec3(0, 5, 10),
 		target:      math3d.NewVec3(0, 0, 0),
 		up:          math3d.Vec3Up,
@@ -128,23 +396,24 @@ func NewCamera() *Camera {
 		minPitch:    -1.5,
 		maxPitch:    1.5,
 	}
+	c.updatePosition()
+	return c
 }
 
 // GetViewMatrix returns the view matrix for this camera
 func (c *Camera) GetViewMatrix() math3d.Mat4 {
-	c.updatePosition()
 	return math3d.LookAt(c.position, c.target, c.up)
 }
 
 // GetPosition returns the camera position
 func (c *Camera) GetPosition() math3d.Vec3 {
-	c.updatePosition()
 	return c.position
 }
 
 // OrbitLeftRight rotates the camera left/right around the target
 func (c *Camera) OrbitLeftRight(delta float32) {
 	c.yaw += delta
+	c.updatePosition()
 }
 
 // OrbitUpDown rotates the camera up/down around the target
@@ -156,6 +425,7 @@ func (c *Camera) OrbitUpDown(delta float32) {
 	if c.pitch > c.maxPitch {
 		c.pitch = c.maxPitch
 	}
+	c.updatePosition()
 }
 
 // Zoom changes the camera distance from the target
@@ -167,15 +437,23 @@ func (c *Camera) Zoom(delta float32) {
 	if c.distance > c.maxDistance {
 		c.distance = c.maxDistance
 	}
+	c.updatePosition()
 }
 
-// updatePosition updates the camera position based on yaw, pitch, and distance
-func (c *Camera) updatePosition() {
-	x := c.distance * float32(math.Cos(float64(c.pitch))) * float32(math.Sin(float64(c.yaw)))
-	y := c.distance * float32(math.Sin(float64(c.pitch)))
-	z := c.distance * float32(math.Cos(float64(c.pitch))) * float32(math.Cos(float64(c.yaw)))
+// GetMode returns the camera mode
+func (c *Camera) GetMode() CameraMode {
+	return c.mode
+}
 
-	c.position = c.target.Add(math3d.NewVec3(x, y, z))
+// updatePosition updates the camera position based on yaw, pitch, and distance,
+// or in walk mode the target in front of the eye.
+// Every change to them calls it, so reading the camera never writes to it.
+func (c *Camera) updatePosition() {
+	if c.mode == CameraWalk {
+		c.target = c.position.Sub(math3d.SphericalToCartesian(c.distance, c.yaw, c.pitch))
+		return
+	}
+	c.position = math3d.OrbitAround(c.target, c.yaw, c.pitch, c.distance)
 }
 
 // Mouse represents mouse input state
@@ -240,6 +518,42 @@ func (w *Water) GetDudvOffset(clockTime float32) float32 {
 	return (clockTime / 1000.0) * w.WaveSpeed
 }
 
+// ToneMapping selects the operator used to map HDR lighting to display range
+type ToneMapping string
+
+// Supported tone mapping operators
+const (
+	ToneMappingLinear      ToneMapping = "linear"
+	ToneMappingExponential ToneMapping = "exponential"
+	ToneMappingReinhard    ToneMapping = "reinhard"
+	ToneMappingFilmic      ToneMapping = "filmic" // Hable/Uncharted 2 spline curve
+)
+
+// Valid reports whether t is a supported tone mapping operator
+func (t ToneMapping) Valid() bool {
+	switch t {
+	case ToneMappingLinear, ToneMappingExponential, ToneMappingReinhard, ToneMappingFilmic:
+		return true
+	}
+	return false
+}
+
+// Render represents global rendering parameters shared by all clients
+type Render struct {
+	Exposure    float32     `json:"exposure"`
+	Gamma       float32     `json:"gamma"`
+	ToneMapping ToneMapping `json:"toneMapping"`
+}
+
+// NewRender creates render parameters with default values
+func NewRender() *Render {
+	return &Render{
+		Exposure:    1.0,
+		Gamma:       2.2,
+		ToneMapping: ToneMappingLinear,
+	}
+}
+
 // Message represents a state update message
 type Message interface {
 	message()
@@ -319,3 +633,24 @@ type ShowSceneryMessage struct {
 }
 
 func (*ShowSceneryMessage) message() {}
+
+// SetExposureMessage sets the exposure multiplier applied before tone mapping
+type SetExposureMessage struct {
+	Value float32
+}
+
+func (*SetExposureMessage) message() {}
+
+// SetGammaMessage sets the display gamma
+type SetGammaMessage struct {
+	Value float32
+}
+
+func (*SetGammaMessage) message() {}
+
+// SetToneMappingMessage selects the tone mapping operator
+type SetToneMappingMessage struct {
+	Value ToneMapping
+}
+
+func (*SetToneMappingMessage) message() {}
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// Touch gestures arrive as deltas since the client's previous touch event, so
+// unlike the mouse no pointer position or pressed state is tracked.
+
+// touchPixelsPerRadian is how far a finger drags to orbit one radian, the same as the mouse
+const touchPixelsPerRadian = 50.0
+
+// TouchOrbitMessage represents a single finger dragging by DX, DY pixels
+type TouchOrbitMessage struct {
+	DX, DY int32
+}
+
+func (*TouchOrbitMessage) message() {}
+
+// PinchZoomMessage represents two fingers moving apart or together. Scale is
+// the distance between them divided by the distance at the previous event, so
+// spreading the fingers moves the camera closer.
+type PinchZoomMessage struct {
+	Scale float32
+}
+
+func (*PinchZoomMessage) message() {}
+
+// TwoFingerRotateMessage represents two fingers turning by Angle radians,
+// counterclockwise on screen. The camera orbits with them.
+type TwoFingerRotateMessage struct {
+	Angle float32
+}
+
+func (*TwoFingerRotateMessage) message() {}
+
+// applyTouch moves the camera by a touch gesture. The write lock must be held.
+func (s *State) applyTouch(msg Message) {
+	switch m := msg.(type) {
+	case *TouchOrbitMessage:
+		// Dragging moves the scene with the finger, as dragging the mouse does
+		s.camera.OrbitLeftRight(float32(-m.DX) / touchPixelsPerRadian)
+		s.camera.OrbitUpDown(float32(m.DY) / touchPixelsPerRadian)
+	case *PinchZoomMessage:
+		s.camera.ZoomBy(1 / m.Scale)
+	case *TwoFingerRotateMessage:
+		s.camera.OrbitLeftRight(m.Angle)
+	}
+}
+
+// ZoomBy multiplies the camera distance from the target by factor
+func (c *Camera) ZoomBy(factor float32) {
+	c.Zoom(c.distance*factor - c.distance)
+}
+
+// validatePinchScale reports an error if scale cannot scale the camera distance
+func validatePinchScale(scale float32) error {
+	if !math3d.IsFiniteFloat(scale) || scale <= 0 {
+		return fmt.Errorf("pinch scale must be positive and finite, got %v", scale)
+	}
+	return nil
+}
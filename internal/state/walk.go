@@ -0,0 +1,119 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// In walk mode the camera is an eye standing on the terrain instead of orbiting
+// a target. Yaw, pitch and distance then place the target in front of the eye,
+// so mouse drags and the orbit keys look around, and W/A/S/D walk. The eye is
+// kept WalkEyeHeight above the terrain or the water surface, whichever is
+// higher, and cannot leave the terrain.
+
+// CameraMode selects how the camera moves
+type CameraMode string
+
+// Camera modes
+const (
+	CameraOrbit CameraMode = "orbit" // Orbit around a target
+	CameraWalk  CameraMode = "walk"  // Walk over the terrain
+)
+
+// Valid reports whether m is a known camera mode
+func (m CameraMode) Valid() bool {
+	return m == CameraOrbit || m == CameraWalk
+}
+
+// Walk mode settings
+const (
+	WalkEyeHeight = 1.7 // Height of the eye above the ground
+	WaterLevel    = 0.0 // Height of the water surface, which the eye cannot sink below
+	KeyWalkSpeed  = 4.0 // Units per second
+)
+
+// GroundFunc returns the terrain height at (x, z), or false where there is no terrain
+type GroundFunc func(x, z float32) (float32, bool)
+
+// SetCameraModeMessage switches between the orbit and walk cameras
+type SetCameraModeMessage struct {
+	Mode CameraMode
+}
+
+func (*SetCameraModeMessage) message() {}
+
+// SetGround sets the terrain walk mode stands on. Without one, walk mode stands
+// on the water surface wherever it goes.
+func (s *State) SetGround(ground GroundFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ground = ground
+	if s.camera.mode == CameraWalk {
+		s.camera.placeOnGround(s.groundAt)
+		s.bumpVersion()
+		s.publish()
+	}
+}
+
+// groundAt returns the height walk mode stands on at (x, z), or false outside
+// the terrain. The lock must be held.
+func (s *State) groundAt(x, z float32) (float32, bool) {
+	if s.ground == nil {
+		return WaterLevel, true
+	}
+	height, ok := s.ground(x, z)
+	if !ok {
+		return 0, false
+	}
+	return max(height, WaterLevel), true
+}
+
+// setCameraMode switches the camera mode. Walking starts where the orbit
+// camera was looking, and orbiting starts around the point the eye looked at.
+// The write lock must be held.
+func (s *State) setCameraMode(mode CameraMode) {
+	c := s.camera
+	if c.mode == mode {
+		return
+	}
+	c.mode = mode
+	if mode == CameraWalk {
+		c.position = math3d.NewVec3(c.target.X, c.position.Y, c.target.Z)
+		if _, ok := s.groundAt(c.position.X, c.position.Z); !ok {
+			c.position.X, c.position.Z = 0, 0
+		}
+		c.placeOnGround(s.groundAt)
+		return
+	}
+	c.updatePosition()
+}
+
+// placeOnGround moves the eye to WalkEyeHeight above the ground below it. An eye
+// off the terrain stays at its height.
+func (c *Camera) placeOnGround(ground GroundFunc) {
+	if height, ok := ground(c.position.X, c.position.Z); ok {
+		c.position.Y = height + WalkEyeHeight
+	}
+	c.updatePosition()
+}
+
+// Walk moves the eye right and forward as seen from the camera, following the
+// ground. Steps that would leave the terrain are not taken.
+func (c *Camera) Walk(right, forward float32, ground GroundFunc) {
+	x, z := c.position.X, c.position.Z
+	c.position = c.position.Add(horizontalOffset(c.yaw, right, forward))
+	if _, ok := ground(c.position.X, c.position.Z); !ok {
+		c.position.X, c.position.Z = x, z
+	}
+	c.placeOnGround(ground)
+}
+
+// validateCameraMode reports an error if mode is not a known camera mode
+func validateCameraMode(mode CameraMode) error {
+	if !mode.Valid() {
+		return fmt.Errorf("unknown camera mode '%s'", mode)
+	}
+	return nil
+}
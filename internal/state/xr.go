@@ -0,0 +1,237 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+)
+
+// A WebXR client streams the poses of the headset, its per-eye views and its
+// controllers. Poses are in the session's "local" reference space, whose origin
+// is placed in the scene where the orbit camera was when the session started.
+// While a session is active the head pose replaces the orbit camera, so every
+// client sees what the headset sees.
+
+// Limits on what one pose message may carry
+const (
+	MaxXRViews       = 4 // Stereo headsets use 2; some displays render more
+	MaxXRControllers = 4
+)
+
+// XREye identifies the eye a view is rendered for, as XRView.eye does
+type XREye string
+
+// WebXR eyes
+const (
+	XREyeLeft  XREye = "left"
+	XREyeRight XREye = "right"
+	XREyeNone  XREye = "none" // Monoscopic view, e.g. a phone in AR mode
+)
+
+// Valid reports whether e is a WebXR eye
+func (e XREye) Valid() bool {
+	switch e {
+	case XREyeLeft, XREyeRight, XREyeNone:
+		return true
+	}
+	return false
+}
+
+// XRHand identifies the hand holding a controller, as XRInputSource.handedness does
+type XRHand string
+
+// WebXR handedness values
+const (
+	XRHandLeft  XRHand = "left"
+	XRHandRight XRHand = "right"
+	XRHandNone  XRHand = "none"
+)
+
+// Valid reports whether h is a WebXR handedness value
+func (h XRHand) Valid() bool {
+	switch h {
+	case XRHandLeft, XRHandRight, XRHandNone:
+		return true
+	}
+	return false
+}
+
+// XRPose is a position and orientation in the XR reference space
+type XRPose struct {
+	Position    math3d.Vec3 `json:"position"`
+	Orientation math3d.Quat `json:"orientation"`
+}
+
+// Validate reports an error if the pose has non-finite values or no orientation
+func (p XRPose) Validate() error {
+	if !p.Position.IsFinite() || !p.Orientation.IsFinite() {
+		return fmt.Errorf("pose values must be finite")
+	}
+	if p.Orientation.LengthSquared() < 1e-6 {
+		return fmt.Errorf("pose orientation must not be zero")
+	}
+	return nil
+}
+
+// XRView is one view the client renders per frame
+type XRView struct {
+	Eye        XREye       `json:"eye"`
+	Transform  XRPose      `json:"transform"`  // Pose of the eye
+	Projection math3d.Mat4 `json:"projection"` // Projection matrix from the XR device, passed through unchanged
+}
+
+// XRController is a tracked controller
+type XRController struct {
+	Hand    XRHand `json:"hand"`
+	Pose    XRPose `json:"pose"`    // Grip pose
+	Select  bool   `json:"select"`  // Primary action (trigger) held
+	Squeeze bool   `json:"squeeze"` // Grip held; moving the controller drags the scene
+}
+
+// XR is the state of an immersive WebXR session
+type XR struct {
+	Active      bool           `json:"active"`
+	Origin      math3d.Vec3    `json:"origin"` // Scene position of the reference space origin
+	Head        XRPose         `json:"head"`
+	Views       []XRView       `json:"views"`
+	Controllers []XRController `json:"controllers"`
+
+	grabs map[XRHand]xrGrab // Controllers whose squeeze is held
+}
+
+// xrGrab records where a controller grabbed the scene
+type xrGrab struct {
+	position math3d.Vec3 // Controller position when the squeeze started
+	origin   math3d.Vec3 // Origin when the squeeze started
+}
+
+// ViewMatrix returns the scene view matrix for a pose in the reference space
+func (x *XR) ViewMatrix(pose XRPose) math3d.Mat4 {
+	position := x.Origin.Add(pose.Position)
+	rotation := pose.Orientation.Normalize().Conjugate().ToMat4()
+	return rotation.Multiply(math3d.TranslationVec3(position.Scale(-1)))
+}
+
+// HeadPosition returns the scene position of the headset
+func (x *XR) HeadPosition() math3d.Vec3 {
+	return x.Origin.Add(x.Head.Position)
+}
+
+// GetXR returns a copy of the XR session state
+func (s *State) GetXR() XR {
+	xr := s.Snapshot().XR
+	xr.Views = append([]XRView(nil), xr.Views...)
+	xr.Controllers = append([]XRController(nil), xr.Controllers...)
+	return xr
+}
+
+// enterXR starts a session with the reference space origin at the orbit camera.
+// The write lock must be held.
+func (s *State) enterXR() {
+	s.xr = &XR{
+		Active: true,
+		Origin: s.camera.GetPosition(),
+		Head:   XRPose{Orientation: math3d.QuatIdentity()},
+		grabs:  make(map[XRHand]xrGrab),
+	}
+}
+
+// updateXRPoses replaces the streamed poses and moves the origin by the
+// controllers dragging the scene. The write lock must be held.
+func (s *State) updateXRPoses(m *XRPoseMessage) error {
+	x := s.xr
+	if !x.Active {
+		return fmt.Errorf("no XR session is active")
+	}
+
+	x.Head = normalizedPose(m.Head)
+	x.Views = make([]XRView, len(m.Views))
+	for i, view := range m.Views {
+		view.Transform = normalizedPose(view.Transform)
+		x.Views[i] = view
+	}
+	x.Controllers = make([]XRController, len(m.Controllers))
+	for i, controller := range m.Controllers {
+		controller.Pose = normalizedPose(controller.Pose)
+		x.Controllers[i] = controller
+	}
+
+	// Grabbing the scene keeps the grabbed point under the controller, so
+	// moving the hand by d moves the origin by -d
+	held := make(map[XRHand]bool)
+	for _, controller := range x.Controllers {
+		if !controller.Squeeze {
+			continue
+		}
+		held[controller.Hand] = true
+		grab, ok := x.grabs[controller.Hand]
+		if !ok {
+			x.grabs[controller.Hand] = xrGrab{position: controller.Pose.Position, origin: x.Origin}
+			continue
+		}
+		x.Origin = grab.origin.Add(grab.position).Sub(controller.Pose.Position)
+	}
+	for hand := range x.grabs {
+		if !held[hand] {
+			delete(x.grabs, hand)
+		}
+	}
+	return nil
+}
+
+func normalizedPose(pose XRPose) XRPose {
+	pose.Orientation = pose.Orientation.Normalize()
+	return pose
+}
+
+// EnterXRMessage starts an immersive session driven by XRPoseMessages
+type EnterXRMessage struct{}
+
+func (*EnterXRMessage) message() {}
+
+// ExitXRMessage ends the immersive session and returns to the orbit camera
+type ExitXRMessage struct{}
+
+func (*ExitXRMessage) message() {}
+
+// XRPoseMessage carries the poses of one XR frame
+type XRPoseMessage struct {
+	Head        XRPose
+	Views       []XRView
+	Controllers []XRController
+}
+
+func (*XRPoseMessage) message() {}
+
+// Validate reports an error if the message has invalid poses or too many views or controllers
+func (m *XRPoseMessage) Validate() error {
+	if err := m.Head.Validate(); err != nil {
+		return fmt.Errorf("head: %w", err)
+	}
+	if len(m.Views) > MaxXRViews {
+		return fmt.Errorf("at most %d XR views are supported", MaxXRViews)
+	}
+	for i, view := range m.Views {
+		if !view.Eye.Valid() {
+			return fmt.Errorf("view %d has unknown eye '%s'", i, view.Eye)
+		}
+		if err := view.Transform.Validate(); err != nil {
+			return fmt.Errorf("view %d: %w", i, err)
+		}
+		if !view.Projection.IsFinite() {
+			return fmt.Errorf("view %d projection must be finite", i)
+		}
+	}
+	if len(m.Controllers) > MaxXRControllers {
+		return fmt.Errorf("at most %d XR controllers are supported", MaxXRControllers)
+	}
+	for i, controller := range m.Controllers {
+		if !controller.Hand.Valid() {
+			return fmt.Errorf("controller %d has unknown hand '%s'", i, controller.Hand)
+		}
+		if err := controller.Pose.Validate(); err != nil {
+			return fmt.Errorf("controller %d: %w", i, err)
+		}
+	}
+	return nil
+}
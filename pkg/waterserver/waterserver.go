@@ -0,0 +1,495 @@
+// Package waterserver runs the WebGL water server inside another Go program.
+//
+// A Server can listen on its own, serve on a listener supplied by the caller,
+// or be mounted under any net/http compatible router:
+//
+//	srv, err := waterserver.New(waterserver.WithAssetsPath("./assets"))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.StartBackground()
+//	mux.Handle("/water/", http.StripPrefix("/water", srv.Handler()))
+//
+// This package is the supported API surface; everything under internal/ may change without notice.
+package waterserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	webglwater "github.com/ku3ppi/webgl-water"
+	"github.com/ku3ppi/webgl-water/internal/app"
+	"github.com/ku3ppi/webgl-water/internal/assets"
+	"github.com/ku3ppi/webgl-water/internal/codec"
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// Default locations and port used when no option overrides them
+const (
+	DefaultAssetsPath = "./assets"
+	DefaultStaticPath = "./web/static"
+	DefaultPort       = 8080
+)
+
+// Hooks are optional callbacks invoked on server events. Any of them may be nil.
+// Hooks run on the server's goroutines and should return quickly.
+type Hooks struct {
+	// OnServe is called once the server starts accepting connections on addr
+	OnServe func(addr net.Addr)
+	// OnClientConnect is called when a WebSocket client connects
+	OnClientConnect func(r *http.Request)
+	// OnClientDisconnect is called when a WebSocket client disconnects
+	OnClientDisconnect func(r *http.Request)
+	// OnTick is called after every simulation step with the clock in milliseconds
+	OnTick func(clock float32)
+}
+
+// config collects the values set by options
+type config struct {
+	assetsPath         string
+	staticPath         string
+	port               int
+	listener           net.Listener
+	hooks              Hooks
+	checkpointPath     string
+	checkpointInterval time.Duration
+	cameraPresetsPath  string
+	tickRate           int
+	recordingsDir      string
+	adminToken         string
+	hotReload          bool
+	clipmap            *state.ClipmapConfig
+	chaos              *app.NetworkConditions
+	analytics          bool
+	store              assets.Store // Synced into assetsPath before loading, if set
+	cachePolicy        *app.CachePolicy
+	compression        *app.CompressionConfig
+	precompress        bool
+	waterMesh          *assets.WaterMeshParams
+	scatters           map[string]ScatterParams
+	memoryBudget       int64
+	pinnedAssets       []string
+	backgroundLoad     bool
+	loadWorkers        int
+	collections        map[string]string // Directories of asset collections by name
+}
+
+// Option configures a Server
+type Option func(*config)
+
+// WithAssetsPath sets the directory meshes, textures and scenes are loaded from
+func WithAssetsPath(path string) Option {
+	return func(c *config) { c.assetsPath = path }
+}
+
+// WithHTTPAssets downloads the asset files listed in index.json at baseURL into
+// cacheDir on start and loads them from there. Only files that changed since the
+// last start are downloaded again.
+func WithHTTPAssets(baseURL, cacheDir string) Option {
+	return func(c *config) {
+		c.store = assets.HTTPStore{BaseURL: baseURL}
+		c.assetsPath = cacheDir
+	}
+}
+
+// WithS3Assets downloads the asset files under prefix in an S3 bucket into cacheDir
+// on start and loads them from there. Credentials are read from the standard
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables.
+// endpoint selects an S3-compatible service instead of AWS if not empty.
+func WithS3Assets(bucket, prefix, region, endpoint, cacheDir string) Option {
+	return func(c *config) {
+		c.store = assets.NewS3StoreFromEnv(bucket, prefix, region, endpoint)
+		c.assetsPath = cacheDir
+	}
+}
+
+// WithStaticPath sets the directory the frontend's static files are served from
+func WithStaticPath(path string) Option {
+	return func(c *config) { c.staticPath = path }
+}
+
+// WithPort sets the TCP port ListenAndServe listens on
+func WithPort(port int) Option {
+	return func(c *config) { c.port = port }
+}
+
+// WithListener makes ListenAndServe serve on l instead of opening its own port
+func WithListener(l net.Listener) Option {
+	return func(c *config) { c.listener = l }
+}
+
+// WithHooks installs callbacks for server events
+func WithHooks(hooks Hooks) Option {
+	return func(c *config) { c.hooks = hooks }
+}
+
+// WithCheckpoints restores state from path on start and writes a new checkpoint to it every interval.
+// An interval of zero or less writes one every minute.
+func WithCheckpoints(path string, interval time.Duration) Option {
+	return func(c *config) {
+		c.checkpointPath = path
+		c.checkpointInterval = interval
+	}
+}
+
+// WithCameraPresets loads saved camera presets from path on start and writes
+// them back whenever one is saved or deleted
+func WithCameraPresets(path string) Option {
+	return func(c *config) { c.cameraPresetsPath = path }
+}
+
+// WithTickRate runs hz fixed simulation steps per second instead of 60.
+// Broadcasts still go out at about 60 per second.
+func WithTickRate(hz int) Option {
+	return func(c *config) { c.tickRate = hz }
+}
+
+// WithRecordings lets API clients record sessions to and replay them from
+// files in dir, for reproducing reported glitches and regression runs
+func WithRecordings(dir string) Option {
+	return func(c *config) { c.recordingsDir = dir }
+}
+
+// WithAdminToken enables GET /api/admin/backup and POST /api/admin/restore for
+// requests carrying "Authorization: Bearer <token>". Without it they answer 404.
+func WithAdminToken(token string) Option {
+	return func(c *config) { c.adminToken = token }
+}
+
+// WithHotReload reloads meshes, textures, scenes and shaders when their files change
+// and tells connected clients to re-fetch them
+func WithHotReload() Option {
+	return func(c *config) { c.hotReload = true }
+}
+
+// WithClipmap renders the water as levels nested grids of cells×cells cells around
+// the camera, the finest with cells spacing world units wide, for very large oceans
+func WithClipmap(levels, cells int, spacing float32) Option {
+	return func(c *config) {
+		c.clipmap = &state.ClipmapConfig{Levels: levels, Cells: cells, BaseSpacing: spacing}
+	}
+}
+
+// WithRadialWater replaces the uniform water grid with a disc size world units
+// across that follows the camera, made of rings concentric rings of sectors
+// vertices each. Falloff 1 spaces the rings evenly; larger values pack them
+// closer to the camera.
+func WithRadialWater(size float32, rings, sectors int, falloff float32) Option {
+	return func(c *config) {
+		params := assets.DefaultWaterMeshParams()
+		params.Topology = assets.WaterTopologyRadial
+		params.Size, params.Rings, params.Sectors, params.Falloff = size, rings, sectors, falloff
+		c.waterMesh = &params
+	}
+}
+
+// WithProjectedWater replaces the uniform water grid with a segments×segments
+// grid in screen space that the vertex shader projects onto the water, so the
+// water reaches the horizon with detail where the camera looks
+func WithProjectedWater(segments int) Option {
+	return func(c *config) {
+		params := assets.DefaultWaterMeshParams()
+		params.Topology = assets.WaterTopologyProjected
+		params.Segments = segments
+		c.waterMesh = &params
+	}
+}
+
+// ScatterParams configures WithScatter
+type ScatterParams = assets.ScatterParams
+
+// DefaultScatterParams returns scatter settings that place 100 instances on
+// the terrain around the water line
+func DefaultScatterParams() ScatterParams {
+	return assets.DefaultScatterParams()
+}
+
+// WithScatter places instances of mesh on a terrain mesh, such as rocks and
+// reeds along the shore, served at /api/instances/{mesh}. The option can be
+// given once per mesh.
+func WithScatter(mesh string, params ScatterParams) Option {
+	return func(c *config) {
+		if c.scatters == nil {
+			c.scatters = make(map[string]ScatterParams)
+		}
+		c.scatters[mesh] = params
+	}
+}
+
+// WithAssetCollection registers the assets in dir as a collection of its own,
+// so its meshes and textures can share names with those of other collections.
+// Subdirectories of the assets directory's "collections" directory are
+// registered without it. Collections are listed at /api/collections and
+// served under /api/collections/{name}/ and /assets/collections/{name}/.
+func WithAssetCollection(name, dir string) Option {
+	return func(c *config) {
+		if c.collections == nil {
+			c.collections = make(map[string]string)
+		}
+		c.collections[name] = dir
+	}
+}
+
+// WithAssetMemoryBudget limits the memory spent on data derived from assets
+// (compressed meshes, mipmaps and resized textures) to budget bytes, dropping
+// the least recently used beyond it and rebuilding it when next requested. The
+// data of pinned meshes and textures is never dropped; the water, terrain and
+// default textures are always pinned. Usage is served at /api/admin/cache.
+func WithAssetMemoryBudget(budget int64, pinned ...string) Option {
+	return func(c *config) {
+		c.memoryBudget = budget
+		c.pinnedAssets = append(c.pinnedAssets, pinned...)
+	}
+}
+
+// WithNetworkChaos delivers WebSocket messages as if over a bad network, for debugging
+// client-side prediction and interpolation: each message is delayed by latency plus
+// up to jitter, dropped with probability dropRate (0-1), and overtaken by later
+// messages with probability reorderRate (0-1)
+func WithNetworkChaos(latency, jitter time.Duration, dropRate, reorderRate float64) Option {
+	return func(c *config) {
+		c.chaos = &app.NetworkConditions{Latency: latency, Jitter: jitter, DropRate: dropRate, ReorderRate: reorderRate}
+	}
+}
+
+// WithAnalytics records which parameters each browser session changes and how long
+// it stays, served as a summary at /api/analytics and as CSV at /api/analytics.csv
+func WithAnalytics() Option {
+	return func(c *config) { c.analytics = true }
+}
+
+// WithCacheControl sets the Cache-Control headers sent with files under /assets,
+// /shaders and /static. Every file is served with an ETag, so "no-cache" (the
+// default) still saves the download when a file has not changed.
+// An empty value sends no Cache-Control header for that group.
+func WithCacheControl(assets, shaders, static string) Option {
+	return func(c *config) {
+		c.cachePolicy = &app.CachePolicy{Assets: assets, Shaders: shaders, Static: static}
+	}
+}
+
+// WithCompression selects codecs by name: httpCodecs are offered to clients
+// through Accept-Encoding in order of preference (none by default), snapshots
+// compresses backup archives ("gzip" by default) and checkpoints compresses
+// checkpoint files ("none" by default). "gzip", "zstd", "br" (brotli) and
+// "none" are built in; others can be added with RegisterCodec.
+func WithCompression(httpCodecs []string, snapshots, checkpoints string) Option {
+	return func(c *config) {
+		c.compression = &app.CompressionConfig{HTTP: httpCodecs, Snapshots: snapshots, Checkpoints: checkpoints}
+	}
+}
+
+// WithPrecompression compresses mesh JSON and shader sources with the HTTP
+// codecs chosen by WithCompression once when they are loaded, instead of on
+// every request
+func WithPrecompression() Option {
+	return func(c *config) { c.precompress = true }
+}
+
+// WithBackgroundLoading makes New return before the assets are loaded, so the
+// page can show a loading screen. Asset, shader and API requests are answered
+// with 503 Service Unavailable until loading finishes; /api/assets/status
+// reports its progress.
+func WithBackgroundLoading() Option {
+	return func(c *config) { c.backgroundLoad = true }
+}
+
+// WithLoadWorkers sets how many goroutines load assets concurrently,
+// GOMAXPROCS by default
+func WithLoadWorkers(workers int) Option {
+	return func(c *config) { c.loadWorkers = workers }
+}
+
+// Codec compresses and decompresses byte streams for WithCompression
+type Codec = codec.Codec
+
+// RegisterCodec makes c available to WithCompression under its name, e.g. to
+// add another codec or use other settings for a built-in one. Call it before New.
+func RegisterCodec(c Codec) {
+	codec.Register(c)
+}
+
+// Server is an embeddable water server
+type Server struct {
+	server   *app.Server
+	port     int
+	listener net.Listener
+}
+
+// New creates a server, loads its assets (unless WithBackgroundLoading is given)
+// and restores its checkpoint if one is configured.
+// The simulation does not advance until StartBackground, Serve or ListenAndServe is called.
+func New(opts ...Option) (*Server, error) {
+	cfg := config{
+		assetsPath: DefaultAssetsPath,
+		staticPath: DefaultStaticPath,
+		port:       DefaultPort,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.store != nil {
+		if err := assets.SyncStore(context.Background(), cfg.store, cfg.assetsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Files on disk override the defaults built into the binary
+	server := app.NewServer(cfg.assetsPath, cfg.staticPath, cfg.port, app.WithFallbackFiles(app.FallbackFiles{
+		Assets:  webglwater.Assets(),
+		Shaders: webglwater.Shaders(),
+		Static:  webglwater.Static(),
+	}))
+	server.SetHooks(app.Hooks{
+		OnServe:            cfg.hooks.OnServe,
+		OnClientConnect:    cfg.hooks.OnClientConnect,
+		OnClientDisconnect: cfg.hooks.OnClientDisconnect,
+		OnTick:             cfg.hooks.OnTick,
+	})
+	if cfg.checkpointPath != "" {
+		server.EnableCheckpoints(cfg.checkpointPath, cfg.checkpointInterval)
+	}
+	if cfg.cameraPresetsPath != "" {
+		server.EnableCameraPresets(cfg.cameraPresetsPath)
+	}
+	if cfg.recordingsDir != "" {
+		server.EnableRecordings(cfg.recordingsDir)
+	}
+	if cfg.adminToken != "" {
+		if err := server.EnableAdmin(cfg.adminToken); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.tickRate != 0 {
+		if err := server.SetTickRate(cfg.tickRate); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.hotReload {
+		server.EnableHotReload()
+	}
+	if cfg.clipmap != nil {
+		if err := server.EnableClipmap(*cfg.clipmap); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.waterMesh != nil {
+		if err := server.SetWaterMesh(*cfg.waterMesh); err != nil {
+			return nil, err
+		}
+	}
+	for mesh, params := range cfg.scatters {
+		if err := server.SetScatter(mesh, params); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.cachePolicy != nil {
+		server.SetCachePolicy(*cfg.cachePolicy)
+	}
+	if cfg.compression != nil {
+		if err := server.SetCompression(*cfg.compression); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.precompress {
+		server.EnablePrecompression()
+	}
+	if cfg.memoryBudget > 0 {
+		server.SetAssetMemoryBudget(cfg.memoryBudget, cfg.pinnedAssets...)
+	}
+	if cfg.analytics {
+		server.EnableAnalytics()
+	}
+	for name, dir := range cfg.collections {
+		if err := server.RegisterCollection(name, dir); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.backgroundLoad {
+		server.EnableBackgroundLoading()
+	}
+	if cfg.loadWorkers > 0 {
+		server.SetLoadWorkers(cfg.loadWorkers)
+	}
+	if cfg.chaos != nil {
+		if err := server.EnableNetworkChaos(*cfg.chaos); err != nil {
+			return nil, err
+		}
+	}
+	if err := server.Initialize(); err != nil {
+		return nil, err
+	}
+
+	return &Server{server: server, port: cfg.port, listener: cfg.listener}, nil
+}
+
+// Handler returns the HTTP handler serving the frontend, API and WebSocket routes.
+// Call StartBackground when mounting it in another router.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler()
+}
+
+// APIHandler returns only the REST API routes, relative to their mount point
+// (mount with http.StripPrefix, e.g. "/water/api")
+func (s *Server) APIHandler() http.Handler {
+	return s.server.APIHandler()
+}
+
+// WebSocketHandler returns the handler for the real-time state update stream
+func (s *Server) WebSocketHandler() http.Handler {
+	return s.server.WebSocketHandler()
+}
+
+// AssetHandler returns the handler serving asset files as /{filename} and texture mip levels as /{name}/mip/{level}
+func (s *Server) AssetHandler() http.Handler {
+	return s.server.AssetHandler()
+}
+
+// ShaderHandler returns the handler serving shader sources as /{name}
+func (s *Server) ShaderHandler() http.Handler {
+	return s.server.ShaderHandler()
+}
+
+// StaticHandler returns the file server for the frontend's static files
+func (s *Server) StaticHandler() http.Handler {
+	return s.server.StaticHandler()
+}
+
+// StartBackground starts the simulation loop and periodic checkpoints.
+// It is safe to call more than once.
+func (s *Server) StartBackground() {
+	s.server.StartBackground()
+}
+
+// Serve serves HTTP on l until Shutdown is called
+func (s *Server) Serve(l net.Listener) error {
+	return s.server.Serve(l)
+}
+
+// ListenAndServe serves on the listener given by WithListener, or on the configured port
+func (s *Server) ListenAndServe() error {
+	listener := s.listener
+	if listener == nil {
+		var err error
+		listener, err = net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+		if err != nil {
+			return err
+		}
+	}
+	return s.server.Serve(listener)
+}
+
+// SetActiveCollection makes the unprefixed asset routes serve the named
+// collection and tells connected clients to reload their assets
+func (s *Server) SetActiveCollection(name string) error {
+	return s.server.SetActiveCollection(name)
+}
+
+// Shutdown stops the simulation loop and gracefully stops serving HTTP
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,148 @@
+// Package watersim runs the water state machine without the web stack.
+//
+// A Simulation advances the shared clock on a fixed tick and publishes a
+// snapshot of the state on a channel after every step, so game servers can
+// drive the same camera, water and render parameters the web server does:
+//
+//	sim := watersim.New()
+//	go sim.Run(ctx, watersim.Options{})
+//	sim.Update(&watersim.SetWaveSpeedMessage{Value: 0.05})
+//	for event := range sim.Events() {
+//		// use event.Clock, event.Water, ...
+//	}
+package watersim
+
+import (
+	"context"
+	"time"
+
+	"github.com/ku3ppi/webgl-water/internal/math3d"
+	"github.com/ku3ppi/webgl-water/internal/state"
+)
+
+// Math types used in events
+type (
+	Vec3 = math3d.Vec3
+	Mat4 = math3d.Mat4
+)
+
+// State types and messages accepted by Update
+type (
+	Message                = state.Message
+	Water                  = state.Water
+	Render                 = state.Render
+	ToneMapping            = state.ToneMapping
+	MouseDownMessage       = state.MouseDownMessage
+	MouseUpMessage         = state.MouseUpMessage
+	MouseMoveMessage       = state.MouseMoveMessage
+	ZoomMessage            = state.ZoomMessage
+	SetReflectivityMessage = state.SetReflectivityMessage
+	SetFresnelMessage      = state.SetFresnelMessage
+	SetWaveSpeedMessage    = state.SetWaveSpeedMessage
+	UseReflectionMessage   = state.UseReflectionMessage
+	UseRefractionMessage   = state.UseRefractionMessage
+	ShowSceneryMessage     = state.ShowSceneryMessage
+	SetExposureMessage     = state.SetExposureMessage
+	SetGammaMessage        = state.SetGammaMessage
+	SetToneMappingMessage  = state.SetToneMappingMessage
+)
+
+// Supported tone mapping operators
+const (
+	ToneMappingLinear      = state.ToneMappingLinear
+	ToneMappingExponential = state.ToneMappingExponential
+	ToneMappingReinhard    = state.ToneMappingReinhard
+	ToneMappingFilmic      = state.ToneMappingFilmic
+)
+
+// Defaults used for zero Options fields
+const (
+	DefaultTickInterval = 16 * time.Millisecond // ~60 FPS, matching the web server
+	DefaultEventBuffer  = 16
+)
+
+// Options configures Run
+type Options struct {
+	TickInterval time.Duration // Time between simulation steps
+}
+
+// Event is a snapshot of the state published after a simulation step
+type Event struct {
+	Clock          float32 // Milliseconds of simulated time
+	CameraPosition Vec3
+	ViewMatrix     Mat4
+	Water          Water
+	Render         Render
+	Scenery        bool
+}
+
+// Simulation owns a state machine and its event stream
+type Simulation struct {
+	state  *state.State
+	events chan Event
+}
+
+// New creates a simulation with the default state
+func New() *Simulation {
+	return &Simulation{
+		state:  state.NewState(),
+		events: make(chan Event, DefaultEventBuffer),
+	}
+}
+
+// Events returns the channel snapshots are published on. It is closed when Run returns.
+// Snapshots are dropped rather than delaying the simulation when the reader falls behind.
+func (s *Simulation) Events() <-chan Event {
+	return s.events
+}
+
+// Update applies a message to the state. Messages with NaN or infinite values are rejected.
+// It is safe to call concurrently with Run.
+func (s *Simulation) Update(msg Message) error {
+	return s.state.Update(msg)
+}
+
+// Snapshot returns the current state, all of it as of the same instant
+func (s *Simulation) Snapshot() Event {
+	snapshot := s.state.Snapshot()
+	return Event{
+		Clock:          snapshot.Clock,
+		CameraPosition: snapshot.Camera.Position,
+		ViewMatrix:     snapshot.Camera.ViewMatrix,
+		Water:          snapshot.Water,
+		Render:         snapshot.Render,
+		Scenery:        snapshot.Scenery,
+	}
+}
+
+// Run advances the simulation every tick until ctx is done, then closes the event channel.
+// Run must be called at most once.
+func (s *Simulation) Run(ctx context.Context, opts Options) error {
+	defer close(s.events)
+
+	interval := opts.TickInterval
+	if interval <= 0 {
+		interval = DefaultTickInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastTime := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			deltaTime := float32(now.Sub(lastTime).Milliseconds())
+			lastTime = now
+
+			s.state.Update(&state.AdvanceClockMessage{DeltaTime: deltaTime})
+
+			select {
+			case s.events <- s.Snapshot():
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+"""Export the current Blender scene to the .wgscene format read by internal/assets/scene.go.
+
+Usage:
+    blender my-scene.blend --background --python tools/blender/export_scene.py -- assets/my-scene.wgscene
+
+Every mesh object is triangulated and exported with positions, normals and uvs.
+Object parenting, custom properties and the first material slot are preserved.
+Blender is Z-up; coordinates are converted to the Y-up convention used by the server.
+"""
+
+import struct
+import sys
+
+import bmesh
+import bpy
+
+VERSION = 1
+
+
+def pack_string(value):
+    data = value.encode("utf-8")
+    return struct.pack("<I", len(data)) + data
+
+
+def pack_floats(values):
+    return struct.pack("<I", len(values)) + struct.pack("<%df" % len(values), *values)
+
+
+def pack_uints(values):
+    return struct.pack("<I", len(values)) + struct.pack("<%dI" % len(values), *values)
+
+
+def chunk(tag, payload):
+    return tag.encode("ascii") + struct.pack("<I", len(payload)) + payload
+
+
+def to_y_up(x, y, z):
+    return x, z, -y
+
+
+def mesh_chunk(obj):
+    depsgraph = bpy.context.evaluated_depsgraph_get()
+    mesh = obj.evaluated_get(depsgraph).to_mesh()
+
+    bm = bmesh.new()
+    bm.from_mesh(mesh)
+    bmesh.ops.triangulate(bm, faces=bm.faces[:])
+    bm.to_mesh(mesh)
+    bm.free()
+
+    uv_layer = mesh.uv_layers.active
+    positions, normals, uvs, indices = [], [], [], []
+    vertex_index = {}
+
+    for loop in mesh.loops:
+        vertex = mesh.vertices[loop.vertex_index]
+        uv = tuple(uv_layer.data[loop.index].uv) if uv_layer else (0.0, 0.0)
+        normal = tuple(loop.normal)
+        key = (loop.vertex_index, normal, uv)
+        if key not in vertex_index:
+            vertex_index[key] = len(vertex_index)
+            positions.extend(to_y_up(*vertex.co))
+            normals.extend(to_y_up(*normal))
+            uvs.extend(uv)
+        indices.append(vertex_index[key])
+
+    obj.evaluated_get(depsgraph).to_mesh_clear()
+
+    payload = (
+        pack_string(obj.data.name)
+        + pack_floats(positions)
+        + pack_floats(normals)
+        + pack_floats(uvs)
+        + pack_uints(indices)
+    )
+    return chunk("MESH", payload)
+
+
+def material_chunk(material):
+    color = (1.0, 1.0, 1.0, 1.0)
+    texture = ""
+    roughness, metallic, alpha = 0.5, 0.0, 1.0
+
+    if material.use_nodes:
+        bsdf = material.node_tree.nodes.get("Principled BSDF")
+        if bsdf:
+            color = tuple(bsdf.inputs["Base Color"].default_value)
+            roughness = bsdf.inputs["Roughness"].default_value
+            metallic = bsdf.inputs["Metallic"].default_value
+            alpha = bsdf.inputs["Alpha"].default_value
+            for link in bsdf.inputs["Base Color"].links:
+                if link.from_node.type == "TEX_IMAGE" and link.from_node.image:
+                    texture = link.from_node.image.name.rsplit(".", 1)[0]
+
+    payload = (
+        pack_string(material.name)
+        + struct.pack("<4f", *color)
+        + pack_string(texture)
+        + struct.pack("<3f", roughness, metallic, alpha)
+    )
+    return chunk("MATL", payload)
+
+
+def node_chunk(obj, index_of):
+    parent = index_of.get(obj.parent.name, -1) if obj.parent else -1
+    mesh_name = obj.data.name if obj.type == "MESH" else ""
+    material_name = ""
+    if obj.type == "MESH" and obj.material_slots and obj.material_slots[0].material:
+        material_name = obj.material_slots[0].material.name
+
+    location = to_y_up(*obj.location)
+    rotation = obj.rotation_euler.to_quaternion() if obj.rotation_mode != "QUATERNION" else obj.rotation_quaternion
+    rx, ry, rz = to_y_up(rotation.x, rotation.y, rotation.z)
+    sx, sy, sz = obj.scale.x, obj.scale.z, obj.scale.y
+
+    properties = [(key, str(obj[key])) for key in obj.keys() if not key.startswith("_")]
+
+    payload = (
+        pack_string(obj.name)
+        + struct.pack("<i", parent)
+        + pack_string(mesh_name)
+        + pack_string(material_name)
+        + struct.pack("<3f", *location)
+        + struct.pack("<4f", rx, ry, rz, rotation.w)
+        + struct.pack("<3f", sx, sy, sz)
+        + struct.pack("<I", len(properties))
+        + b"".join(pack_string(key) + pack_string(value) for key, value in properties)
+    )
+    return chunk("NODE", payload)
+
+
+def ordered_objects():
+    """Return objects with every parent listed before its children."""
+    ordered = []
+
+    def visit(obj):
+        ordered.append(obj)
+        for child in obj.children:
+            visit(child)
+
+    for obj in bpy.context.scene.objects:
+        if obj.parent is None:
+            visit(obj)
+    return ordered
+
+
+def export(path):
+    objects = ordered_objects()
+    index_of = {obj.name: i for i, obj in enumerate(objects)}
+
+    chunks = []
+    exported_meshes = set()
+    for obj in objects:
+        if obj.type == "MESH" and obj.data.name not in exported_meshes:
+            exported_meshes.add(obj.data.name)
+            chunks.append(mesh_chunk(obj))
+    for material in bpy.data.materials:
+        if material.users > 0:
+            chunks.append(material_chunk(material))
+    for obj in objects:
+        chunks.append(node_chunk(obj, index_of))
+    chunks.append(chunk("END ", b""))
+
+    with open(path, "wb") as f:
+        f.write(b"WGSC" + struct.pack("<I", VERSION))
+        for c in chunks:
+            f.write(c)
+
+
+if __name__ == "__main__":
+    argv = sys.argv[sys.argv.index("--") + 1:] if "--" in sys.argv else []
+    if len(argv) != 1:
+        print("usage: blender file.blend --background --python export_scene.py -- output.wgscene")
+        sys.exit(1)
+    export(argv[0])
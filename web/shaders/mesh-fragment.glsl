@@ -1,6 +1,7 @@
 precision mediump float;
 
 varying vec3 vNormal;
+varying vec4 vTangent;
 varying vec3 vWorldPos;
 
 varying vec2 vUvs;
@@ -10,30 +11,45 @@ varying vec3 fromFragmentToCamera;
 varying vec4 worldPosition;
 uniform vec4 clipPlane;
 
-float shininess = 0.4;
-
 vec3 sunlightColor = vec3(1.0, 1.0, 1.0);
 vec3 sunlightDir = normalize(vec3(-1.0, -1.0, 0.5));
 
+// Material of the mesh; a texture is only sampled when its flag is 1
 uniform sampler2D meshTexture;
+uniform sampler2D normalTexture;
+uniform float hasDiffuseTexture;
+uniform float hasNormalTexture;
+uniform vec2 materialTiling;
+uniform float materialSpecular;
+uniform float materialShininess;
+uniform float materialOpacity;
 
 void main(void) {
     if (dot(worldPosition, clipPlane) < 0.0) {
         discard;
     }
 
+    vec2 uv = vUvs * materialTiling;
     vec3 ambient = vec3(0.24725, 0.1995, 0.0745);
 
     vec3 normal = normalize(vNormal);
+    if (hasNormalTexture > 0.5) {
+        // Re-orthogonalize the interpolated tangent frame before applying the map
+        vec3 tangent = normalize(vTangent.xyz - normal * dot(normal, vTangent.xyz));
+        vec3 bitangent = cross(normal, tangent) * vTangent.w;
+        vec3 mapped = texture2D(normalTexture, uv).rgb * 2.0 - 1.0;
+        normal = normalize(mat3(tangent, bitangent, normal) * mapped);
+    }
     float diff = max(dot(normal, -sunlightDir), 0.0);
     vec3 diffuse = diff * sunlightColor;
 
     vec3 reflectDir = reflect(-sunlightDir, normal);
-    float spec = pow(max(dot(normalize(fromFragmentToCamera), reflectDir), 0.0), 32.0);
-    vec3 specular = shininess * spec * vec3(0.628281, 0.555802, 0.366065);
+    float spec = pow(max(dot(normalize(fromFragmentToCamera), reflectDir), 0.0), max(materialShininess, 1.0));
+    vec3 specular = materialSpecular * spec * vec3(0.628281, 0.555802, 0.366065);
 
     vec4 lighting = vec4(ambient + diffuse + specular, 1.0);
-    vec4 textureColor = texture2D(meshTexture, vUvs);
+    vec4 textureColor = hasDiffuseTexture > 0.5 ? texture2D(meshTexture, uv) : vec4(1.0);
 
     gl_FragColor = textureColor * lighting;
+    gl_FragColor.a = textureColor.a * materialOpacity;
 }
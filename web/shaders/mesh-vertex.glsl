@@ -1,7 +1,8 @@
 attribute vec3 position;
 attribute vec3 normal;
+attribute vec4 tangent; // w is the bitangent sign
 
-attribute vec2 uvs;
+attribute vec2 texCoords;
 varying vec2 vUvs;
 
 uniform mat4 model;
@@ -9,6 +10,7 @@ uniform mat4 view;
 uniform mat4 perspective;
 
 varying vec3 vNormal;
+varying vec4 vTangent;
 varying vec3 vWorldPos;
 varying vec4 worldPosition;
 
@@ -21,8 +23,9 @@ void main (void) {
   gl_Position = perspective * view * worldPosition;
 
   vNormal = normal;
+  vTangent = tangent;
   vWorldPos = worldPosition.xyz;
   fromFragmentToCamera = cameraPos - worldPosition.xyz;
 
-  vUvs = uvs;
+  vUvs = texCoords;
 }
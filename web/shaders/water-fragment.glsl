@@ -17,6 +17,11 @@ uniform float dudvOffset;
 varying vec4 clipSpace;
 
 varying vec2 textureCoords;
+varying vec2 worldXZ;
+
+// Area (minX, minZ, maxX, maxZ) drawn by the next finer clipmap level
+uniform vec4 clipmapHole;
+uniform float clipmapHoleEnabled;
 
 const float waterDistortionStrength = 0.03;
 const float shineDamper = 20.0;
@@ -24,12 +29,32 @@ const float shineDamper = 20.0;
 uniform float waterReflectivity;
 uniform float fresnelStrength;
 
+// Surface layers (oil slicks, foam tint, debris) composited over the water in order
+#define MAX_SURFACE_LAYERS 4
+#define LAYER_BLEND_ALPHA 0.0
+#define LAYER_BLEND_MULTIPLY 1.0
+#define LAYER_BLEND_ADD 2.0
+uniform int surfaceLayerCount;
+uniform sampler2D surfaceLayer0;
+uniform sampler2D surfaceLayer1;
+uniform sampler2D surfaceLayer2;
+uniform sampler2D surfaceLayer3;
+uniform vec3 surfaceLayerTint[MAX_SURFACE_LAYERS];
+// x: opacity, y: scale, z: drift offset, w: blend mode
+uniform vec4 surfaceLayerParams[MAX_SURFACE_LAYERS];
+
 vec4 shallowWaterColor =  vec4(0.0, 0.1, 0.3, 1.0);
 vec4 deepWaterColor = vec4(0.0, 0.1, 0.2, 1.0);
 
 vec3 getNormal(vec2 textureCoords);
+vec3 applySurfaceLayers(vec3 color, vec2 coords);
 
 void main() {
+    if (clipmapHoleEnabled > 0.5 &&
+        all(greaterThan(worldXZ, clipmapHole.xy)) && all(lessThan(worldXZ, clipmapHole.zw))) {
+        discard;
+    }
+
     // Normalized device coordinates - Between 0 and 1
     vec2 ndc = (clipSpace.xy / clipSpace.w) / 2.0 + 0.5;
 
@@ -96,7 +121,37 @@ void main() {
 
     gl_FragColor = mix(reflectColor, refractColor, refractiveFactor);
     // Mix in a bit of blue so that it looks like water
-    gl_FragColor = mix(gl_FragColor, shallowWaterColor, 0.2) + vec4(specularHighlights, 0.0);
+    gl_FragColor = mix(gl_FragColor, shallowWaterColor, 0.2);
+    gl_FragColor.rgb = applySurfaceLayers(gl_FragColor.rgb, textureCoords + totalDistortion);
+    gl_FragColor += vec4(specularHighlights, 0.0);
+}
+
+vec4 sampleSurfaceLayer(int layer, vec2 coords) {
+    // Sampler arrays cannot be indexed dynamically in GLSL ES 1.0
+    if (layer == 0) return texture2D(surfaceLayer0, coords);
+    if (layer == 1) return texture2D(surfaceLayer1, coords);
+    if (layer == 2) return texture2D(surfaceLayer2, coords);
+    return texture2D(surfaceLayer3, coords);
+}
+
+vec3 applySurfaceLayers(vec3 color, vec2 coords) {
+    for (int i = 0; i < MAX_SURFACE_LAYERS; i++) {
+        if (i >= surfaceLayerCount) break;
+
+        vec4 params = surfaceLayerParams[i];
+        vec4 texel = sampleSurfaceLayer(i, coords * params.y + vec2(params.z));
+        vec3 layerColor = texel.rgb * surfaceLayerTint[i];
+        float coverage = texel.a * params.x;
+
+        if (params.w == LAYER_BLEND_MULTIPLY) {
+            color = mix(color, color * layerColor, coverage);
+        } else if (params.w == LAYER_BLEND_ADD) {
+            color += layerColor * coverage;
+        } else {
+            color = mix(color, layerColor, coverage);
+        }
+    }
+    return color;
 }
 
 vec3 getNormal(vec2 textureCoords) {
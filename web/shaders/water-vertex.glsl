@@ -5,23 +5,43 @@ uniform mat4 model;
 uniform mat4 view;
 
 uniform vec3 cameraPos;
+// 1 when position holds the NDC coordinates of a projected grid (x, z) rather than a model position
+uniform float projectedGrid;
 varying vec3 fromFragmentToCamera;
 
 varying vec4 clipSpace;
 varying vec2 textureCoords;
+varying vec2 worldXZ;
 
 const float tiling = 4.0;
 
+// projectGridVertex casts a ray from the camera through a point on the screen
+// and returns where it meets the water at y = 0. Rays at or above the horizon
+// are bent just below it, so the grid always ends at the horizon.
+vec4 projectGridVertex(vec2 ndc) {
+    vec3 rayView = vec3(ndc.x / perspective[0][0], ndc.y / perspective[1][1], -1.0);
+    // The view matrix is a rotation and translation, so its transpose rotates back to world space
+    vec3 ray = rayView * mat3(view);
+    float side = cameraPos.y >= 0.0 ? -1.0 : 1.0;
+    ray.y = side * max(side * ray.y, 0.001 * length(ray.xz));
+    float t = -cameraPos.y / ray.y;
+    return vec4(cameraPos + ray * t, 1.0);
+}
+
 void main() {
-    vec4 worldPosition = model * vec4(position.x, position.y, position.z, 1.0);
+    vec4 worldPosition = projectedGrid > 0.5
+        ? projectGridVertex(position.xz)
+        : model * vec4(position.x, position.y, position.z, 1.0);
 
     clipSpace = perspective * view *  worldPosition;
 
     gl_Position = clipSpace;
 
     // (-0.5 < pos < 0.5) -> (0.0 < pos < 1.0)
-    textureCoords = position.xz + 0.5;
+    // World space, so textures line up across the scaled and moved clipmap grids
+    textureCoords = worldPosition.xz + 0.5;
     textureCoords = textureCoords * tiling;
+    worldXZ = worldPosition.xz;
 
     fromFragmentToCamera = cameraPos - worldPosition.xyz;
 }
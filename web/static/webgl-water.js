@@ -3,6 +3,36 @@
  * Go Port of the original Rust/WASM implementation
  */
 
+// Must match ProtocolVersion in internal/app/protocol.go
+const PROTOCOL_VERSION = 2;
+
+// Keys the server moves the camera with while they are held (KeyboardEvent.code):
+// WASD pans the camera target, Q/E orbit and the arrows pitch
+const CAMERA_KEYS = new Set(["KeyW", "KeyA", "KeyS", "KeyD", "KeyQ", "KeyE", "ArrowUp", "ArrowDown"]);
+
+// How often gamepad axes are resent while a stick is deflected, in milliseconds,
+// well within the server's timeout
+const GAMEPAD_RESEND_INTERVAL = 250;
+
+// Key switching between the orbit and walk cameras
+const WALK_TOGGLE_KEY = "KeyF";
+
+// Key cycling the active camera through the built-in cameras
+const CAMERA_CYCLE_KEY = "KeyC";
+const BUILT_IN_CAMERAS = ["main", "overview", "reflection-debug"];
+
+// Random per-tab ID the server's opt-in analytics group interactions by
+function sessionId() {
+  let id = sessionStorage.getItem("webgl-water-session");
+  if (!id) {
+    id = Array.from(crypto.getRandomValues(new Uint8Array(12)), (b) =>
+      b.toString(16).padStart(2, "0"),
+    ).join("");
+    sessionStorage.setItem("webgl-water-session", id);
+  }
+  return id;
+}
+
 class WebGLWaterApp {
   constructor() {
     this.canvas = null;
@@ -10,6 +40,8 @@ class WebGLWaterApp {
     this.shaders = {};
     this.programs = {};
     this.meshes = {};
+    this.materials = {};
+    this.meshMaterials = {}; // Material name by mesh name
     this.textures = {};
     this.framebuffers = {};
 
@@ -58,6 +90,8 @@ class WebGLWaterApp {
       console.log("✅ Canvas setup complete");
       this.setupWebGL();
       console.log("✅ WebGL context created");
+      await this.waitForAssets();
+      console.log("✅ Server assets ready");
       await this.loadShaders();
       console.log("✅ Shaders loaded");
       await this.loadAssets();
@@ -121,6 +155,7 @@ class WebGLWaterApp {
     // Get extensions
     this.vaoExt = gl.getExtension("OES_vertex_array_object");
     this.depthTextureExt = gl.getExtension("WEBGL_depth_texture");
+    this.uintIndexExt = gl.getExtension("OES_element_index_uint");
 
     if (!this.vaoExt) {
       console.warn("⚠️ VAO extension not available");
@@ -135,6 +170,37 @@ class WebGLWaterApp {
     }
   }
 
+  // Polls the server's load progress until its assets are ready, showing it on
+  // the loading screen
+  async waitForAssets() {
+    const overlay = document.getElementById("loading");
+    const text = document.getElementById("loading-text");
+    const fill = document.getElementById("loading-fill");
+
+    for (;;) {
+      const response = await fetch("/api/assets/status", { cache: "no-store" });
+      const progress = await response.json();
+      if (progress.ready) {
+        break;
+      }
+      if (progress.error) {
+        if (text) text.textContent = `Failed to load assets: ${progress.error}`;
+        throw new Error(`Server failed to load assets: ${progress.error}`);
+      }
+
+      const phase = progress.total > 0 ? progress.done / progress.total : 0;
+      const fraction = progress.steps > 0 ? (Math.max(progress.step - 1, 0) + phase) / progress.steps : 0;
+      if (text) {
+        const current = progress.current ? ` (${progress.current})` : "";
+        text.textContent = `Loading ${progress.phase}${current}...`;
+      }
+      if (fill) fill.style.width = `${Math.round(fraction * 100)}%`;
+      await new Promise((resolve) => setTimeout(resolve, 250));
+    }
+
+    if (overlay) overlay.style.display = "none";
+  }
+
   async loadShaders() {
     const shaderNames = [
       "water-vertex",
@@ -148,7 +214,7 @@ class WebGLWaterApp {
     // Load shader sources
     for (const name of shaderNames) {
       try {
-        const response = await fetch(`/shaders/${name}.glsl`);
+        const response = await fetch(`/shaders/${name}.glsl`, { cache: "no-cache" });
         if (!response.ok) {
           throw new Error(`Failed to load shader: ${name}`);
         }
@@ -240,14 +306,15 @@ class WebGLWaterApp {
 
     for (const meshName of meshData.meshes) {
       console.log(`📦 Loading mesh: ${meshName}`);
-      const response = await fetch(`/api/meshes/${meshName}`);
+      const response = await fetch(`/api/meshes/${meshName}.bin`);
       if (!response.ok) {
         throw new Error(`Failed to load mesh ${meshName}: ${response.status}`);
       }
-      const mesh = await response.json();
-      this.meshes[meshName] = this.createMeshBuffers(mesh);
+      const mesh = this.createMeshBuffers(meshName, await response.arrayBuffer());
+      this.meshes[meshName] = mesh;
       console.log(`✅ Mesh loaded: ${meshName} (${mesh.vertexCount} vertices)`);
     }
+    await this.loadMaterials();
 
     // Load textures
     const textureNames = [
@@ -261,49 +328,83 @@ class WebGLWaterApp {
     }
   }
 
-  createMeshBuffers(meshData) {
+  // Fetch every material and the assignment of materials to meshes.
+  // Their textures are loaded when a mesh first renders with them.
+  async loadMaterials() {
+    const [listResponse, meshResponse] = await Promise.all([
+      fetch("/api/materials"),
+      fetch("/api/meshes"),
+    ]);
+    const { materials } = await listResponse.json();
+    const meshData = await meshResponse.json();
+
+    const loaded = {};
+    for (const name of materials) {
+      const response = await fetch(`/api/materials/${name}`);
+      if (response.ok) {
+        loaded[name] = await response.json();
+      }
+    }
+    this.materials = loaded;
+    this.meshMaterials = meshData.materials || {};
+  }
+
+  // Upload a mesh in the server's binary format (/api/meshes/{name}.bin): a
+  // 28-byte little-endian header followed by interleaved float32 vertices and
+  // 16- or 32-bit indices
+  createMeshBuffers(name, data) {
     const gl = this.gl;
+    const header = new DataView(data, 0, 28);
+    const magic = String.fromCharCode(...new Uint8Array(data, 0, 4));
+    if (magic !== "WGMB" || header.getUint32(4, true) !== 1) {
+      throw new Error(`Mesh ${name} is not in a supported binary format`);
+    }
+    const attributes = header.getUint32(8, true);
+    const stride = header.getUint32(12, true);
+    const vertexCount = header.getUint32(16, true);
+    const indexCount = header.getUint32(20, true);
+    const indexWidth = header.getUint32(24, true);
 
     const mesh = {
       vertexBuffer: gl.createBuffer(),
-      normalBuffer: gl.createBuffer(),
-      texCoordBuffer: gl.createBuffer(),
       indexBuffer: gl.createBuffer(),
-      vertexCount: meshData.vertexCount,
-      indexCount: meshData.indices.length,
+      vertexCount,
+      indexCount,
+      indexType: gl.UNSIGNED_SHORT,
+      stride,
+      offsets: {},
     };
 
-    // Vertex positions
-    gl.bindBuffer(gl.ARRAY_BUFFER, mesh.vertexBuffer);
-    gl.bufferData(
-      gl.ARRAY_BUFFER,
-      new Float32Array(meshData.vertices),
-      gl.STATIC_DRAW,
-    );
+    // Byte offset of each attribute within a vertex, in the order the server interleaves them
+    let offset = 0;
+    for (const [flag, attribute, components] of [
+      [1, "position", 3],
+      [2, "normal", 3],
+      [4, "texCoords", 2],
+      [8, "tangent", 4],
+    ]) {
+      if (attributes & flag) {
+        mesh.offsets[attribute] = offset;
+        offset += components * 4;
+      }
+    }
 
-    // Normals
-    gl.bindBuffer(gl.ARRAY_BUFFER, mesh.normalBuffer);
-    gl.bufferData(
-      gl.ARRAY_BUFFER,
-      new Float32Array(meshData.normals),
-      gl.STATIC_DRAW,
-    );
+    // Meshes over 65536 vertices need 32-bit indices
+    const verticesEnd = 28 + vertexCount * stride;
+    let indices = new Uint16Array(data, verticesEnd, indexCount);
+    if (indexWidth === 32) {
+      if (!this.uintIndexExt) {
+        throw new Error(`Mesh ${name} needs 32-bit indices, which are not supported`);
+      }
+      indices = new Uint32Array(data, verticesEnd, indexCount);
+      mesh.indexType = gl.UNSIGNED_INT;
+    }
 
-    // Texture coordinates
-    gl.bindBuffer(gl.ARRAY_BUFFER, mesh.texCoordBuffer);
-    gl.bufferData(
-      gl.ARRAY_BUFFER,
-      new Float32Array(meshData.texCoords),
-      gl.STATIC_DRAW,
-    );
+    gl.bindBuffer(gl.ARRAY_BUFFER, mesh.vertexBuffer);
+    gl.bufferData(gl.ARRAY_BUFFER, new Uint8Array(data, 28, vertexCount * stride), gl.STATIC_DRAW);
 
-    // Indices
     gl.bindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.indexBuffer);
-    gl.bufferData(
-      gl.ELEMENT_ARRAY_BUFFER,
-      new Uint16Array(meshData.indices),
-      gl.STATIC_DRAW,
-    );
+    gl.bufferData(gl.ELEMENT_ARRAY_BUFFER, indices, gl.STATIC_DRAW);
 
     return mesh;
   }
@@ -350,6 +451,40 @@ class WebGLWaterApp {
     });
   }
 
+  // Re-fetch an asset the server reloaded after its file changed
+  async reloadAsset(kind, name) {
+    try {
+      if (kind === "texture") {
+        const response = await fetch(`/api/textures/${name}`);
+        if (!response.ok) {
+          return;
+        }
+        const texture = await response.json();
+        const previous = this.textures[name];
+        await this.loadTexture(name, `/assets/${texture.filePath}?v=${Date.now()}`);
+        if (previous) {
+          this.gl.deleteTexture(previous);
+        }
+      } else if (kind === "mesh" && this.meshes[name]) {
+        const response = await fetch(`/api/meshes/${name}.bin`);
+        if (!response.ok) {
+          return;
+        }
+        this.meshes[name] = this.createMeshBuffers(name, await response.arrayBuffer());
+      } else if (kind === "shader") {
+        await this.loadShaders();
+      } else if (kind === "material") {
+        await this.loadMaterials();
+      } else if (kind === "collection") {
+        // Every mesh, material and texture may differ in the activated collection
+        await this.loadAssets();
+      }
+      console.log(`🔄 Reloaded ${kind}: ${name}`);
+    } catch (error) {
+      console.error(`❌ Error reloading ${kind} ${name}:`, error);
+    }
+  }
+
   setupFramebuffers() {
     this.framebuffers.reflection = this.createFramebuffer(
       this.REFLECTION_TEXTURE_WIDTH,
@@ -442,6 +577,19 @@ class WebGLWaterApp {
     this.canvas.addEventListener("mousemove", this.onMouseMove.bind(this));
     this.canvas.addEventListener("wheel", this.onWheel.bind(this));
 
+    // Touch controls
+    this.lastTouches = null;
+    this.canvas.addEventListener("touchstart", this.onTouch.bind(this), { passive: false });
+    this.canvas.addEventListener("touchmove", this.onTouch.bind(this), { passive: false });
+    this.canvas.addEventListener("touchend", this.onTouch.bind(this), { passive: false });
+    this.canvas.addEventListener("touchcancel", this.onTouch.bind(this), { passive: false });
+
+    // Keyboard controls
+    this.heldKeys = new Set();
+    window.addEventListener("keydown", this.onKeyDown.bind(this));
+    window.addEventListener("keyup", this.onKeyUp.bind(this));
+    window.addEventListener("blur", this.releaseKeys.bind(this));
+
     // UI controls
     this.setupUIControls();
   }
@@ -519,6 +667,122 @@ class WebGLWaterApp {
     });
   }
 
+  // One finger orbits; two fingers pinch to zoom and turn to rotate. Gestures
+  // are sent as changes since the previous touch event.
+  onTouch(event) {
+    event.preventDefault();
+    const touches = Array.from(event.touches, (t) => ({ x: t.clientX, y: t.clientY }));
+    const last = this.lastTouches;
+    this.lastTouches = touches;
+    if (event.type !== "touchmove" || !last || last.length !== touches.length) {
+      return;
+    }
+
+    if (touches.length === 1) {
+      this.sendCameraUpdate({
+        touchOrbit: {
+          dx: Math.round(touches[0].x - last[0].x),
+          dy: Math.round(touches[0].y - last[0].y),
+        },
+      });
+    } else if (touches.length === 2) {
+      const span = (t) => Math.hypot(t[1].x - t[0].x, t[1].y - t[0].y);
+      // Screen Y points down, so negate it for counterclockwise angles
+      const angle = (t) => Math.atan2(t[0].y - t[1].y, t[1].x - t[0].x);
+      let rotate = angle(touches) - angle(last);
+      rotate = Math.atan2(Math.sin(rotate), Math.cos(rotate));
+      const update = { rotate };
+      if (span(last) > 0 && span(touches) > 0) {
+        update.pinch = span(touches) / span(last);
+      }
+      this.sendCameraUpdate(update);
+    }
+  }
+
+  onKeyDown(event) {
+    // Ctrl+Z undoes settings changes, Ctrl+Shift+Z and Ctrl+Y redo them
+    if ((event.ctrlKey || event.metaKey) && !event.repeat && event.target.tagName !== "INPUT") {
+      if (event.code === "KeyZ" || event.code === "KeyY") {
+        event.preventDefault();
+        this.sendHistory(event.code === "KeyY" || event.shiftKey ? "redo" : "undo");
+        return;
+      }
+    }
+    if (event.code === WALK_TOGGLE_KEY && !event.repeat && event.target.tagName !== "INPUT") {
+      const walking = this.state && this.state.camera && this.state.camera.mode === "walk";
+      this.sendCameraUpdate({ mode: walking ? "orbit" : "walk" });
+      return;
+    }
+    if (event.code === CAMERA_CYCLE_KEY && !event.repeat && event.target.tagName !== "INPUT") {
+      const current = BUILT_IN_CAMERAS.indexOf(this.state.activeCamera);
+      this.activateCamera(BUILT_IN_CAMERAS[(current + 1) % BUILT_IN_CAMERAS.length]);
+      return;
+    }
+    // Digits recall camera presets; with Shift they save the current camera
+    const digit = /^Digit([1-9])$/.exec(event.code);
+    if (digit && !event.repeat && event.target.tagName !== "INPUT") {
+      this.sendCameraPreset(digit[1], event.shiftKey);
+      return;
+    }
+    if (!CAMERA_KEYS.has(event.code) || event.target.tagName === "INPUT") {
+      return;
+    }
+    event.preventDefault();
+
+    // Repeats keep the key held on the server
+    this.heldKeys.add(event.code);
+    this.sendKey("key_down", event.code);
+  }
+
+  onKeyUp(event) {
+    if (!this.heldKeys.delete(event.code)) {
+      return;
+    }
+    this.sendKey("key_up", event.code);
+  }
+
+  // Releases every held key, as no keyup arrives once the window loses focus
+  releaseKeys() {
+    for (const code of this.heldKeys) {
+      this.sendKey("key_up", code);
+    }
+    this.heldKeys.clear();
+  }
+
+  // Sends the sticks of the first gamepad with the standard mapping: the left
+  // stick orbits and the right stick's vertical axis zooms. Axes are sent when
+  // they change and resent while deflected so the server keeps applying them.
+  pollGamepad() {
+    if (!navigator.getGamepads || !this.ws || this.ws.readyState !== WebSocket.OPEN) {
+      return;
+    }
+    const pad = Array.from(navigator.getGamepads()).find((p) => p && p.mapping === "standard");
+    if (!pad) {
+      return;
+    }
+
+    const axes = { orbitX: pad.axes[0], orbitY: -pad.axes[1], zoom: pad.axes[3] };
+    const last = this.lastGamepad;
+    const now = performance.now();
+    const deflected = Object.values(axes).some((v) => Math.abs(v) > 0.1);
+    const changed = !last || Object.keys(axes).some((k) => Math.abs(axes[k] - last.axes[k]) > 0.02);
+    if (!changed && (!deflected || now - last.sent < GAMEPAD_RESEND_INTERVAL)) {
+      return;
+    }
+    this.lastGamepad = { axes, sent: now };
+    this.ws.send(JSON.stringify({ type: "gamepad", gamepad: axes }));
+  }
+
+  // Key messages go over the WebSocket when it is open, so the server
+  // releases held keys if the connection drops
+  sendKey(type, code) {
+    if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+      this.ws.send(JSON.stringify({ type, key: code }));
+    } else {
+      this.sendCameraUpdate(type === "key_down" ? { keyDown: code } : { keyUp: code });
+    }
+  }
+
   async updateWaterProperty(property, value) {
     const update = {};
     update[property] = value;
@@ -528,6 +792,8 @@ class WebGLWaterApp {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
+          "X-Protocol-Version": String(PROTOCOL_VERSION),
+          "X-Session-ID": sessionId(),
         },
         body: JSON.stringify(update),
       });
@@ -548,6 +814,8 @@ class WebGLWaterApp {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
+          "X-Protocol-Version": String(PROTOCOL_VERSION),
+          "X-Session-ID": sessionId(),
         },
         body: JSON.stringify(update),
       });
@@ -556,14 +824,60 @@ class WebGLWaterApp {
     }
   }
 
+  async activateCamera(name) {
+    try {
+      await fetch(`/api/state/cameras/${encodeURIComponent(name)}/activate`, {
+        method: "POST",
+        headers: {
+          "X-Protocol-Version": String(PROTOCOL_VERSION),
+          "X-Session-ID": sessionId(),
+        },
+      });
+    } catch (error) {
+      console.error("Failed to activate camera:", error);
+    }
+  }
+
+  async sendHistory(action) {
+    try {
+      await fetch(`/api/state/${action}`, {
+        method: "POST",
+        headers: {
+          "X-Protocol-Version": String(PROTOCOL_VERSION),
+          "X-Session-ID": sessionId(),
+        },
+      });
+    } catch (error) {
+      console.error(`Failed to ${action}:`, error);
+    }
+  }
+
+  async sendCameraPreset(name, save) {
+    const path = `/api/state/camera/presets/${encodeURIComponent(name)}`;
+    try {
+      await fetch(save ? path : `${path}/recall`, {
+        method: save ? "PUT" : "POST",
+        headers: {
+          "X-Protocol-Version": String(PROTOCOL_VERSION),
+          "X-Session-ID": sessionId(),
+        },
+      });
+    } catch (error) {
+      console.error("Failed to update camera preset:", error);
+    }
+  }
+
   connectWebSocket() {
     const protocol = location.protocol === "https:" ? "wss:" : "ws:";
-    const wsUrl = `${protocol}//${location.host}/ws`;
+    const wsUrl = `${protocol}//${location.host}/ws?protocol=${PROTOCOL_VERSION}&session=${sessionId()}`;
 
     this.ws = new WebSocket(wsUrl);
 
     this.ws.onopen = () => {
       console.log("WebSocket connected");
+      if (document.hidden) {
+        this.ws.send(JSON.stringify({ type: "sleep" }));
+      }
     };
 
     this.ws.onmessage = (event) => {
@@ -571,6 +885,13 @@ class WebGLWaterApp {
         const data = JSON.parse(event.data);
         if (data.type === "state_update") {
           this.state = { ...this.state, ...data };
+        } else if (data.type === "asset_changed") {
+          this.reloadAsset(data.kind, data.name);
+        } else if (data.type === "reload_required") {
+          this.reloadRequired = true;
+          if (confirm("The server has been updated. Reload the page to continue?")) {
+            location.reload();
+          }
         }
       } catch (error) {
         console.error("Error parsing WebSocket message:", error);
@@ -581,7 +902,20 @@ class WebGLWaterApp {
       console.error("WebSocket error:", error);
     };
 
+    // Stop the server streaming to a backgrounded tab; it sends a full state on wake
+    if (!this.visibilityListener) {
+      this.visibilityListener = () => {
+        if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+          this.ws.send(JSON.stringify({ type: document.hidden ? "sleep" : "wake" }));
+        }
+      };
+      document.addEventListener("visibilitychange", this.visibilityListener);
+    }
+
     this.ws.onclose = () => {
+      if (this.reloadRequired) {
+        return;
+      }
       console.log("WebSocket disconnected, attempting to reconnect...");
       setTimeout(() => {
         this.connectWebSocket();
@@ -618,6 +952,8 @@ class WebGLWaterApp {
     // Render main scene
     this.renderMainScene();
 
+    this.pollGamepad();
+
     // Continue render loop
     requestAnimationFrame(this.render.bind(this));
   }
@@ -672,7 +1008,9 @@ class WebGLWaterApp {
   renderWater() {
     const gl = this.gl;
     const program = this.programs.water;
-    const mesh = this.meshes.water_plane;
+    // Large oceans are drawn as clipmap levels around the camera when the server sends a layout
+    const clipmap = this.meshes.clipmap_grid ? this.state.clipmap : null;
+    const mesh = clipmap ? this.meshes.clipmap_grid : this.meshes.water_plane;
 
     if (!program || !mesh) return;
 
@@ -686,6 +1024,12 @@ class WebGLWaterApp {
     const viewMatrix = this.getViewMatrix();
     const modelMatrix = this.getIdentityMatrix();
     const cameraPos = this.state.camera.position;
+    // The radial mesh follows the camera; the projected grid is placed by the vertex shader
+    const topology = clipmap ? "grid" : (this.state.waterMesh || {}).topology;
+    if (topology === "radial") {
+      modelMatrix[12] = cameraPos[0];
+      modelMatrix[14] = cameraPos[2];
+    }
 
     gl.uniformMatrix4fv(
       program.uniformLocations.perspective,
@@ -695,6 +1039,7 @@ class WebGLWaterApp {
     gl.uniformMatrix4fv(program.uniformLocations.view, false, viewMatrix);
     gl.uniformMatrix4fv(program.uniformLocations.model, false, modelMatrix);
     gl.uniform3fv(program.uniformLocations.cameraPos, cameraPos);
+    gl.uniform1f(program.uniformLocations.projectedGrid, topology === "projected" ? 1 : 0);
 
     // Water-specific uniforms
     const dudvOffset = (this.state.clock / 1000.0) * this.state.water.waveSpeed;
@@ -724,8 +1069,80 @@ class WebGLWaterApp {
     this.bindTexture(gl.TEXTURE4, this.framebuffers.refraction.depthTexture);
     gl.uniform1i(program.uniformLocations.waterDepthTexture, 4);
 
+    this.bindSurfaceLayers(program, dudvOffset);
+
     // Draw
-    gl.drawElements(gl.TRIANGLES, mesh.indexCount, gl.UNSIGNED_SHORT, 0);
+    if (!clipmap) {
+      gl.uniform1f(program.uniformLocations.clipmapHoleEnabled, 0);
+      gl.drawElements(gl.TRIANGLES, mesh.indexCount, mesh.indexType, 0);
+      return;
+    }
+    for (const level of clipmap.levels) {
+      const s = level.spacing;
+      const levelMatrix = new Float32Array([
+        s, 0, 0, 0,
+        0, 1, 0, 0,
+        0, 0, s, 0,
+        level.origin[0], 0, level.origin[1], 1,
+      ]);
+      gl.uniformMatrix4fv(program.uniformLocations.model, false, levelMatrix);
+      gl.uniform1f(program.uniformLocations.clipmapHoleEnabled, level.hole ? 1 : 0);
+      if (level.hole) {
+        gl.uniform4fv(program.uniformLocations.clipmapHole, level.hole);
+      }
+      gl.drawElements(gl.TRIANGLES, mesh.indexCount, mesh.indexType, 0);
+    }
+  }
+
+  // Bind the surface layers from the server state to texture units 5-8.
+  // Layers whose texture has not loaded yet are skipped for now.
+  bindSurfaceLayers(program, dudvOffset) {
+    const gl = this.gl;
+    const blendModes = { alpha: 0, multiply: 1, add: 2 };
+
+    let count = 0;
+    for (const layer of this.state.layers || []) {
+      const texture = this.textures[layer.texture];
+      if (!texture) {
+        this.loadLayerTexture(layer.texture);
+        continue;
+      }
+      if (count >= 4) break;
+
+      this.bindTexture(gl.TEXTURE5 + count, texture);
+      gl.uniform1i(program.uniformLocations[`surfaceLayer${count}`], 5 + count);
+      gl.uniform3fv(
+        gl.getUniformLocation(program, `surfaceLayerTint[${count}]`),
+        layer.tint,
+      );
+      gl.uniform4f(
+        gl.getUniformLocation(program, `surfaceLayerParams[${count}]`),
+        layer.opacity,
+        layer.scale,
+        (dudvOffset * layer.drift) % 1.0,
+        blendModes[layer.blend] || 0,
+      );
+      count++;
+    }
+    gl.uniform1i(program.uniformLocations.surfaceLayerCount, count);
+  }
+
+  // Load a texture used by a surface layer or material once, looking up its file from the API
+  async loadLayerTexture(name) {
+    this.pendingTextures = this.pendingTextures || new Set();
+    if (this.pendingTextures.has(name)) return;
+    this.pendingTextures.add(name);
+
+    try {
+      const response = await fetch(`/api/textures/${name}`);
+      if (!response.ok) {
+        throw new Error(`Unknown texture ${name}`);
+      }
+      const texture = await response.json();
+      await this.loadTexture(name, `/assets/${texture.filePath}`);
+    } catch (error) {
+      console.error(`❌ Error loading layer texture ${name}:`, error);
+    }
   }
 
   renderMeshes(clipPlane, mirror) {
@@ -762,12 +1179,57 @@ class WebGLWaterApp {
     gl.uniform3fv(program.uniformLocations.cameraPos, cameraPos);
     gl.uniform4fv(program.uniformLocations.clipPlane, clipPlane);
 
-    // Bind texture
-    this.bindTexture(gl.TEXTURE0, this.textures.stone);
-    gl.uniform1i(program.uniformLocations.tex, 0);
+    const material = this.bindMaterial(program, this.meshMaterials.terrain);
 
-    // Draw
-    gl.drawElements(gl.TRIANGLES, mesh.indexCount, gl.UNSIGNED_SHORT, 0);
+    // Draw, blending translucent materials over what is behind them
+    const translucent = material.opacity < 1;
+    if (translucent) {
+      gl.enable(gl.BLEND);
+      gl.blendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA);
+    }
+    gl.drawElements(gl.TRIANGLES, mesh.indexCount, mesh.indexType, 0);
+    if (translucent) {
+      gl.disable(gl.BLEND);
+    }
+  }
+
+  // Set the textures and surface uniforms of the mesh program from a material.
+  // A missing material or texture that is still loading shades with the defaults.
+  bindMaterial(program, name) {
+    const gl = this.gl;
+    const material = this.materials[name] || {
+      tiling: [1, 1],
+      specular: 0.4,
+      shininess: 32,
+      opacity: 1,
+    };
+
+    const textureFor = (textureName) => {
+      if (!textureName) return null;
+      if (!this.textures[textureName]) {
+        this.loadLayerTexture(textureName);
+      }
+      return this.textures[textureName] || null;
+    };
+    const diffuse = textureFor(material.diffuseTexture);
+    const normal = textureFor(material.normalTexture);
+
+    if (diffuse) {
+      this.bindTexture(gl.TEXTURE0, diffuse);
+      gl.uniform1i(program.uniformLocations.meshTexture, 0);
+    }
+    gl.uniform1f(program.uniformLocations.hasDiffuseTexture, diffuse ? 1 : 0);
+    if (normal) {
+      this.bindTexture(gl.TEXTURE1, normal);
+      gl.uniform1i(program.uniformLocations.normalTexture, 1);
+    }
+    gl.uniform1f(program.uniformLocations.hasNormalTexture, normal ? 1 : 0);
+
+    gl.uniform2fv(program.uniformLocations.materialTiling, material.tiling);
+    gl.uniform1f(program.uniformLocations.materialSpecular, material.specular);
+    gl.uniform1f(program.uniformLocations.materialShininess, material.shininess);
+    gl.uniform1f(program.uniformLocations.materialOpacity, material.opacity);
+    return material;
   }
 
   renderDebugViews() {
@@ -877,43 +1339,24 @@ class WebGLWaterApp {
   bindMeshAttributes(program, mesh) {
     const gl = this.gl;
 
-    if (program.attribLocations.position !== undefined) {
-      gl.bindBuffer(gl.ARRAY_BUFFER, mesh.vertexBuffer);
-      gl.enableVertexAttribArray(program.attribLocations.position);
-      gl.vertexAttribPointer(
-        program.attribLocations.position,
-        3,
-        gl.FLOAT,
-        false,
-        0,
-        0,
-      );
-    }
-
-    if (program.attribLocations.normal !== undefined) {
-      gl.bindBuffer(gl.ARRAY_BUFFER, mesh.normalBuffer);
-      gl.enableVertexAttribArray(program.attribLocations.normal);
-      gl.vertexAttribPointer(
-        program.attribLocations.normal,
-        3,
-        gl.FLOAT,
-        false,
-        0,
-        0,
-      );
-    }
-
-    if (program.attribLocations.texCoords !== undefined) {
-      gl.bindBuffer(gl.ARRAY_BUFFER, mesh.texCoordBuffer);
-      gl.enableVertexAttribArray(program.attribLocations.texCoords);
-      gl.vertexAttribPointer(
-        program.attribLocations.texCoords,
-        2,
-        gl.FLOAT,
-        false,
-        0,
-        0,
-      );
+    gl.bindBuffer(gl.ARRAY_BUFFER, mesh.vertexBuffer);
+    for (const [attribute, components] of [
+      ["position", 3],
+      ["normal", 3],
+      ["texCoords", 2],
+      ["tangent", 4],
+    ]) {
+      const location = program.attribLocations[attribute];
+      const offset = mesh.offsets[attribute];
+      if (location === undefined) {
+        continue;
+      }
+      if (offset === undefined) {
+        gl.disableVertexAttribArray(location);
+        continue;
+      }
+      gl.enableVertexAttribArray(location);
+      gl.vertexAttribPointer(location, components, gl.FLOAT, false, mesh.stride, offset);
     }
 
     gl.bindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.indexBuffer);